@@ -2,16 +2,35 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
 	"fmt"
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/compliance"
 	"github.com/CryptoCrowd/internal/config"
 	"github.com/CryptoCrowd/internal/db"
 	"github.com/CryptoCrowd/internal/handler"
+	"github.com/CryptoCrowd/internal/kyc"
 	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/mailer"
+	"github.com/CryptoCrowd/internal/metrics"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/notification"
+	"github.com/CryptoCrowd/internal/ratelimit"
+	"github.com/CryptoCrowd/internal/realtime"
 	"github.com/CryptoCrowd/internal/repository"
 	"github.com/CryptoCrowd/internal/router"
+	"github.com/CryptoCrowd/internal/screening"
 	"github.com/CryptoCrowd/internal/service"
+	"github.com/CryptoCrowd/internal/tracing"
+	"github.com/CryptoCrowd/internal/worker"
 	"github.com/CryptoCrowd/migrate"
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -19,43 +38,115 @@ import (
 )
 
 const (
-	configPath = "config.json"
+	// expiredCampaignsInterval - период проверки проектов с истекшим сроком сбора
+	expiredCampaignsInterval = 5 * time.Minute
+	// rateLimitPruneInterval - период удаления восполненных корзин ограничения частоты запросов
+	rateLimitPruneInterval = 10 * time.Minute
+	// accountErasureInterval - период исполнения запросов на удаление персональных данных
+	accountErasureInterval = time.Hour
+	// deletedPurgeInterval - период окончательного удаления записей с истекшим сроком хранения
+	deletedPurgeInterval = 24 * time.Hour
+	// idempotencyPruneInterval - период удаления ключей идемпотентности с истекшим сроком
+	idempotencyPruneInterval = time.Hour
+	// configCheckInterval - период проверки изменения файла конфигурации
+	configCheckInterval = 10 * time.Second
+	// tracingShutdownTimeout - время на отправку накопленных спанов при завершении
+	tracingShutdownTimeout = 5 * time.Second
 )
 
 type repositories struct {
 	accRepo  *repository.PostgresAccount
 	projRepo *repository.PostgresProject
 	invRepo  *repository.PostgresInvestment
+	notRepo  *repository.PostgresNotification
+	tokRepo  *repository.PostgresAccountToken
+	tfaRepo  *repository.PostgresTwoFactor
+	sesRepo  *repository.PostgresSession
+	nonRepo  *repository.PostgresSIWENonce
+	walRepo  *repository.PostgresWallet
+	keyRepo  *repository.PostgresAPIKey
+	audRepo  *repository.PostgresAudit
+	kycRepo  *repository.PostgresKYC
+	irRepo   *repository.PostgresInvestmentRules
+	scrRepo  *repository.PostgresScreening
+	prvRepo  *repository.PostgresPrivacy
+	idmRepo  *repository.PostgresIdempotency
 }
 
 type services struct {
 	accService  *service.Account
 	projService *service.Project
 	invService  *service.Investment
+	notService  *service.Notification
+	authService *service.Auth
+	tfaService  *service.TwoFactor
+	sesService  *service.Session
+	walService  *service.WalletAuth
+	keyService  *service.APIKey
+	audService  *service.Audit
+	kycService  *service.KYC
+	scrService  *service.Screening
+	prvService  *service.Privacy
+	retService  *service.Retention
+	idmService  *service.Idempotency
+	rtmService  *service.Runtime
+	hltService  *service.Health
 }
 
 type handlers struct {
 	accHandler  *handler.AccountHandler
 	projHandler *handler.ProjectHandler
 	invHandler  *handler.InvestmentHandler
+	notHandler  *handler.NotificationHandler
+	rtHandler   *handler.RealtimeHandler
+	authHandler *handler.AuthHandler
+	tfaHandler  *handler.TwoFactorHandler
+	sesHandler  *handler.SessionHandler
+	walHandler  *handler.WalletHandler
+	keyHandler  *handler.APIKeyHandler
+	audHandler  *handler.AuditHandler
+	kycHandler  *handler.KYCHandler
+	scrHandler  *handler.ScreeningHandler
+	prvHandler  *handler.PrivacyHandler
+	rtmHandler  *handler.RuntimeHandler
+	hltHandler  *handler.HealthHandler
 }
 
 func main() {
 	ctx, mainCtxCancel := context.WithCancel(context.Background())
 	defer mainCtxCancel()
 
-	cfg, err := config.Load(configPath)
+	configPath := flag.String("config", "", "путь к файлу конфигурации в формате JSON, YAML или TOML")
+	flag.Parse()
+
+	// До инициализации логгера глобальный логгер ничего не пишет, поэтому ошибки
+	// конфигурации выводятся напрямую в stderr
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		logger.Fatalf("ошибка загрузки конфигурации: %v", err)
+		exitf("ошибка загрузки конфигурации: %v", err)
+	}
+	if err = cfg.Validate(); err != nil {
+		exitf("некорректная конфигурация:\n%v", err)
 	}
 
 	err = logger.InitGlobalLogger(cfg)
 	if err != nil {
-		logger.Fatalf("ошибка инициализации логгера: %v", err)
+		exitf("ошибка инициализации логгера: %v", err)
 	}
 
 	logger.Info("Приложение запускается...")
-	logger.Infof("Используется конфигурация из файла: %s", configPath)
+	if cfg.Path() != "" {
+		logger.Infof("Используется конфигурация из файла: %s", cfg.Path())
+	} else {
+		logger.Info("Файл конфигурации не задан, используются переменные окружения и значения по умолчанию")
+	}
+	logger.Infof("Итоговая конфигурация: %s", cfg.Redacted())
+
+	tracingShutdown, err := tracing.Init(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Fatalf("ошибка инициализации трассировки: %v", err)
+	}
+	defer shutdownTracing(tracingShutdown)
 
 	pool, err := initInfrastructure(ctx, cfg)
 	if err != nil {
@@ -66,42 +157,172 @@ func main() {
 	repos := initRepositories(pool)
 	logger.Debug("Репозитории успешно инициализированы")
 
-	services := initServices(repos)
+	metrics.Registry.MustRegister(
+		metrics.NewPoolCollector(pool.Stats),
+		metrics.NewBusinessCollector(repos.projRepo, repos.invRepo),
+	)
+
+	mail, err := mailer.New(cfg.Mailer)
+	if err != nil {
+		logger.Fatalf("ошибка инициализации почты: %v", err)
+	}
+
+	templates, err := notification.LoadTemplates()
+	if err != nil {
+		logger.Fatalf("ошибка загрузки шаблонов уведомлений: %v", err)
+	}
+
+	tokens, err := auth.NewTokenIssuer(cfg.Auth.TokenSecret, time.Duration(cfg.Auth.TokenTTL)*time.Minute)
+	if err != nil {
+		logger.Fatalf("ошибка инициализации токенов доступа: %v", err)
+	}
+
+	kycProvider, kycStore, err := initKYC(cfg.KYC)
+	if err != nil {
+		logger.Fatalf("ошибка инициализации проверки личности: %v", err)
+	}
+
+	if err = compliance.Validate(globalInvestmentRules(cfg.Compliance)); err != nil {
+		logger.Fatalf("некорректные правила инвестирования: %v", err)
+	}
+
+	sanctions, err := screening.NewScreener(cfg.Screening.Lists, cfg.Screening.NameThreshold)
+	if err != nil {
+		logger.Fatalf("ошибка загрузки санкционных списков: %v", err)
+	}
+
+	watcher := config.NewWatcher(cfg)
+
+	migrations, err := migrate.NewChecker(pool)
+	if err != nil {
+		logger.Fatalf("ошибка инициализации проверки миграций: %v", err)
+	}
+
+	services := initServices(cfg, pool, migrations, repos, mail, templates, tokens, kycProvider, kycStore, sanctions, watcher)
 	logger.Debug("Сервисы успешно инициализированы")
 
-	handlers := initHandlers(services)
+	hub := realtime.NewHub(cfg.Realtime.MaxConnections, cfg.Realtime.MaxConnectionsPerProject)
+
+	handlers := initHandlers(services, hub, time.Duration(cfg.Realtime.PingInterval)*time.Second)
 	logger.Debug("Хендлеры успешно инициализированы")
 
-	app := router.SetupRouter(handlers.accHandler, handlers.projHandler, handlers.invHandler)
+	limits, err := initRateLimits(cfg.RateLimit, pool)
+	if err != nil {
+		logger.Fatalf("ошибка инициализации ограничения частоты запросов: %v", err)
+	}
+	watcher.Subscribe(applyReloadedConfig(limits))
+
+	app := router.SetupRouter(router.Handlers{
+		Account:      handlers.accHandler,
+		Project:      handlers.projHandler,
+		Investment:   handlers.invHandler,
+		Notification: handlers.notHandler,
+		Realtime:     handlers.rtHandler,
+		Auth:         handlers.authHandler,
+		TwoFactor:    handlers.tfaHandler,
+		Session:      handlers.sesHandler,
+		Wallet:       handlers.walHandler,
+		APIKey:       handlers.keyHandler,
+		Audit:        handlers.audHandler,
+		KYC:          handlers.kycHandler,
+		Screening:    handlers.scrHandler,
+		Privacy:      handlers.prvHandler,
+		Runtime:      handlers.rtmHandler,
+		Health:       handlers.hltHandler,
+	}, services.accService, services.sesService, services.keyService, limits, services.idmService, router.Options{
+		ReadTimeout:     time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout:    time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		IdleTimeout:     time.Duration(cfg.Server.IdleTimeout) * time.Second,
+		BodyLimit:       cfg.Server.BodyLimit << 20,
+		TrustedProxies:  cfg.Server.TrustedProxies,
+		ProxyHeader:     cfg.Server.ProxyHeader,
+		AdminClientCert: cfg.Server.TLS.ClientCAFile != "",
+	})
 	logger.Debug("Маршруты успешно настроены")
 
-	serverShutdown := startServer(ctx, app, cfg.Server.Port)
+	// Фоновые задачи должны освободить соединения до закрытия пула
+	bgCtx, stopBackground := context.WithCancel(ctx)
+	defer stopBackground()
+
+	go realtime.Listen(bgCtx, pool, hub)
+	go worker.RunPeriodic(bgCtx, "expired-campaigns", expiredCampaignsInterval, services.projService.FailExpired)
+	go worker.RunPeriodic(bgCtx, "rate-limit-prune", rateLimitPruneInterval, limits.Store.Prune)
+	go worker.RunPeriodic(bgCtx, "sanctions-rescreen", time.Duration(cfg.Screening.RescreenInterval)*time.Minute,
+		services.scrService.Rescreen)
+	go worker.RunPeriodic(bgCtx, "account-erasure", accountErasureInterval, services.prvService.EraseDue)
+	go worker.RunPeriodic(bgCtx, "deleted-purge", deletedPurgeInterval, services.retService.PurgeDeleted)
+	go worker.RunPeriodic(bgCtx, "idempotency-prune", idempotencyPruneInterval, services.idmService.DeleteExpired)
+	go worker.RunPeriodic(bgCtx, "config-reload", configCheckInterval, reloadOnFileChange(watcher, services.rtmService))
+	go reloadOnSignal(bgCtx, services.rtmService)
+
+	tlsConfig, err := initTLS(cfg.Server.TLS)
+	if err != nil {
+		logger.Fatalf("ошибка инициализации TLS: %v", err)
+	}
+
+	serverShutdown := startServer(ctx, app, cfg.Server, tlsConfig)
 	defer serverShutdown()
+	metricsShutdown := startMetricsServer(ctx, cfg.Server)
+	defer metricsShutdown()
+	// Закрываем потоки обновлений до остановки сервера, иначе он будет ждать их завершения
+	defer hub.Close()
 
 	waitForShutdownSignal()
+	drain(services.hltService, time.Duration(cfg.Server.ShutdownDelay)*time.Second)
 
 	logger.Info("Приложение успешно завершено")
 }
 
+// shutdownTracing отправляет накопленные спаны. Вызывается после остановки сервера и фоновых задач
+func shutdownTracing(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+
+	if err := shutdown(ctx); err != nil {
+		logger.Errorf("ошибка отправки спанов трассировки: %v", err)
+	}
+}
+
 // Инициализация инфраструктуры (миграции, подключение к БД)
 func initInfrastructure(ctx context.Context, cfg *config.Config) (*db.Pool, error) {
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.Database.User, cfg.Database.Password, cfg.Database.Host,
-		cfg.Database.Port, cfg.Database.Name)
+	dbURL := cfg.Database.DSN()
 
-	logger.Infof("Запуск миграций для БД: %s:%s/%s", cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
+	logger.Info("Запуск миграций для БД")
 	if err := migrate.Run(dbURL, "up"); err != nil {
 		return nil, fmt.Errorf("ошибка при выполнении миграций: %v", err)
 	}
 	logger.Debug("Миграции успешно выполнены")
 
+	version, err := migrate.Version(dbURL)
+	if err != nil {
+		return nil, err
+	}
+	metrics.SetMigrationVersion(version)
+
 	logger.Debug("Подключение к базе данных...")
-	pool, err := db.NewPool(ctx, dbURL)
+	poolConfig := db.PoolConfig{
+		MaxConns:         int32(cfg.Database.MaxConns),
+		MinConns:         int32(cfg.Database.MinConns),
+		MaxConnLifetime:  time.Duration(cfg.Database.MaxConnLifetime) * time.Second,
+		MaxConnIdleTime:  time.Duration(cfg.Database.MaxConnIdleTime) * time.Second,
+		StatementTimeout: time.Duration(cfg.Database.StatementTimeout) * time.Second,
+	}
+
+	pool, err := db.NewPool(ctx, dbURL, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка подключения к базе данных: %v", err)
 	}
 	logger.Info("Подключение к базе данных установлено")
 
+	if replicaURL := cfg.Database.ReplicaDSN(); replicaURL != "" {
+		// Без реплики приложение работает, чтение выполняется на основной БД
+		if err = pool.ConnectReplica(ctx, replicaURL, poolConfig); err != nil {
+			logger.Warnf("Реплика для чтения недоступна, чтение выполняется на основной БД: %v", err)
+		} else {
+			logger.Info("Подключение к реплике для чтения установлено")
+		}
+	}
+
 	return pool, nil
 }
 
@@ -110,36 +331,267 @@ func initRepositories(pool *db.Pool) *repositories {
 		accRepo:  repository.NewPostgresAccount(pool),
 		projRepo: repository.NewPostgresProject(pool),
 		invRepo:  repository.NewPostgresInvestment(pool),
+		notRepo:  repository.NewPostgresNotification(pool),
+		tokRepo:  repository.NewPostgresAccountToken(pool),
+		tfaRepo:  repository.NewPostgresTwoFactor(pool),
+		sesRepo:  repository.NewPostgresSession(pool),
+		nonRepo:  repository.NewPostgresSIWENonce(pool),
+		walRepo:  repository.NewPostgresWallet(pool),
+		keyRepo:  repository.NewPostgresAPIKey(pool),
+		audRepo:  repository.NewPostgresAudit(pool),
+		kycRepo:  repository.NewPostgresKYC(pool),
+		irRepo:   repository.NewPostgresInvestmentRules(pool),
+		scrRepo:  repository.NewPostgresScreening(pool),
+		prvRepo:  repository.NewPostgresPrivacy(pool),
+		idmRepo:  repository.NewPostgresIdempotency(pool),
+	}
+}
+
+// Инициализация хранилища и лимитов частоты запросов
+func initRateLimits(cfg config.RateLimitConfig, pool *db.Pool) (router.RateLimits, error) {
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store = repository.NewPostgresRateLimit(pool)
+	default:
+		return router.RateLimits{}, fmt.Errorf("неизвестное хранилище лимитов: %s", cfg.Store)
+	}
+
+	return router.RateLimits{
+		Store:    store,
+		IP:       ratelimit.NewPolicy(rateLimit(cfg.IP)),
+		Account:  ratelimit.NewPolicy(rateLimit(cfg.Account)),
+		Auth:     ratelimit.NewPolicy(rateLimit(cfg.Auth)),
+		Accounts: ratelimit.NewPolicy(rateLimit(cfg.Accounts)),
+	}, nil
+}
+
+func rateLimit(rule config.RateLimitRule) ratelimit.Limit {
+	return ratelimit.Limit{Requests: rule.Requests, Period: time.Duration(rule.Period) * time.Second}
+}
+
+// applyReloadedConfig применяет параметры, которые меняются без перезапуска.
+// Запросы, уже прошедшие проверку лимита, не затрагиваются
+func applyReloadedConfig(limits router.RateLimits) func(*config.Config) {
+	return func(cfg *config.Config) {
+		if err := logger.SetLevel(cfg.Logger.Level); err != nil {
+			logger.Errorf("ошибка применения уровня логирования: %v", err)
+		}
+
+		limits.IP.Set(rateLimit(cfg.RateLimit.IP))
+		limits.Account.Set(rateLimit(cfg.RateLimit.Account))
+		limits.Auth.Set(rateLimit(cfg.RateLimit.Auth))
+		limits.Accounts.Set(rateLimit(cfg.RateLimit.Accounts))
+	}
+}
+
+// reloadOnFileChange возвращает фоновую задачу, перезагружающую конфигурацию после изменения файла
+func reloadOnFileChange(watcher *config.Watcher, runtime *service.Runtime) worker.Job {
+	return func(ctx context.Context) error {
+		changed, err := watcher.Changed()
+		if err != nil || !changed {
+			return err
+		}
+
+		logger.Info("Файл конфигурации изменен, перезагружаем конфигурацию")
+		_, err = runtime.ReloadConfig(ctx)
+		return err
+	}
+}
+
+// reloadOnSignal перезагружает конфигурацию по сигналу SIGHUP до отмены контекста
+func reloadOnSignal(ctx context.Context, runtime *service.Runtime) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			logger.Info("Получен сигнал SIGHUP, перезагружаем конфигурацию")
+			// Ошибка уже записана в лог, действующая конфигурация остается без изменений
+			_, _ = runtime.ReloadConfig(ctx)
+		}
+	}
+}
+
+// Инициализация провайдера проверки личности и хранилища документов
+func initKYC(cfg config.KYCConfig) (kyc.Provider, kyc.DocumentStore, error) {
+	var provider kyc.Provider
+	switch cfg.Provider {
+	case "manual":
+		provider = kyc.NewManualProvider()
+	case "mock":
+		mock, err := kyc.NewMockProvider(cfg.MockDecision)
+		if err != nil {
+			return nil, nil, err
+		}
+		provider = mock
+	default:
+		return nil, nil, fmt.Errorf("неизвестный провайдер KYC: %s", cfg.Provider)
+	}
+
+	store, err := kyc.NewFileStore(cfg.StorageDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return provider, store, nil
+}
+
+// globalInvestmentRules переводит глобальные правила инвестирования из конфигурации,
+// где нулевое значение означает отсутствие лимита
+func globalInvestmentRules(cfg config.ComplianceConfig) model.InvestmentRules {
+	limit := func(value decimal.Decimal) *decimal.Decimal {
+		if value.IsZero() {
+			return nil
+		}
+		return &value
+	}
+
+	return model.InvestmentRules{
+		AnnualCap:        limit(cfg.AnnualCap),
+		MinTicket:        limit(cfg.MinTicket),
+		MaxTicket:        limit(cfg.MaxTicket),
+		MaxProjectShare:  limit(cfg.MaxProjectShare),
+		BlockedCountries: cfg.BlockedCountries,
 	}
 }
 
-func initServices(repos *repositories) *services {
+func initServices(
+	cfg *config.Config,
+	pool *db.Pool,
+	migrations *migrate.Checker,
+	repos *repositories,
+	mail mailer.Mailer,
+	templates *notification.Templates,
+	tokens *auth.TokenIssuer,
+	kycProvider kyc.Provider,
+	kycStore kyc.DocumentStore,
+	sanctions service.SanctionsLists,
+	reloader service.ConfigReloader,
+) *services {
+	stepUpWindow := time.Duration(cfg.Auth.StepUpWindow) * time.Minute
+
+	notService := service.NewNotification(repos.notRepo, repos.accRepo, mail, templates)
+	tfaService := service.NewTwoFactor(repos.tfaRepo, repos.accRepo, cfg.Auth.TOTPIssuer, stepUpWindow)
+	sesService := service.NewSession(repos.sesRepo, tokens)
+	authService := service.NewAuth(repos.accRepo, repos.tokRepo, notService, tfaService, sesService, cfg.Auth)
+	audService := service.NewAudit(repos.audRepo, pool)
+	scrService := service.NewScreening(repos.scrRepo, sanctions, audService)
+
 	return &services{
-		accService:  service.NewAccount(repos.accRepo),
-		projService: service.NewProject(repos.projRepo),
-		invService:  service.NewInvestment(repos.invRepo, repos.projRepo),
+		accService:  service.NewAccount(repos.accRepo, authService, sesService, audService, scrService),
+		projService: service.NewProject(repos.projRepo, repos.accRepo, notService, audService),
+		invService: service.NewInvestment(repos.invRepo, repos.projRepo, repos.accRepo, audService,
+			cfg.Auth.LargeInvestmentAmount, stepUpWindow, cfg.KYC.UnverifiedInvestmentLimit,
+			repos.irRepo, globalInvestmentRules(cfg.Compliance), scrService),
+		notService:  notService,
+		authService: authService,
+		tfaService:  tfaService,
+		sesService:  sesService,
+		walService:  service.NewWalletAuth(repos.nonRepo, repos.walRepo, tfaService, sesService, scrService, cfg.Auth),
+		keyService:  service.NewAPIKey(repos.keyRepo),
+		audService:  audService,
+		kycService: service.NewKYC(repos.kycRepo, repos.accRepo, kycProvider, kycStore, audService,
+			int64(cfg.KYC.MaxDocumentSize)<<20),
+		scrService: scrService,
+		prvService: service.NewPrivacy(repos.prvRepo, kycStore, audService,
+			time.Duration(cfg.Privacy.ErasureGracePeriod)*24*time.Hour),
+		retService: service.NewRetention(repos.accRepo, repos.projRepo, repos.invRepo,
+			time.Duration(cfg.SoftDelete.Retention)*24*time.Hour,
+			time.Duration(cfg.SoftDelete.InvestmentRetention)*24*time.Hour),
+		idmService: service.NewIdempotency(repos.idmRepo, time.Duration(cfg.Idempotency.KeyTTL)*time.Hour,
+			time.Duration(cfg.Idempotency.LockTimeout)*time.Second),
+		rtmService: service.NewRuntime(reloader, audService),
+		hltService: service.NewHealth(pool, migrations.Pending, worker.Heartbeats),
 	}
 }
 
-func initHandlers(services *services) *handlers {
+func initHandlers(services *services, hub *realtime.Hub, pingInterval time.Duration) *handlers {
 	return &handlers{
 		accHandler:  handler.NewAccountHandler(services.accService),
 		projHandler: handler.NewProjectHandler(services.projService),
 		invHandler:  handler.NewInvestmentHandler(services.invService),
+		notHandler:  handler.NewNotificationHandler(services.notService),
+		rtHandler:   handler.NewRealtimeHandler(services.projService, hub, pingInterval),
+		authHandler: handler.NewAuthHandler(services.authService),
+		tfaHandler:  handler.NewTwoFactorHandler(services.tfaService),
+		sesHandler:  handler.NewSessionHandler(services.sesService),
+		walHandler:  handler.NewWalletHandler(services.walService),
+		keyHandler:  handler.NewAPIKeyHandler(services.keyService),
+		audHandler:  handler.NewAuditHandler(services.audService),
+		kycHandler:  handler.NewKYCHandler(services.kycService),
+		scrHandler:  handler.NewScreeningHandler(services.scrService),
+		prvHandler:  handler.NewPrivacyHandler(services.prvService),
+		rtmHandler:  handler.NewRuntimeHandler(services.rtmService),
+		hltHandler:  handler.NewHealthHandler(services.hltService),
 	}
 }
 
-func startServer(ctx context.Context, app *fiber.App, port string) func() {
+// initTLS загружает сертификат сервера и CA клиентских сертификатов.
+// Возвращает nil, если HTTPS не настроен
+func initTLS(cfg config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки сертификата сервера: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения CA клиентских сертификатов: %w", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("файл %s не содержит сертификатов в формате PEM", cfg.ClientCAFile)
+		}
+
+		// Сертификат запрашивается у всех клиентов, но обязателен только на маршрутах /admin
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
+
+func startServer(ctx context.Context, app *fiber.App, cfg config.ServerConfig, tlsConfig *tls.Config) func() {
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+
 	go func() {
-		logger.Infof("HTTP-сервер запущен на порту :%s", port)
-		if err := app.Listen(fmt.Sprintf(":%s", port)); err != nil {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			logger.Fatalf("ошибка запуска HTTP-сервера: %v", err)
+		}
+
+		if tlsConfig != nil {
+			ln = tls.NewListener(ln, tlsConfig)
+			logger.Infof("HTTPS-сервер запущен на %s", addr)
+		} else {
+			logger.Infof("HTTP-сервер запущен на %s", addr)
+		}
+
+		if err := app.Listener(ln); err != nil {
 			logger.Fatalf("ошибка запуска HTTP-сервера: %v", err)
 		}
 	}()
 
 	return func() {
 		logger.Debug("Остановка HTTP-сервера...")
-		shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.ShutdownTimeout)*time.Second)
 		defer cancel()
 
 		if err := app.ShutdownWithContext(shutdownCtx); err != nil {
@@ -149,6 +601,53 @@ func startServer(ctx context.Context, app *fiber.App, port string) func() {
 	}
 }
 
+// exitf завершает процесс с кодом 1, выводя сообщение в stderr. Используется до инициализации логгера
+func exitf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+// startMetricsServer отдает /metrics на отдельном адресе, закрытом от публичной сети,
+// чтобы метрики не были доступны через API и не расходовали его лимиты запросов
+func startMetricsServer(ctx context.Context, cfg config.ServerConfig) func() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	server := &http.Server{
+		Addr:              net.JoinHostPort(cfg.MetricsHost, cfg.MetricsPort),
+		Handler:           mux,
+		ReadHeaderTimeout: time.Duration(cfg.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.IdleTimeout) * time.Second,
+	}
+
+	go func() {
+		logger.Infof("Сервер метрик запущен на %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("ошибка запуска сервера метрик: %v", err)
+		}
+	}()
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.ShutdownTimeout)*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Errorf("ошибка при завершении сервера метрик: %v", err)
+		}
+	}
+}
+
+// drain переводит /readyz в состояние 503 и ждет delay, чтобы балансировщик успел исключить
+// экземпляр до остановки сервера. Запросы в это время обслуживаются как обычно
+func drain(health *service.Health, delay time.Duration) {
+	health.Drain()
+	if delay > 0 {
+		logger.Infof("Экземпляр помечен как неготовый, сервер остановится через %s", delay)
+		time.Sleep(delay)
+	}
+}
+
 func waitForShutdownSignal() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
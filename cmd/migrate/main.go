@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -10,26 +11,26 @@ import (
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Использование: migrate [up|down|status]")
+	configPath := flag.String("config", "", "путь к файлу конфигурации в формате JSON, YAML или TOML")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Println("Использование: migrate [--config path] [up|down|status]")
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
-
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		cfg, err := config.Load("config.json")
-		if err != nil {
-			log.Fatalf("ошибка загрузки конфигурации: %v", err)
-		}
+	command := flag.Arg(0)
 
-		dbURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-			cfg.Database.User, cfg.Database.Password, cfg.Database.Host,
-			cfg.Database.Port, cfg.Database.Name)
+	// Строку подключения целиком можно передать через CRYPTOCROWD_DATABASE_URL
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("ошибка загрузки конфигурации: %v", err)
+	}
+	if err = cfg.Database.Validate(); err != nil {
+		log.Fatalf("некорректная конфигурация базы данных:\n%v", err)
 	}
 
-	if err := migrate.Run(dbURL, command); err != nil {
+	if err := migrate.Run(cfg.Database.DSN(), command); err != nil {
 		log.Fatalf("ошибка выполнения миграции: %v", err)
 	}
 }
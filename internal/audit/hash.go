@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// hashedEvent - каноническое представление события для хеширования. Порядок полей фиксирован
+type hashedEvent struct {
+	PrevHash   string                       `json:"prev_hash"`
+	ActorID    *int64                       `json:"actor_id"`
+	Action     string                       `json:"action"`
+	TargetType string                       `json:"target_type"`
+	TargetID   int64                        `json:"target_id"`
+	Changes    map[string]model.AuditChange `json:"changes"`
+	IP         string                       `json:"ip"`
+	RequestID  string                       `json:"request_id"`
+	CreatedAt  string                       `json:"created_at"`
+}
+
+// Hash вычисляет хеш события, связывающий его с предыдущим событием цепочки.
+// Изменение любого сохраненного события ломает хеши всех последующих
+func Hash(event model.AuditEvent) (string, error) {
+	// Ключи map сериализуются в отсортированном порядке, поэтому результат детерминирован
+	payload, err := json.Marshal(hashedEvent{
+		PrevHash:   event.PrevHash,
+		ActorID:    event.ActorID,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Changes:    event.Changes,
+		IP:         event.IP,
+		RequestID:  event.RequestID,
+		CreatedAt:  event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
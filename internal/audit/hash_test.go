@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+func testEvent() model.AuditEvent {
+	actorID := int64(7)
+	return model.AuditEvent{
+		ActorID:    &actorID,
+		Action:     model.AuditProjectUpdate,
+		TargetType: model.AuditTargetProject,
+		TargetID:   10,
+		Changes: map[string]model.AuditChange{
+			"status": {Before: "pending", After: "approved"},
+			"name":   {Before: "a", After: "b"},
+		},
+		IP:        "10.0.0.1",
+		RequestID: "req-1",
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		PrevHash:  "prev",
+	}
+}
+
+func TestHashIsDeterministic(t *testing.T) {
+	want, err := Hash(testEvent())
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	same := testEvent()
+	// Хеш не зависит от часового пояса времени, ID и уже вычисленного хеша события
+	same.CreatedAt = same.CreatedAt.In(time.FixedZone("UTC+3", 3*60*60))
+	same.ID = 42
+	same.Hash = "stored"
+
+	for range 10 {
+		got, err := Hash(same)
+		if err != nil {
+			t.Fatalf("Hash: %v", err)
+		}
+		if got != want {
+			t.Fatalf("Hash() = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestHashCoversEveryField(t *testing.T) {
+	base, err := Hash(testEvent())
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	otherActor := int64(8)
+
+	tests := []struct {
+		name   string
+		modify func(e *model.AuditEvent)
+	}{
+		{name: "previous hash", modify: func(e *model.AuditEvent) { e.PrevHash = "other" }},
+		{name: "actor", modify: func(e *model.AuditEvent) { e.ActorID = &otherActor }},
+		{name: "system actor", modify: func(e *model.AuditEvent) { e.ActorID = nil }},
+		{name: "action", modify: func(e *model.AuditEvent) { e.Action = model.AuditProjectDelete }},
+		{name: "target type", modify: func(e *model.AuditEvent) { e.TargetType = model.AuditTargetAccount }},
+		{name: "target ID", modify: func(e *model.AuditEvent) { e.TargetID = 11 }},
+		{name: "change value", modify: func(e *model.AuditEvent) {
+			e.Changes["status"] = model.AuditChange{Before: "pending", After: "rejected"}
+		}},
+		{name: "removed change", modify: func(e *model.AuditEvent) { delete(e.Changes, "name") }},
+		{name: "IP", modify: func(e *model.AuditEvent) { e.IP = "10.0.0.2" }},
+		{name: "request ID", modify: func(e *model.AuditEvent) { e.RequestID = "req-2" }},
+		{name: "time", modify: func(e *model.AuditEvent) { e.CreatedAt = e.CreatedAt.Add(time.Microsecond) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := testEvent()
+			tt.modify(&event)
+
+			got, err := Hash(event)
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if got == base {
+				t.Fatalf("hash did not change when the %s changed", tt.name)
+			}
+		})
+	}
+}
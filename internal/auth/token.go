@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const minSecretLength = 32
+
+var (
+	// ErrInvalidToken возвращается для поврежденных, поддельных и просроченных токенов
+	ErrInvalidToken = errors.New("недействительный токен доступа")
+)
+
+// Claims - данные, которые переносит токен доступа
+type Claims struct {
+	UserID    int64 `json:"sub"`
+	SessionID int64 `json:"sid"`
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+	// MFAAt - время последнего подтверждения вторым фактором, 0 если подтверждения не было
+	MFAAt int64 `json:"mfa,omitempty"`
+	// APIKeyID и Scopes заполняются при входе по ключу API, который дает доступ только к своим областям
+	APIKeyID int64    `json:"kid,omitempty"`
+	Scopes   []string `json:"scope,omitempty"`
+}
+
+// HasScopes сообщает, разрешены ли все перечисленные области. Токены сессий не ограничены областями
+func (c Claims) HasScopes(scopes ...string) bool {
+	if c.APIKeyID == 0 {
+		return true
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// MFAVerifiedWithin сообщает, подтверждал ли пользователь второй фактор не раньше чем window назад
+func (c Claims) MFAVerifiedWithin(window time.Duration, now time.Time) bool {
+	return c.MFAAt > 0 && now.Sub(time.Unix(c.MFAAt, 0)) <= window
+}
+
+// TokenIssuer выпускает и проверяет токены доступа, подписанные HMAC-SHA256
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokenIssuer(secret string, ttl time.Duration) (*TokenIssuer, error) {
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("секрет подписи токенов должен быть не короче %d символов", minSecretLength)
+	}
+
+	return &TokenIssuer{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}, nil
+}
+
+// TTL возвращает срок действия выпускаемых токенов
+func (t *TokenIssuer) TTL() time.Duration {
+	return t.ttl
+}
+
+// Issue выпускает токен для сессии пользователя. mfaAt - время подтверждения вторым фактором или нулевое время
+func (t *TokenIssuer) Issue(userID int64, sessionID int64, mfaAt time.Time) (string, Claims, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(t.ttl).Unix(),
+	}
+	if !mfaAt.IsZero() {
+		claims.MFAAt = mfaAt.Unix()
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", Claims{}, fmt.Errorf("ошибка кодирования токена: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + t.sign(encoded), claims, nil
+}
+
+// Parse проверяет подпись и срок действия токена и возвращает его данные
+func (t *TokenIssuer) Parse(token string) (Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(t.sign(encoded))) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.UserID <= 0 {
+		return Claims{}, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (t *TokenIssuer) sign(encoded string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
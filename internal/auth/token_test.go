@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func newTestIssuer(t *testing.T, secret string, ttl time.Duration) *TokenIssuer {
+	t.Helper()
+
+	issuer, err := NewTokenIssuer(secret, ttl)
+	if err != nil {
+		t.Fatalf("NewTokenIssuer: %v", err)
+	}
+	return issuer
+}
+
+func TestNewTokenIssuerRejectsShortSecret(t *testing.T) {
+	if _, err := NewTokenIssuer(testSecret[:minSecretLength-1], time.Hour); err == nil {
+		t.Fatal("NewTokenIssuer accepted a secret shorter than the minimum")
+	}
+}
+
+func TestTokenIssueParse(t *testing.T) {
+	issuer := newTestIssuer(t, testSecret, time.Hour)
+	mfaAt := time.Now().Add(-time.Minute)
+
+	token, issued, err := issuer.Issue(7, 42, mfaAt)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	claims, err := issuer.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if claims.UserID != 7 || claims.SessionID != 42 || claims.MFAAt != mfaAt.Unix() || claims.ExpiresAt != issued.ExpiresAt {
+		t.Fatalf("Parse() = %+v, want %+v", claims, issued)
+	}
+
+	if _, withoutMFA, _ := issuer.Issue(7, 42, time.Time{}); withoutMFA.MFAAt != 0 {
+		t.Fatalf("MFAAt = %d for a token issued without a second factor, want 0", withoutMFA.MFAAt)
+	}
+}
+
+func TestTokenParseRejected(t *testing.T) {
+	issuer := newTestIssuer(t, testSecret, time.Hour)
+	token, _, err := issuer.Issue(7, 42, time.Time{})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	encoded, signature, _ := strings.Cut(token, ".")
+
+	// signed подписывает произвольные данные, чтобы проверить разбор уже после проверки подписи
+	signed := func(payload string) string {
+		encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+		return encoded + "." + issuer.sign(encoded)
+	}
+	expired, _, err := newTestIssuer(t, testSecret, -time.Second).Issue(7, 42, time.Time{})
+	if err != nil {
+		t.Fatalf("Issue expired: %v", err)
+	}
+	foreign, _, err := newTestIssuer(t, strings.Repeat("x", minSecretLength), time.Hour).Issue(7, 42, time.Time{})
+	if err != nil {
+		t.Fatalf("Issue with another secret: %v", err)
+	}
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":1,"sid":42,"exp":4102444800}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "no signature", token: encoded},
+		{name: "signature of another payload", token: forged + "." + signature},
+		{name: "truncated signature", token: encoded + "." + signature[:len(signature)-1]},
+		{name: "signed with another secret", token: foreign},
+		{name: "expired", token: expired},
+		{name: "payload is not base64", token: "%%%." + issuer.sign("%%%")},
+		{name: "payload is not JSON", token: signed("not json")},
+		{name: "no user", token: signed(`{"sid":42,"exp":4102444800}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := issuer.Parse(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Parse: error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestClaimsHasScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims Claims
+		scopes []string
+		want   bool
+	}{
+		{name: "session token is not limited", claims: Claims{UserID: 7}, scopes: []string{"projects:read"}, want: true},
+		{name: "granted scope", claims: Claims{APIKeyID: 1, Scopes: []string{"investments:read"}}, scopes: []string{"investments:read"}, want: true},
+		{name: "one of the scopes missing", claims: Claims{APIKeyID: 1, Scopes: []string{"investments:read"}}, scopes: []string{"investments:read", "projects:read"}},
+		{name: "API key without scopes", claims: Claims{APIKeyID: 1}, scopes: []string{"investments:read"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.claims.HasScopes(tt.scopes...); got != tt.want {
+				t.Fatalf("HasScopes(%v) = %v, want %v", tt.scopes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimsMFAVerifiedWithin(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		mfaAt int64
+		want  bool
+	}{
+		{name: "never verified", mfaAt: 0},
+		{name: "verified recently", mfaAt: now.Add(-time.Minute).Unix(), want: true},
+		{name: "verified too long ago", mfaAt: now.Add(-time.Hour).Unix()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Claims{MFAAt: tt.mfaAt}).MFAVerifiedWithin(5*time.Minute, now); got != tt.want {
+				t.Fatalf("MFAVerifiedWithin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret - секрет "12345678901234567890" из тестовых векторов RFC 6238 в base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	key, err := base32NoPadding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+
+	// Ожидаемые коды - последние шесть цифр восьмизначных кодов SHA1 из приложения B RFC 6238
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current code", secret: rfc6238Secret, code: "005924", wantStep: step, wantOK: true},
+		{name: "lowercase secret", secret: strings.ToLower(rfc6238Secret), code: "005924", wantStep: step, wantOK: true},
+		{name: "previous step within skew", secret: rfc6238Secret, code: codeAt(t, step-1), wantStep: step - 1, wantOK: true},
+		{name: "next step within skew", secret: rfc6238Secret, code: codeAt(t, step+1), wantStep: step + 1, wantOK: true},
+		{name: "step outside skew", secret: rfc6238Secret, code: codeAt(t, step-2)},
+		{name: "replayed code", secret: rfc6238Secret, code: "005924", lastStep: step},
+		{name: "older code after a newer one was used", secret: rfc6238Secret, code: codeAt(t, step-1), lastStep: step},
+		{name: "newer code after an older one was used", secret: rfc6238Secret, code: codeAt(t, step+1), lastStep: step, wantStep: step + 1, wantOK: true},
+		{name: "wrong code", secret: rfc6238Secret, code: "000000"},
+		{name: "wrong length", secret: rfc6238Secret, code: "05924"},
+		{name: "invalid secret", secret: "not base32!", code: "005924"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(tt.secret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Fatalf("ValidateTOTP() = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes (%v), want 20", secret, len(key), err)
+	}
+
+	now := time.Now()
+	step := now.Unix() / totpPeriod
+	if got, ok := ValidateTOTP(secret, totpCode(key, step), now, 0); !ok || got != step {
+		t.Fatalf("code of a generated secret is rejected: (%d, %v)", got, ok)
+	}
+}
+
+func codeAt(t *testing.T, step int64) string {
+	t.Helper()
+
+	key, err := base32NoPadding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	return totpCode(key, step)
+}
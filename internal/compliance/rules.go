@@ -0,0 +1,234 @@
+package compliance
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// Коды нарушений правил инвестирования
+const (
+	RuleAnnualCap       = "annual_cap"
+	RuleMinTicket       = "min_ticket"
+	RuleMaxTicket       = "max_ticket"
+	RuleMaxProjectShare = "max_project_share"
+	RuleJurisdiction    = "jurisdiction"
+)
+
+// Facts - сведения об инвестиции, по которым проверяются правила
+type Facts struct {
+	Amount decimal.Decimal
+	// Country - страна проживания инвестора (ISO 3166-1 alpha-2), пустая, если не указана
+	Country string
+	// InvestedThisYear - сумма инвестиций инвестора с начала календарного года без учета новой
+	InvestedThisYear decimal.Decimal
+	// InvestedInProject - сумма прежних инвестиций инвестора в этот проект
+	InvestedInProject decimal.Decimal
+	// ProjectTarget - запрошенная проектом сумма
+	ProjectTarget decimal.Decimal
+}
+
+// Violation - нарушение правила. Limit содержит допустимое значение, если оно применимо
+type Violation struct {
+	Rule    string           `json:"rule"`
+	Message string           `json:"message"`
+	Limit   *decimal.Decimal `json:"limit,omitempty"`
+}
+
+// Rule - одно правило инвестирования
+type Rule interface {
+	Check(f Facts) (Violation, bool)
+}
+
+// AnnualCap ограничивает сумму инвестиций одного инвестора за календарный год
+type AnnualCap struct {
+	Limit decimal.Decimal
+}
+
+func (r AnnualCap) Check(f Facts) (Violation, bool) {
+	if f.InvestedThisYear.Add(f.Amount).LessThanOrEqual(r.Limit) {
+		return Violation{}, false
+	}
+	remaining := decimal.Max(r.Limit.Sub(f.InvestedThisYear), decimal.Zero)
+	return Violation{
+		Rule:    RuleAnnualCap,
+		Message: fmt.Sprintf("annual investment cap of %s exceeded, %s remaining this year", r.Limit, remaining),
+		Limit:   &remaining,
+	}, true
+}
+
+// MinTicket задает минимальную сумму одной инвестиции
+type MinTicket struct {
+	Min decimal.Decimal
+}
+
+func (r MinTicket) Check(f Facts) (Violation, bool) {
+	if f.Amount.GreaterThanOrEqual(r.Min) {
+		return Violation{}, false
+	}
+	return Violation{
+		Rule:    RuleMinTicket,
+		Message: fmt.Sprintf("minimum investment is %s", r.Min),
+		Limit:   &r.Min,
+	}, true
+}
+
+// MaxTicket задает максимальную сумму одной инвестиции
+type MaxTicket struct {
+	Max decimal.Decimal
+}
+
+func (r MaxTicket) Check(f Facts) (Violation, bool) {
+	if f.Amount.LessThanOrEqual(r.Max) {
+		return Violation{}, false
+	}
+	return Violation{
+		Rule:    RuleMaxTicket,
+		Message: fmt.Sprintf("maximum investment is %s", r.Max),
+		Limit:   &r.Max,
+	}, true
+}
+
+// MaxProjectShare ограничивает долю запрошенной проектом суммы, которую может внести один инвестор
+type MaxProjectShare struct {
+	// Share - доля от 0 до 1
+	Share decimal.Decimal
+}
+
+func (r MaxProjectShare) Check(f Facts) (Violation, bool) {
+	if !f.ProjectTarget.IsPositive() {
+		return Violation{}, false
+	}
+	allowed := f.ProjectTarget.Mul(r.Share)
+	if f.InvestedInProject.Add(f.Amount).LessThanOrEqual(allowed) {
+		return Violation{}, false
+	}
+	remaining := decimal.Max(allowed.Sub(f.InvestedInProject), decimal.Zero)
+	return Violation{
+		Rule: RuleMaxProjectShare,
+		Message: fmt.Sprintf("a single investor can fund at most %s%% of the project, %s remaining",
+			r.Share.Shift(2), remaining),
+		Limit: &remaining,
+	}, true
+}
+
+// JurisdictionBlock запрещает инвестиции из перечисленных стран. Если список не пуст,
+// инвестор должен указать страну проживания
+type JurisdictionBlock struct {
+	Countries []string
+}
+
+func (r JurisdictionBlock) Check(f Facts) (Violation, bool) {
+	if f.Country == "" {
+		return Violation{
+			Rule:    RuleJurisdiction,
+			Message: "country of residence must be set before investing",
+		}, true
+	}
+	if !slices.Contains(r.Countries, strings.ToUpper(f.Country)) {
+		return Violation{}, false
+	}
+	return Violation{
+		Rule:    RuleJurisdiction,
+		Message: fmt.Sprintf("investments from %s are not allowed", strings.ToUpper(f.Country)),
+	}, true
+}
+
+// Merge возвращает действующие правила проекта: заданные для проекта лимиты заменяют
+// глобальные, а списки запрещенных стран объединяются
+func Merge(global model.InvestmentRules, project model.InvestmentRules) model.InvestmentRules {
+	merged := global
+	if project.AnnualCap != nil {
+		merged.AnnualCap = project.AnnualCap
+	}
+	if project.MinTicket != nil {
+		merged.MinTicket = project.MinTicket
+	}
+	if project.MaxTicket != nil {
+		merged.MaxTicket = project.MaxTicket
+	}
+	if project.MaxProjectShare != nil {
+		merged.MaxProjectShare = project.MaxProjectShare
+	}
+
+	merged.BlockedCountries = slices.Clone(global.BlockedCountries)
+	for _, country := range project.BlockedCountries {
+		if !slices.Contains(merged.BlockedCountries, country) {
+			merged.BlockedCountries = append(merged.BlockedCountries, country)
+		}
+	}
+	return merged
+}
+
+// Build создает правила из настроек. Незаданные лимиты не проверяются
+func Build(rules model.InvestmentRules) []Rule {
+	var built []Rule
+	if len(rules.BlockedCountries) > 0 {
+		built = append(built, JurisdictionBlock{Countries: rules.BlockedCountries})
+	}
+	if rules.MinTicket != nil {
+		built = append(built, MinTicket{Min: *rules.MinTicket})
+	}
+	if rules.MaxTicket != nil {
+		built = append(built, MaxTicket{Max: *rules.MaxTicket})
+	}
+	if rules.MaxProjectShare != nil {
+		built = append(built, MaxProjectShare{Share: *rules.MaxProjectShare})
+	}
+	if rules.AnnualCap != nil {
+		built = append(built, AnnualCap{Limit: *rules.AnnualCap})
+	}
+	return built
+}
+
+// Evaluate проверяет все правила и возвращает найденные нарушения
+func Evaluate(rules []Rule, f Facts) []Violation {
+	var violations []Violation
+	for _, rule := range rules {
+		if v, violated := rule.Check(f); violated {
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// Validate проверяет корректность настроек правил
+func Validate(rules model.InvestmentRules) error {
+	for name, value := range map[string]*decimal.Decimal{
+		"annual_cap": rules.AnnualCap,
+		"min_ticket": rules.MinTicket,
+		"max_ticket": rules.MaxTicket,
+	} {
+		if value != nil && !value.IsPositive() {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	}
+	if rules.MinTicket != nil && rules.MaxTicket != nil && rules.MinTicket.GreaterThan(*rules.MaxTicket) {
+		return fmt.Errorf("min_ticket must not exceed max_ticket")
+	}
+	if share := rules.MaxProjectShare; share != nil && (!share.IsPositive() || share.GreaterThan(decimal.NewFromInt(1))) {
+		return fmt.Errorf("max_project_share must be greater than 0 and at most 1")
+	}
+	for _, country := range rules.BlockedCountries {
+		if !IsCountryCode(country) {
+			return fmt.Errorf("invalid country code %q", country)
+		}
+	}
+	return nil
+}
+
+// IsCountryCode проверяет формат кода страны ISO 3166-1 alpha-2 в верхнем регистре
+func IsCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,62 +1,306 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
+	"net"
+	"net/url"
 	"os"
+
+	"github.com/shopspring/decimal"
 )
 
 // Config - основная структура конфигурации приложения
 type Config struct {
-	Database DatabaseConfig `json:"database"`
-	Server   ServerConfig   `json:"server"`
-	Logger   LoggerConfig   `json:"logger"`
+	Database    DatabaseConfig    `json:"database"`
+	Server      ServerConfig      `json:"server"`
+	Logger      LoggerConfig      `json:"logger"`
+	Mailer      MailerConfig      `json:"mailer"`
+	Realtime    RealtimeConfig    `json:"realtime"`
+	Auth        AuthConfig        `json:"auth"`
+	RateLimit   RateLimitConfig   `json:"rate_limit"`
+	KYC         KYCConfig         `json:"kyc"`
+	Compliance  ComplianceConfig  `json:"compliance"`
+	Screening   ScreeningConfig   `json:"screening"`
+	Privacy     PrivacyConfig     `json:"privacy"`
+	SoftDelete  SoftDeleteConfig  `json:"soft_delete"`
+	Idempotency IdempotencyConfig `json:"idempotency"`
+	Tracing     TracingConfig     `json:"tracing"`
+
+	path string
 }
 
 // DatabaseConfig - конфигурация базы данных
 type DatabaseConfig struct {
+	URL      string `json:"url" secret:"true"` // строка подключения целиком, заменяет остальные параметры
 	User     string `json:"user"`
-	Password string `json:"password"`
+	Password string `json:"password" secret:"true" source:"env"` // только из окружения
 	Host     string `json:"host"`
 	Port     string `json:"port"`
 	Name     string `json:"name"`
+
+	SSLMode         string `json:"ssl_mode"`      // disable, allow, prefer, require, verify-ca или verify-full
+	SSLRootCert     string `json:"ssl_root_cert"` // CA для проверки сертификата сервера при verify-ca и verify-full
+	SSLCert         string `json:"ssl_cert"`      // клиентский сертификат
+	SSLKey          string `json:"ssl_key"`
+	ApplicationName string `json:"application_name"` // имя приложения в pg_stat_activity
+
+	MaxConns         int `json:"max_conns"`
+	MinConns         int `json:"min_conns"`
+	MaxConnLifetime  int `json:"max_conn_lifetime"`  // в секундах
+	MaxConnIdleTime  int `json:"max_conn_idle_time"` // в секундах
+	StatementTimeout int `json:"statement_timeout"`  // в секундах, 0 - без ограничения
+
+	ReplicaURL  string `json:"replica_url" secret:"true"` // строка подключения к реплике для чтения целиком
+	ReplicaHost string `json:"replica_host"`              // реплика с теми же учетными данными и настройками, что и основная БД
+	ReplicaPort string `json:"replica_port"`
 }
 
 // ServerConfig - конфигурация HTTP-сервера
 type ServerConfig struct {
-	Host            string `json:"host"`
-	Port            string `json:"port"`
-	ReadTimeout     int    `json:"read_timeout"`     // в секундах
-	WriteTimeout    int    `json:"write_timeout"`    // в секундах
-	IdleTimeout     int    `json:"idle_timeout"`     // в секундах
-	ShutdownTimeout int    `json:"shutdown_timeout"` // в секундах
+	Host            string    `json:"host"`
+	Port            string    `json:"port"`
+	ReadTimeout     int       `json:"read_timeout"`     // в секундах
+	WriteTimeout    int       `json:"write_timeout"`    // в секундах
+	IdleTimeout     int       `json:"idle_timeout"`     // в секундах
+	ShutdownTimeout int       `json:"shutdown_timeout"` // в секундах
+	ShutdownDelay   int       `json:"shutdown_delay"`   // в секундах, сколько /readyz отвечает 503 до остановки сервера
+	BodyLimit       int       `json:"body_limit"`       // в мегабайтах, максимальный размер тела запроса
+	TrustedProxies  []string  `json:"trusted_proxies"`  // IP-адреса и подсети прокси, которым доверяется заголовок ProxyHeader
+	ProxyHeader     string    `json:"proxy_header"`     // заголовок с адресом клиента, по умолчанию X-Forwarded-For
+	MetricsHost     string    `json:"metrics_host"`     // адрес внутреннего listener'а с /metrics, недоступного из публичной сети
+	MetricsPort     string    `json:"metrics_port"`
+	TLS             TLSConfig `json:"tls"`
+}
+
+// TLSConfig - конфигурация HTTPS. Если сертификат не задан, сервер принимает HTTP
+type TLSConfig struct {
+	CertFile     string `json:"cert_file"`
+	KeyFile      string `json:"key_file"`
+	ClientCAFile string `json:"client_ca_file"` // если задан, маршруты /admin требуют клиентский сертификат, подписанный этим CA
 }
 
 // LoggerConfig - конфигурация логгера
 type LoggerConfig struct {
-	Level      string `json:"level"`
-	OutputPath string `json:"output_path"`
-	Encoding   string `json:"encoding"`
-	DevMode    bool   `json:"dev_mode"`
+	Level      string            `json:"level"`
+	OutputPath string            `json:"output_path"` // stdout, stderr или путь к файлу, если sinks не заданы
+	Encoding   string            `json:"encoding"`
+	DevMode    bool              `json:"dev_mode"`
+	Sinks      []LogSinkConfig   `json:"sinks"` // выводы логов; в переменной окружения задаются массивом JSON
+	Sampling   LogSamplingConfig `json:"sampling"`
 }
 
-// Load загружает конфигурацию из JSON-файла
-func Load(path string) (*Config, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка открытия файла конфигурации: %w", err)
+// LogSinkConfig - конфигурация одного вывода логов
+type LogSinkConfig struct {
+	Type     string `json:"type"`     // stdout, stderr, file или syslog
+	Level    string `json:"level"`    // минимальный уровень записей для этого вывода, не ниже общего level
+	Encoding string `json:"encoding"` // console или json, по умолчанию logger.encoding
+
+	Path           string `json:"path"`            // путь к файлу при type = file
+	MaxSize        int    `json:"max_size"`        // в мегабайтах, размер файла, после которого он ротируется
+	MaxAge         int    `json:"max_age"`         // в днях, срок хранения ротированных файлов
+	MaxBackups     int    `json:"max_backups"`     // число хранимых ротированных файлов, 0 - без ограничения
+	RotateInterval int    `json:"rotate_interval"` // в часах, ротация по времени, 0 - только по размеру
+	Compress       bool   `json:"compress"`        // сжимать ротированные файлы gzip
+
+	Network string `json:"network"` // при type = syslog: пустая строка для локального демона, udp, tcp или unix
+	Address string `json:"address"`
+	Tag     string `json:"tag"`
+}
+
+// LogSamplingConfig - ограничение объема логов: из одинаковых записей за секунду
+// пишутся первые Initial, затем каждая Thereafter-я. При Thereafter = 0 ограничение отключено
+type LogSamplingConfig struct {
+	Initial    int `json:"initial"`
+	Thereafter int `json:"thereafter"`
+}
+
+// MailerConfig - конфигурация отправки почты
+type MailerConfig struct {
+	Driver   string `json:"driver"` // smtp или file
+	From     string `json:"from"`
+	SMTPHost string `json:"smtp_host"`
+	SMTPPort string `json:"smtp_port"`
+	Username string `json:"username"`
+	Password string `json:"password" secret:"true" source:"env"` // только из окружения
+	Dir      string `json:"dir"`                                 // каталог для писем при driver = file
+}
+
+// RealtimeConfig - конфигурация потоковых обновлений по WebSocket и SSE
+type RealtimeConfig struct {
+	MaxConnections           int `json:"max_connections"`
+	MaxConnectionsPerProject int `json:"max_connections_per_project"`
+	PingInterval             int `json:"ping_interval"` // в секундах
+}
+
+// AuthConfig - конфигурация аутентификации
+type AuthConfig struct {
+	FrontendURL           string          `json:"frontend_url"`                            // адрес фронтенда для ссылок в письмах
+	EmailTokenTTL         int             `json:"email_token_ttl"`                         // в часах
+	PasswordResetTokenTTL int             `json:"password_reset_token_ttl"`                // в минутах
+	TokenSecret           string          `json:"token_secret" secret:"true" source:"env"` // ключ подписи токенов доступа, не короче 32 символов, только из окружения
+	TokenTTL              int             `json:"token_ttl"`                               // в минутах
+	TOTPIssuer            string          `json:"totp_issuer"`
+	StepUpWindow          int             `json:"step_up_window"`          // в минутах
+	LargeInvestmentAmount decimal.Decimal `json:"large_investment_amount"` // начиная с этой суммы инвестиция требует повторного ввода 2FA
+	SIWEDomain            string          `json:"siwe_domain"`             // домен, для которого подписываются сообщения Sign-In With Ethereum
+	SIWEChainIDs          []int64         `json:"siwe_chain_ids"`          // допустимые Chain ID сетей
+	SIWENonceTTL          int             `json:"siwe_nonce_ttl"`          // в минутах
+}
+
+// RateLimitConfig - конфигурация ограничения частоты запросов
+type RateLimitConfig struct {
+	Store    string        `json:"store"`    // memory или postgres для нескольких реплик
+	IP       RateLimitRule `json:"ip"`       // на IP-адрес для всех маршрутов API
+	Account  RateLimitRule `json:"account"`  // на аутентифицированный аккаунт
+	Auth     RateLimitRule `json:"auth"`     // на IP-адрес для маршрутов /auth
+	Accounts RateLimitRule `json:"accounts"` // на IP-адрес для маршрутов /accounts
+}
+
+// KYCConfig - конфигурация проверки личности
+type KYCConfig struct {
+	Provider                  string          `json:"provider"`                    // manual или mock
+	MockDecision              string          `json:"mock_decision"`               // решение mock-провайдера: verified, rejected или pending
+	StorageDir                string          `json:"storage_dir"`                 // каталог для файлов документов
+	MaxDocumentSize           int             `json:"max_document_size"`           // в мегабайтах
+	UnverifiedInvestmentLimit decimal.Decimal `json:"unverified_investment_limit"` // общая сумма инвестиций, доступная без KYC
+}
+
+// ComplianceConfig - глобальные правила инвестирования. Нулевые лимиты не проверяются,
+// для отдельного проекта их можно переопределить
+type ComplianceConfig struct {
+	AnnualCap        decimal.Decimal `json:"annual_cap"`        // сумма инвестиций одного инвестора за календарный год
+	MinTicket        decimal.Decimal `json:"min_ticket"`        // минимальная сумма одной инвестиции
+	MaxTicket        decimal.Decimal `json:"max_ticket"`        // максимальная сумма одной инвестиции
+	MaxProjectShare  decimal.Decimal `json:"max_project_share"` // доля проекта на одного инвестора, от 0 до 1
+	BlockedCountries []string        `json:"blocked_countries"` // коды стран ISO 3166-1 alpha-2
+}
+
+// ScreeningConfig - конфигурация проверки по санкционным спискам
+type ScreeningConfig struct {
+	Lists            []string `json:"lists"`             // пути к файлам списков в формате CSV или JSON
+	NameThreshold    float64  `json:"name_threshold"`    // минимальное сходство имен от 0 до 1
+	RescreenInterval int      `json:"rescreen_interval"` // в минутах, период проверки обновления списков
+}
+
+// PrivacyConfig - конфигурация выгрузки и удаления персональных данных
+type PrivacyConfig struct {
+	ErasureGracePeriod int `json:"erasure_grace_period"` // в днях, срок до удаления данных, в течение которого запрос можно отменить
+}
+
+// SoftDeleteConfig - конфигурация хранения удаленных записей до окончательного удаления
+type SoftDeleteConfig struct {
+	Retention           int `json:"retention"`            // в днях, срок хранения удаленных пользователей и проектов
+	InvestmentRetention int `json:"investment_retention"` // в днях, срок хранения удаленных инвестиций
+}
+
+// IdempotencyConfig - конфигурация ключей идемпотентности
+type IdempotencyConfig struct {
+	KeyTTL      int `json:"key_ttl"`      // в часах, срок, в течение которого повтор запроса с тем же ключом возвращает сохраненный ответ
+	LockTimeout int `json:"lock_timeout"` // в секундах, срок, после которого ключ незавершенного запроса можно занять заново
+}
+
+// TracingConfig - конфигурация трассировки OpenTelemetry
+type TracingConfig struct {
+	Exporter    string  `json:"exporter"`     // none, stdout или otlp
+	Endpoint    string  `json:"endpoint"`     // адрес OTLP-коллектора, например localhost:4317
+	Protocol    string  `json:"protocol"`     // grpc или http
+	Insecure    bool    `json:"insecure"`     // подключение к коллектору без TLS
+	SampleRatio float64 `json:"sample_ratio"` // доля новых трасс от 0 до 1; входящие запросы следуют решению вызывающей стороны
+	ServiceName string  `json:"service_name"`
+}
+
+// RateLimitRule - лимит корзины токенов: не более Requests запросов за Period
+type RateLimitRule struct {
+	Requests int `json:"requests"`
+	Period   int `json:"period"` // в секундах
+}
+
+// DSN возвращает строку подключения к базе данных
+func (c DatabaseConfig) DSN() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	return c.dsn(c.Host, c.Port)
+}
+
+// ReplicaDSN возвращает строку подключения к реплике для чтения или пустую строку,
+// если реплика не настроена
+func (c DatabaseConfig) ReplicaDSN() string {
+	switch {
+	case c.ReplicaURL != "":
+		return c.ReplicaURL
+	case c.ReplicaHost == "":
+		return ""
+	case c.URL != "":
+		// Реплика отличается от основной БД только адресом
+		dsn, err := url.Parse(c.URL)
+		if err != nil {
+			return ""
+		}
+		dsn.Host = net.JoinHostPort(c.ReplicaHost, c.replicaPort(dsn.Port()))
+		return dsn.String()
+	default:
+		return c.dsn(c.ReplicaHost, c.replicaPort(c.Port))
+	}
+}
+
+func (c DatabaseConfig) replicaPort(primary string) string {
+	if c.ReplicaPort != "" {
+		return c.ReplicaPort
 	}
-	defer file.Close()
+	return primary
+}
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+func (c DatabaseConfig) dsn(host string, port string) string {
+	query := url.Values{}
+	query.Set("sslmode", c.SSLMode)
+	if c.SSLRootCert != "" {
+		query.Set("sslrootcert", c.SSLRootCert)
+	}
+	if c.SSLCert != "" {
+		query.Set("sslcert", c.SSLCert)
+		query.Set("sslkey", c.SSLKey)
+	}
+	if c.ApplicationName != "" {
+		query.Set("application_name", c.ApplicationName)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/" + c.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// Load собирает конфигурацию из источников в порядке возрастания приоритета: значения по
+// умолчанию, файл, переменные окружения. Путь к файлу берется из аргумента (флаг --config),
+// затем из CRYPTOCROWD_CONFIG. Если путь не задан, читается DefaultPath при его наличии
+func Load(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv(envConfigPath)
+	}
+	if path == "" {
+		if _, err := os.Stat(DefaultPath); err == nil {
+			path = DefaultPath
+		}
 	}
 
 	var cfg Config
-	if err = json.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования файла конфигурации: %w", err)
+	if path != "" {
+		if err := readFile(path, &cfg); err != nil {
+			return nil, err
+		}
+		if err := rejectFileSecrets(&cfg); err != nil {
+			return nil, err
+		}
+	}
+	cfg.path = path
+
+	if err := applyEnv(&cfg, os.Environ()); err != nil {
+		return nil, fmt.Errorf("ошибка чтения переменных окружения: %w", err)
 	}
 
 	// Устанавливаем значения по умолчанию, если они не определены
@@ -65,8 +309,60 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// Path возвращает путь к прочитанному файлу конфигурации или пустую строку,
+// если конфигурация собрана только из переменных окружения
+func (c *Config) Path() string {
+	return c.path
+}
+
 // setDefaults устанавливает значения по умолчанию для параметров, которые не были заданы
 func setDefaults(cfg *Config) {
+	// Значения по умолчанию для базы данных
+	if cfg.Database.SSLMode == "" {
+		cfg.Database.SSLMode = "prefer"
+	}
+	if cfg.Database.ApplicationName == "" {
+		cfg.Database.ApplicationName = "cryptocrowd"
+	}
+	if cfg.Database.MaxConns == 0 {
+		cfg.Database.MaxConns = 100
+	}
+	if cfg.Database.MinConns == 0 {
+		cfg.Database.MinConns = 10
+	}
+	if cfg.Database.MaxConnLifetime == 0 {
+		cfg.Database.MaxConnLifetime = 60 // 60 секунд
+	}
+	if cfg.Database.MaxConnIdleTime == 0 {
+		cfg.Database.MaxConnIdleTime = 30 // 30 секунд
+	}
+
+	// Значения по умолчанию для логгера
+	if cfg.Logger.Encoding == "" {
+		cfg.Logger.Encoding = "json"
+	}
+	if len(cfg.Logger.Sinks) == 0 {
+		cfg.Logger.Sinks = []LogSinkConfig{outputPathSink(cfg.Logger.OutputPath)}
+	}
+	for idx := range cfg.Logger.Sinks {
+		sink := &cfg.Logger.Sinks[idx]
+		if sink.Encoding == "" {
+			sink.Encoding = cfg.Logger.Encoding
+		}
+		if sink.Type == "file" && sink.MaxSize == 0 {
+			sink.MaxSize = 100 // 100 МБ
+		}
+		if sink.Type == "file" && sink.MaxAge == 0 {
+			sink.MaxAge = 14 // 14 дней
+		}
+		if sink.Type == "syslog" && sink.Tag == "" {
+			sink.Tag = "cryptocrowd"
+		}
+	}
+	if cfg.Logger.Sampling.Thereafter > 0 && cfg.Logger.Sampling.Initial == 0 {
+		cfg.Logger.Sampling.Initial = 100
+	}
+
 	// Значения по умолчанию для сервера
 	if cfg.Server.Host == "" {
 		cfg.Server.Host = "127.0.0.1"
@@ -86,4 +382,165 @@ func setDefaults(cfg *Config) {
 	if cfg.Server.ShutdownTimeout == 0 {
 		cfg.Server.ShutdownTimeout = 10 // 10 секунд
 	}
+	if cfg.Server.BodyLimit == 0 {
+		cfg.Server.BodyLimit = 4 // 4 МБ
+	}
+	if cfg.Server.MetricsHost == "" {
+		cfg.Server.MetricsHost = "127.0.0.1"
+	}
+	if cfg.Server.MetricsPort == "" {
+		cfg.Server.MetricsPort = "9100"
+	}
+	if len(cfg.Server.TrustedProxies) > 0 && cfg.Server.ProxyHeader == "" {
+		cfg.Server.ProxyHeader = "X-Forwarded-For"
+	}
+
+	// Значения по умолчанию для почты
+	if cfg.Mailer.Driver == "" {
+		cfg.Mailer.Driver = "file"
+	}
+	if cfg.Mailer.From == "" {
+		cfg.Mailer.From = "no-reply@cryptocrowd.local"
+	}
+	if cfg.Mailer.SMTPPort == "" {
+		cfg.Mailer.SMTPPort = "587"
+	}
+	if cfg.Mailer.Dir == "" {
+		cfg.Mailer.Dir = "mail"
+	}
+
+	// Значения по умолчанию для потоковых обновлений
+	if cfg.Realtime.MaxConnections == 0 {
+		cfg.Realtime.MaxConnections = 10000
+	}
+	if cfg.Realtime.MaxConnectionsPerProject == 0 {
+		cfg.Realtime.MaxConnectionsPerProject = 1000
+	}
+	if cfg.Realtime.PingInterval == 0 {
+		cfg.Realtime.PingInterval = 30 // 30 секунд
+	}
+
+	// Значения по умолчанию для аутентификации
+	if cfg.Auth.FrontendURL == "" {
+		cfg.Auth.FrontendURL = "http://localhost:3000"
+	}
+	if cfg.Auth.EmailTokenTTL == 0 {
+		cfg.Auth.EmailTokenTTL = 48 // 48 часов
+	}
+	if cfg.Auth.PasswordResetTokenTTL == 0 {
+		cfg.Auth.PasswordResetTokenTTL = 60 // 60 минут
+	}
+	if cfg.Auth.TokenTTL == 0 {
+		cfg.Auth.TokenTTL = 60 // 60 минут
+	}
+	if cfg.Auth.TOTPIssuer == "" {
+		cfg.Auth.TOTPIssuer = "CryptoCrowd"
+	}
+	if cfg.Auth.StepUpWindow == 0 {
+		cfg.Auth.StepUpWindow = 5 // 5 минут
+	}
+	if cfg.Auth.LargeInvestmentAmount.IsZero() {
+		cfg.Auth.LargeInvestmentAmount = decimal.NewFromInt(10000)
+	}
+	if cfg.Auth.SIWEDomain == "" {
+		cfg.Auth.SIWEDomain = "localhost:3000"
+	}
+	if len(cfg.Auth.SIWEChainIDs) == 0 {
+		cfg.Auth.SIWEChainIDs = []int64{1} // Ethereum Mainnet
+	}
+	if cfg.Auth.SIWENonceTTL == 0 {
+		cfg.Auth.SIWENonceTTL = 10 // 10 минут
+	}
+
+	// Значения по умолчанию для ограничения частоты запросов
+	if cfg.RateLimit.Store == "" {
+		cfg.RateLimit.Store = "memory"
+	}
+	setRuleDefaults(&cfg.RateLimit.IP, 300, 60)
+	setRuleDefaults(&cfg.RateLimit.Account, 600, 60)
+	setRuleDefaults(&cfg.RateLimit.Auth, 10, 60)
+	setRuleDefaults(&cfg.RateLimit.Accounts, 20, 60)
+
+	// Значения по умолчанию для проверки личности
+	if cfg.KYC.Provider == "" {
+		cfg.KYC.Provider = "manual"
+	}
+	if cfg.KYC.MockDecision == "" {
+		cfg.KYC.MockDecision = "verified"
+	}
+	if cfg.KYC.StorageDir == "" {
+		cfg.KYC.StorageDir = "kyc-documents"
+	}
+	if cfg.KYC.MaxDocumentSize == 0 {
+		// Документ вместе с multipart-оберткой должен уложиться в лимит тела запроса (server.body_limit)
+		cfg.KYC.MaxDocumentSize = 3
+	}
+	if cfg.KYC.UnverifiedInvestmentLimit.IsZero() {
+		cfg.KYC.UnverifiedInvestmentLimit = decimal.NewFromInt(1000)
+	}
+
+	// Значения по умолчанию для проверки по санкционным спискам
+	if cfg.Screening.NameThreshold == 0 {
+		cfg.Screening.NameThreshold = 0.92
+	}
+	if cfg.Screening.RescreenInterval == 0 {
+		cfg.Screening.RescreenInterval = 60 // 60 минут
+	}
+
+	// Значения по умолчанию для удаления персональных данных
+	if cfg.Privacy.ErasureGracePeriod == 0 {
+		cfg.Privacy.ErasureGracePeriod = 30 // 30 дней
+	}
+
+	// Значения по умолчанию для хранения удаленных записей
+	if cfg.SoftDelete.Retention == 0 {
+		cfg.SoftDelete.Retention = 90 // 90 дней
+	}
+	if cfg.SoftDelete.InvestmentRetention == 0 {
+		cfg.SoftDelete.InvestmentRetention = 3650 // 10 лет
+	}
+
+	// Значения по умолчанию для ключей идемпотентности
+	if cfg.Idempotency.KeyTTL == 0 {
+		cfg.Idempotency.KeyTTL = 24 // 24 часа
+	}
+	if cfg.Idempotency.LockTimeout == 0 {
+		cfg.Idempotency.LockTimeout = 60 // 60 секунд
+	}
+
+	// Значения по умолчанию для трассировки
+	if cfg.Tracing.Exporter == "" {
+		cfg.Tracing.Exporter = "none"
+	}
+	if cfg.Tracing.Protocol == "" {
+		cfg.Tracing.Protocol = "grpc"
+	}
+	if cfg.Tracing.SampleRatio == 0 {
+		cfg.Tracing.SampleRatio = 1
+	}
+	if cfg.Tracing.ServiceName == "" {
+		cfg.Tracing.ServiceName = "cryptocrowd"
+	}
+}
+
+// outputPathSink возвращает вывод, заданный устаревшим параметром output_path
+func outputPathSink(outputPath string) LogSinkConfig {
+	switch outputPath {
+	case "", "stdout":
+		return LogSinkConfig{Type: "stdout"}
+	case "stderr":
+		return LogSinkConfig{Type: "stderr"}
+	default:
+		return LogSinkConfig{Type: "file", Path: outputPath}
+	}
+}
+
+// setRuleDefaults заполняет незаданные поля лимита
+func setRuleDefaults(rule *RateLimitRule, requests int, period int) {
+	if rule.Requests == 0 {
+		rule.Requests = requests
+	}
+	if rule.Period == 0 {
+		rule.Period = period
+	}
 }
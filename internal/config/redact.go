@@ -0,0 +1,27 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// redactedValue заменяет значения секретных параметров в выводе конфигурации
+const redactedValue = "***"
+
+// Redacted возвращает итоговую конфигурацию в формате JSON, в которой значения полей
+// с тегом secret:"true" заменены на redactedValue. Незаданные секреты остаются пустыми,
+// чтобы по выводу было видно, что параметр не передан
+func (c *Config) Redacted() string {
+	redacted := *c
+	walkFields(reflect.ValueOf(&redacted).Elem(), EnvPrefix, func(field reflect.Value, sf reflect.StructField, _ string) {
+		if sf.Tag.Get("secret") == "true" && field.Kind() == reflect.String && field.String() != "" {
+			field.SetString(redactedValue)
+		}
+	})
+
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Watcher хранит действующую конфигурацию и перечитывает ее по запросу: по сигналу SIGHUP,
+// при изменении файла или из административного API. Без перезапуска применяются только
+// параметры из copyReloadable, об изменении остальных выводится предупреждение
+type Watcher struct {
+	mu          sync.Mutex
+	current     *Config
+	modTime     time.Time
+	subscribers []func(*Config)
+}
+
+// NewWatcher создает наблюдатель для конфигурации, загруженной через Load
+func NewWatcher(cfg *Config) *Watcher {
+	w := &Watcher{current: cfg}
+	if cfg.path != "" {
+		if info, err := os.Stat(cfg.path); err == nil {
+			w.modTime = info.ModTime()
+		}
+	}
+	return w
+}
+
+// Current возвращает действующую конфигурацию. Возвращаемое значение нельзя изменять
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Subscribe регистрирует функцию, которая вызывается с новой конфигурацией после
+// каждой успешной перезагрузки
+func (w *Watcher) Subscribe(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload перечитывает файл и переменные окружения. Если новая конфигурация не проходит
+// проверку, действующая остается без изменений. Возвращает список параметров,
+// изменение которых требует перезапуска
+func (w *Watcher) Reload() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Версия файла запоминается и при ошибке, чтобы некорректный файл не перечитывался,
+	// пока его не исправят
+	if w.current.path != "" {
+		if info, err := os.Stat(w.current.path); err == nil {
+			w.modTime = info.ModTime()
+		}
+	}
+
+	loaded, err := Load(w.current.path)
+	if err != nil {
+		return nil, err
+	}
+	if err = loaded.Validate(); err != nil {
+		return nil, fmt.Errorf("некорректная конфигурация:\n%w", err)
+	}
+
+	next := *w.current
+	copyReloadable(&next, loaded)
+	restartRequired := changedSections(&next, loaded)
+
+	w.current = &next
+	for _, fn := range w.subscribers {
+		fn(w.current)
+	}
+
+	return restartRequired, nil
+}
+
+// Changed сообщает, изменился ли файл конфигурации с последней загрузки
+func (w *Watcher) Changed() (bool, error) {
+	w.mu.Lock()
+	path, modTime := w.current.path, w.modTime
+	w.mu.Unlock()
+
+	if path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки файла конфигурации: %w", err)
+	}
+	return !info.ModTime().Equal(modTime), nil
+}
+
+// copyReloadable копирует из src параметры, которые компоненты применяют без перезапуска
+func copyReloadable(dst *Config, src *Config) {
+	dst.Logger.Level = src.Logger.Level
+	dst.RateLimit.IP = src.RateLimit.IP
+	dst.RateLimit.Account = src.RateLimit.Account
+	dst.RateLimit.Auth = src.RateLimit.Auth
+	dst.RateLimit.Accounts = src.RateLimit.Accounts
+}
+
+// changedSections возвращает json-имена разделов, которые в loaded отличаются от действующих
+func changedSections(current *Config, loaded *Config) []string {
+	var changed []string
+
+	cv, lv := reflect.ValueOf(current).Elem(), reflect.ValueOf(loaded).Elem()
+	for idx := range cv.NumField() {
+		sf := cv.Type().Field(idx)
+		if !sf.IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(cv.Field(idx).Interface(), lv.Field(idx).Interface()) {
+			changed = append(changed, sf.Tag.Get("json"))
+		}
+	}
+	return changed
+}
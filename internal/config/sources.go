@@ -0,0 +1,215 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix - префикс переменных окружения, переопределяющих параметры конфигурации.
+// Имя переменной составляется из json-тегов полей: CRYPTOCROWD_DATABASE_HOST
+const EnvPrefix = "CRYPTOCROWD"
+
+// secretFileSuffix - суффикс переменной окружения с путем к файлу, из которого читается значение
+const secretFileSuffix = "_FILE"
+
+// envConfigPath - переменная окружения с путем к файлу конфигурации, если не указан флаг --config
+const envConfigPath = EnvPrefix + "_CONFIG"
+
+// DefaultPath - файл конфигурации, который читается, если путь не задан. В отличие от явно
+// указанного файла, его отсутствие не является ошибкой
+const DefaultPath = "config.json"
+
+// readFile декодирует файл конфигурации в формате JSON, YAML или TOML по расширению.
+// Неизвестные параметры считаются ошибкой
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+	case ".yaml", ".yml":
+		var doc map[string]any
+		if err = yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("ошибка декодирования YAML: %w", err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return fmt.Errorf("ошибка декодирования YAML: %w", err)
+		}
+	case ".toml":
+		var doc map[string]any
+		if err = toml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("ошибка декодирования TOML: %w", err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return fmt.Errorf("ошибка декодирования TOML: %w", err)
+		}
+	default:
+		return fmt.Errorf("неподдерживаемый формат файла конфигурации: %s", ext)
+	}
+
+	// YAML и TOML приводятся к JSON, чтобы все форматы использовали одни теги и типы полей
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("ошибка декодирования файла конфигурации: %w", err)
+	}
+	return nil
+}
+
+// rejectFileSecrets возвращает ошибку, если в файле конфигурации задан параметр с тегом
+// source:"env". Такие ключи передаются только через переменную окружения или файл секрета,
+// чтобы они не попадали в репозиторий вместе с файлом конфигурации
+func rejectFileSecrets(cfg *Config) error {
+	var errs []error
+	walkFields(reflect.ValueOf(cfg).Elem(), EnvPrefix, func(field reflect.Value, sf reflect.StructField, name string) {
+		if sf.Tag.Get("source") == "env" && !field.IsZero() {
+			errs = append(errs, fmt.Errorf("%s: параметр нельзя задавать в файле конфигурации, используйте %s или %s",
+				sf.Tag.Get("json"), name, name+secretFileSuffix))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// applyEnv переопределяет параметры значениями переменных окружения с префиксом EnvPrefix.
+// Переменная с суффиксом _FILE указывает файл, из которого читается значение, например секрет
+// из Docker или Kubernetes. Возвращает все найденные ошибки, включая неизвестные переменные
+func applyEnv(cfg *Config, environ []string) error {
+	env := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if ok && strings.HasPrefix(name, EnvPrefix+"_") {
+			env[name] = value
+		}
+	}
+	delete(env, envConfigPath)
+
+	var errs []error
+	walkFields(reflect.ValueOf(cfg).Elem(), EnvPrefix, func(field reflect.Value, _ reflect.StructField, name string) {
+		value, plainSet := env[name]
+		path, fileSet := env[name+secretFileSuffix]
+		delete(env, name)
+		delete(env, name+secretFileSuffix)
+
+		switch {
+		case plainSet && fileSet:
+			errs = append(errs, fmt.Errorf("%s: заданы одновременно %s и %s", name, name, name+secretFileSuffix))
+			return
+		case fileSet:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: ошибка чтения файла секрета: %w", name+secretFileSuffix, err))
+				return
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		case !plainSet:
+			return
+		}
+
+		if err := setField(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	})
+
+	for name := range env {
+		errs = append(errs, fmt.Errorf("%s: неизвестная переменная окружения", name))
+	}
+	return errors.Join(errs...)
+}
+
+// walkFields вызывает fn для каждого конечного поля структуры. name - имя поля в верхнем
+// регистре, составленное из json-тегов с префиксом, например CRYPTOCROWD_RATE_LIMIT_IP_REQUESTS
+func walkFields(v reflect.Value, prefix string, fn func(field reflect.Value, sf reflect.StructField, name string)) {
+	t := v.Type()
+	for idx := range t.NumField() {
+		sf := t.Field(idx)
+		tag, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(tag)
+		field := v.Field(idx)
+		if field.Kind() == reflect.Struct && !isTextValue(field) {
+			walkFields(field, name, fn)
+			continue
+		}
+		fn(field, sf, name)
+	}
+}
+
+// isTextValue сообщает, задается ли значение поля строкой, как decimal.Decimal
+func isTextValue(field reflect.Value) bool {
+	_, ok := field.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// setField присваивает полю значение из строки переменной окружения.
+// Списки задаются через запятую
+func setField(field reflect.Value, value string) error {
+	if isTextValue(field) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число: %q", value)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("ожидается число: %q", value)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("ожидается true или false: %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		// Списки структур, например выводы логов, задаются массивом JSON
+		if field.Type().Elem().Kind() == reflect.Struct {
+			items := reflect.New(field.Type())
+			if err := json.Unmarshal([]byte(value), items.Interface()); err != nil {
+				return fmt.Errorf("ожидается массив JSON: %w", err)
+			}
+			field.Set(items.Elem())
+			return nil
+		}
+
+		items := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, item); err != nil {
+				return err
+			}
+			items = reflect.Append(items, elem)
+		}
+		field.Set(items)
+	default:
+		return fmt.Errorf("неподдерживаемый тип параметра: %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayering(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "json", file: "config.json", content: `{"database": {"host": "db.internal", "max_conns": 20}, "server": {"port": "8000"}}`},
+		{name: "yaml", file: "config.yaml", content: "database:\n  host: db.internal\n  max_conns: 20\nserver:\n  port: \"8000\"\n"},
+		{name: "toml", file: "config.toml", content: "[database]\nhost = \"db.internal\"\nmax_conns = 20\n\n[server]\nport = \"8000\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CRYPTOCROWD_SERVER_PORT", "8100")
+
+			cfg, err := Load(writeFile(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			// Файл переопределяет значения по умолчанию, окружение - файл
+			if cfg.Database.Host != "db.internal" || cfg.Database.MaxConns != 20 {
+				t.Fatalf("database = %s, %d max conns, want the values of the file", cfg.Database.Host, cfg.Database.MaxConns)
+			}
+			if cfg.Server.Port != "8100" {
+				t.Fatalf("server.port = %s, want 8100 from the environment", cfg.Server.Port)
+			}
+			if cfg.Database.SSLMode != "prefer" || cfg.Server.Host != "127.0.0.1" {
+				t.Fatalf("defaults are not applied: ssl_mode = %q, server.host = %q", cfg.Database.SSLMode, cfg.Server.Host)
+			}
+		})
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown parameter", file: "config.json", content: `{"database": {"hots": "db"}}`, wantErr: "hots"},
+		{name: "wrong type", file: "config.json", content: `{"database": {"max_conns": "many"}}`, wantErr: "max_conns"},
+		{name: "invalid YAML", file: "config.yaml", content: "database: [", wantErr: "YAML"},
+		{name: "unsupported format", file: "config.ini", content: "[database]", wantErr: "неподдерживаемый формат"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeFile(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load: error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("Load succeeded with a missing explicit config file")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	secretPath := writeFile(t, "db_password", "s3cret\n")
+
+	tests := []struct {
+		name    string
+		environ []string
+		check   func(t *testing.T, cfg *Config)
+		wantErr string
+	}{
+		{
+			name:    "scalar types",
+			environ: []string{"CRYPTOCROWD_DATABASE_MAX_CONNS=42", "CRYPTOCROWD_LOGGER_DEV_MODE=true", "CRYPTOCROWD_DATABASE_HOST=db"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Database.MaxConns != 42 || !cfg.Logger.DevMode || cfg.Database.Host != "db" {
+					t.Fatalf("max_conns = %d, dev_mode = %v, host = %q", cfg.Database.MaxConns, cfg.Logger.DevMode, cfg.Database.Host)
+				}
+			},
+		},
+		{
+			name:    "nested rule",
+			environ: []string{"CRYPTOCROWD_RATE_LIMIT_IP_REQUESTS=5"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.RateLimit.IP.Requests != 5 {
+					t.Fatalf("rate_limit.ip.requests = %d, want 5", cfg.RateLimit.IP.Requests)
+				}
+			},
+		},
+		{
+			name:    "decimal",
+			environ: []string{"CRYPTOCROWD_COMPLIANCE_ANNUAL_CAP=10000.50"},
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Compliance.AnnualCap.Equal(decimal.RequireFromString("10000.50")) {
+					t.Fatalf("compliance.annual_cap = %s, want 10000.50", cfg.Compliance.AnnualCap)
+				}
+			},
+		},
+		{
+			name:    "comma separated list",
+			environ: []string{"CRYPTOCROWD_SERVER_TRUSTED_PROXIES=10.0.0.1, 10.0.1.0/24,,"},
+			check: func(t *testing.T, cfg *Config) {
+				if want := []string{"10.0.0.1", "10.0.1.0/24"}; !slices.Equal(cfg.Server.TrustedProxies, want) {
+					t.Fatalf("trusted_proxies = %v, want %v", cfg.Server.TrustedProxies, want)
+				}
+			},
+		},
+		{
+			name:    "JSON list of structs",
+			environ: []string{`CRYPTOCROWD_LOGGER_SINKS=[{"type": "stderr", "level": "warn"}]`},
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Logger.Sinks) != 1 || cfg.Logger.Sinks[0].Type != "stderr" || cfg.Logger.Sinks[0].Level != "warn" {
+					t.Fatalf("logger.sinks = %+v", cfg.Logger.Sinks)
+				}
+			},
+		},
+		{
+			name:    "secret file without the trailing newline",
+			environ: []string{"CRYPTOCROWD_DATABASE_PASSWORD_FILE=" + secretPath},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Database.Password != "s3cret" {
+					t.Fatalf("database.password = %q, want s3cret", cfg.Database.Password)
+				}
+			},
+		},
+		{
+			name:    "variables of other programs, tests and the config path are ignored",
+			environ: []string{"HOME=/root", "CRYPTOCROWDX=1", "TEST_DATABASE_URL=postgres://localhost/test", "CRYPTOCROWD_CONFIG=config.yaml"},
+			check:   func(*testing.T, *Config) {},
+		},
+		{name: "value and secret file", environ: []string{"CRYPTOCROWD_DATABASE_PASSWORD=a", "CRYPTOCROWD_DATABASE_PASSWORD_FILE=" + secretPath}, wantErr: "одновременно"},
+		{name: "missing secret file", environ: []string{"CRYPTOCROWD_DATABASE_PASSWORD_FILE=" + secretPath + ".missing"}, wantErr: "файла секрета"},
+		{name: "unknown variable", environ: []string{"CRYPTOCROWD_DATABASE_HOTS=db"}, wantErr: "CRYPTOCROWD_DATABASE_HOTS"},
+		{name: "not an integer", environ: []string{"CRYPTOCROWD_DATABASE_MAX_CONNS=many"}, wantErr: "целое число"},
+		{name: "not a boolean", environ: []string{"CRYPTOCROWD_LOGGER_DEV_MODE=yes please"}, wantErr: "true или false"},
+		{name: "not a decimal", environ: []string{"CRYPTOCROWD_COMPLIANCE_ANNUAL_CAP=lots"}, wantErr: "CRYPTOCROWD_COMPLIANCE_ANNUAL_CAP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := applyEnv(&cfg, tt.environ)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyEnv: error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyEnv: %v", err)
+			}
+			tt.check(t, &cfg)
+		})
+	}
+}
+
+func TestApplyEnvReportsAllErrors(t *testing.T) {
+	var cfg Config
+	err := applyEnv(&cfg, []string{"CRYPTOCROWD_DATABASE_MAX_CONNS=many", "CRYPTOCROWD_UNKNOWN=1"})
+	if err == nil || !strings.Contains(err.Error(), "CRYPTOCROWD_DATABASE_MAX_CONNS") || !strings.Contains(err.Error(), "CRYPTOCROWD_UNKNOWN") {
+		t.Fatalf("applyEnv: error = %v, want both problems reported", err)
+	}
+}
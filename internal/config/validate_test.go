@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatorTokenSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr string
+	}{
+		{name: "random", secret: "3f9c1a7be2d84c06a5f1e9b07d2c48aa"},
+		{name: "empty", secret: "", wantErr: "ключ обязателен"},
+		{name: "short", secret: "0123456789abcdef", wantErr: "не короче"},
+		{name: "old default", secret: "dev-only-token-secret-change-me-in-production", wantErr: "значение из примера"},
+		{name: "placeholder in upper case", secret: "PLACEHOLDER-PLACEHOLDER-PLACEHOLDER", wantErr: "значение из примера"},
+		{name: "one repeated character", secret: strings.Repeat("a", 40), wantErr: "значение из примера"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{}
+			v.tokenSecret("auth.token_secret", tt.secret)
+
+			err := v.err()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsSecretsInFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantEnv string
+	}{
+		{name: "token secret in JSON", file: "config.json", content: `{"auth": {"token_secret": "3f9c1a7be2d84c06a5f1e9b07d2c48aa"}}`, wantEnv: "CRYPTOCROWD_AUTH_TOKEN_SECRET"},
+		{name: "token secret in YAML", file: "config.yaml", content: "auth:\n  token_secret: 3f9c1a7be2d84c06a5f1e9b07d2c48aa\n", wantEnv: "CRYPTOCROWD_AUTH_TOKEN_SECRET"},
+		{name: "database password", file: "config.json", content: `{"database": {"password": "postgres"}}`, wantEnv: "CRYPTOCROWD_DATABASE_PASSWORD"},
+		{name: "mailer password", file: "config.yaml", content: "mailer:\n  password: smtp\n", wantEnv: "CRYPTOCROWD_MAILER_PASSWORD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantEnv) {
+				t.Fatalf("error = %v, want a hint to use %s", err, tt.wantEnv)
+			}
+		})
+	}
+}
+
+func TestLoadTokenSecretFromSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "token_secret")
+	if err := os.WriteFile(secretPath, []byte("3f9c1a7be2d84c06a5f1e9b07d2c48aa\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"auth": {"token_ttl": 30}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CRYPTOCROWD_AUTH_TOKEN_SECRET_FILE", secretPath)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Auth.TokenSecret != "3f9c1a7be2d84c06a5f1e9b07d2c48aa" {
+		t.Fatalf("TokenSecret = %q, want the content of the secret file", cfg.Auth.TokenSecret)
+	}
+	if cfg.Auth.TokenTTL != 30 {
+		t.Fatalf("TokenTTL = %d, want 30 from the file", cfg.Auth.TokenTTL)
+	}
+}
+
+func TestValidateIdempotencyLockTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		lockTimeout int
+		wantErr     bool
+	}{
+		{name: "default", lockTimeout: 0},
+		{name: "shorter than the write timeout", lockTimeout: 10, wantErr: true},
+		{name: "longer than the key TTL", lockTimeout: 2 * 3600, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			content := fmt.Sprintf(`{"server": {"write_timeout": 30}, "idempotency": {"key_ttl": 1, "lock_timeout": %d}}`, tt.lockTimeout)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			err = cfg.Validate()
+			if got := err != nil && strings.Contains(err.Error(), "idempotency.lock_timeout"); got != tt.wantErr {
+				t.Fatalf("Validate: error = %v, want a lock_timeout error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
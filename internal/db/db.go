@@ -2,25 +2,67 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"strconv"
+	"sync/atomic"
 	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// replicaRetryDelay - how long reads skip a replica that failed before trying it again
+const replicaRetryDelay = 30 * time.Second
+
 type Pool struct {
 	*pgxpool.Pool
+
+	replica *replica
+}
+
+// PoolConfig holds the connection pool settings. Zero values keep the pgxpool defaults
+type PoolConfig struct {
+	MaxConns         int32
+	MinConns         int32
+	MaxConnLifetime  time.Duration
+	MaxConnIdleTime  time.Duration
+	StatementTimeout time.Duration
 }
 
-func NewPool(ctx context.Context, connString string) (*Pool, error) {
+func NewPool(ctx context.Context, connString string, cfg PoolConfig) (*Pool, error) {
+	pool, err := newPgxPool(ctx, connString, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pool{Pool: pool}, nil
+}
+
+func newPgxPool(ctx context.Context, connString string, cfg PoolConfig) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
 
-	config.MaxConns = 100
-	config.MinConns = 10
-	config.MaxConnLifetime = 1 * time.Minute
-	config.MaxConnIdleTime = 30 * time.Second
+	if cfg.MaxConns > 0 {
+		config.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		config.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	config.ConnConfig.Tracer = queryTracer{}
+	if cfg.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -32,7 +74,58 @@ func NewPool(ctx context.Context, connString string) (*Pool, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &Pool{pool}, nil
+	return pool, nil
+}
+
+// ConnectReplica attaches a read replica used by Reader. If the replica cannot be reached
+// the pool keeps serving reads from the primary
+func (p *Pool) ConnectReplica(ctx context.Context, connString string, cfg PoolConfig) error {
+	pool, err := newPgxPool(ctx, connString, cfg)
+	if err != nil {
+		return err
+	}
+
+	p.replica = &replica{pool: pool}
+	return nil
+}
+
+type staleReadsKey struct{}
+
+// WithStaleReads marks reads made with ctx as tolerant of replication lag. Only read-only
+// endpoints such as listings should set it: data that feeds checks before a write must come from the primary
+func WithStaleReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleReadsKey{}, true)
+}
+
+// Reader returns the querier for a read made with ctx. Reads marked with WithStaleReads go to the
+// replica if one is connected and fall back to the primary when the replica is unavailable.
+// All other reads go to the primary, and reads inside a transaction of InTx go to that transaction
+func (p *Pool) Reader(ctx context.Context) pgxscan.Querier {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx
+	}
+	if p.replica == nil || ctx.Value(staleReadsKey{}) == nil {
+		return p.Pool
+	}
+
+	return &replicaReader{primary: p.Pool, replica: p.replica}
+}
+
+// Stats returns the statistics of the primary pool and, if connected, the replica pool
+func (p *Pool) Stats() map[string]*pgxpool.Stat {
+	stats := map[string]*pgxpool.Stat{"primary": p.Stat()}
+	if p.replica != nil {
+		stats["replica"] = p.replica.pool.Stat()
+	}
+	return stats
+}
+
+// Close closes the primary and the replica pools
+func (p *Pool) Close() {
+	if p.replica != nil {
+		p.replica.pool.Close()
+	}
+	p.Pool.Close()
 }
 
 func ClosePool(pool *Pool) {
@@ -40,3 +133,39 @@ func ClosePool(pool *Pool) {
 		pool.Close()
 	}
 }
+
+type replica struct {
+	pool *pgxpool.Pool
+	// downUntil holds the UnixNano time until which reads bypass the replica after a failure
+	downUntil atomic.Int64
+}
+
+type replicaReader struct {
+	primary *pgxpool.Pool
+	replica *replica
+}
+
+func (r *replicaReader) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if time.Now().UnixNano() < r.replica.downUntil.Load() {
+		return r.primary.Query(ctx, sql, args...)
+	}
+
+	rows, err := r.replica.pool.Query(ctx, sql, args...)
+	if err == nil || !isConnectionError(ctx, err) {
+		return rows, err
+	}
+
+	r.replica.downUntil.Store(time.Now().Add(replicaRetryDelay).UnixNano())
+	return r.primary.Query(ctx, sql, args...)
+}
+
+// isConnectionError reports whether the query failed because the server could not be reached,
+// as opposed to an error returned by the server or a cancelled request
+func isConnectionError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	return !errors.As(err, &pgErr)
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer creates an OpenTelemetry span for every query. Arguments are not recorded,
+// they may contain personal data and secrets
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		// Queries outside of a traced request or job would only produce single-span traces
+		return ctx
+	}
+
+	config := conn.Config()
+	ctx, _ = otel.Tracer("github.com/CryptoCrowd/internal/db").Start(ctx, querySpanName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.namespace", config.Database),
+			attribute.String("db.query.text", data.SQL),
+			attribute.String("server.address", config.Host),
+		),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int64("db.response.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+// querySpanName names the span by the SQL command, e.g. "SELECT" or "UPDATE"
+func querySpanName(sql string) string {
+	command, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	command = strings.ToUpper(strings.TrimSpace(command))
+	if command == "" {
+		return "query"
+	}
+	return command
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type txKey struct{}
+
+// InTx runs fn in a transaction on the primary and commits it if fn returns nil. Queries made
+// through the pool with the context passed to fn join the transaction, and transactions they
+// begin become savepoints in it, so repository calls can be combined into one atomic change.
+// Inside a transaction InTx simply runs fn in it
+func (p *Pool) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func txFromContext(ctx context.Context) pgx.Tx {
+	tx, _ := ctx.Value(txKey{}).(pgx.Tx)
+	return tx
+}
+
+// Begin starts a transaction, or a savepoint if ctx carries a transaction of InTx
+func (p *Pool) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.Begin(ctx)
+	}
+	return p.Pool.Begin(ctx)
+}
+
+// BeginTx starts a transaction with txOptions. Inside a transaction of InTx it starts a savepoint,
+// which keeps the isolation level and access mode of the outer transaction
+func (p *Pool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.Begin(ctx)
+	}
+	return p.Pool.BeginTx(ctx, txOptions)
+}
+
+func (p *Pool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.Exec(ctx, sql, args...)
+	}
+	return p.Pool.Exec(ctx, sql, args...)
+}
+
+func (p *Pool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.Query(ctx, sql, args...)
+	}
+	return p.Pool.Query(ctx, sql, args...)
+}
+
+func (p *Pool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.QueryRow(ctx, sql, args...)
+	}
+	return p.Pool.QueryRow(ctx, sql, args...)
+}
+
+func (p *Pool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.SendBatch(ctx, b)
+	}
+	return p.Pool.SendBatch(ctx, b)
+}
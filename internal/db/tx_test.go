@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var errFakeTx = errors.New("fake transaction")
+
+// fakeTx records the calls routed to it and fails each of them with errFakeTx
+type fakeTx struct {
+	pgx.Tx
+
+	calls []string
+}
+
+func (f *fakeTx) Begin(context.Context) (pgx.Tx, error) {
+	f.calls = append(f.calls, "Begin")
+	return nil, errFakeTx
+}
+
+func (f *fakeTx) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	f.calls = append(f.calls, "Exec")
+	return pgconn.CommandTag{}, errFakeTx
+}
+
+func (f *fakeTx) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	f.calls = append(f.calls, "Query")
+	return nil, errFakeTx
+}
+
+func TestPoolJoinsContextTransaction(t *testing.T) {
+	pool := &Pool{Pool: unreachablePool(t), replica: &replica{pool: unreachablePool(t)}}
+	tx := &fakeTx{}
+	ctx := context.WithValue(WithStaleReads(context.Background()), txKey{}, pgx.Tx(tx))
+
+	calls := []struct {
+		name string
+		call func() error
+	}{
+		{name: "Begin", call: func() error { _, err := pool.Begin(ctx); return err }},
+		{name: "BeginTx", call: func() error { _, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}); return err }},
+		{name: "Exec", call: func() error { _, err := pool.Exec(ctx, "SELECT 1"); return err }},
+		{name: "Query", call: func() error { _, err := pool.Query(ctx, "SELECT 1"); return err }},
+		{name: "Reader", call: func() error { _, err := pool.Reader(ctx).Query(ctx, "SELECT 1"); return err }},
+	}
+
+	for _, c := range calls {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.call(); !errors.Is(err, errFakeTx) {
+				t.Fatalf("%s did not go to the context transaction: error = %v", c.name, err)
+			}
+		})
+	}
+
+	// Nested InTx runs in the transaction it is given instead of starting another one
+	var nested context.Context
+	err := pool.InTx(ctx, func(ctx context.Context) error {
+		nested = ctx
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("nested InTx: %v", err)
+	}
+	if txFromContext(nested) != pgx.Tx(tx) {
+		t.Fatal("nested InTx started a new transaction")
+	}
+	if want := []string{"Begin", "Begin", "Exec", "Query", "Query"}; !slices.Equal(tx.calls, want) {
+		t.Fatalf("calls = %v, want %v", tx.calls, want)
+	}
+}
@@ -2,17 +2,25 @@ package handler
 
 import (
 	"context"
+	"errors"
+
 	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/service"
 	"github.com/gofiber/fiber/v2"
 )
 
 type AccountServiceInterface interface {
 	Create(ctx context.Context, acc model.Account, plainPassword string) error
-	Update(ctx context.Context, acc model.Account) error
-	UpdatePassword(ctx context.Context, email string, newPassword string) error
-	Delete(ctx context.Context, email string) error
-	GetByEmail(ctx context.Context, email string) (model.Account, error)
+	Update(ctx context.Context, userID int64, username string, version int64) (int64, error)
+	UpdatePassword(ctx context.Context, userID int64, currentPassword string, newPassword string) error
+	SetCountry(ctx context.Context, userID int64, country string, version int64) (int64, error)
+	SetEmail(ctx context.Context, userID int64, email string, version int64) (int64, error)
+	Delete(ctx context.Context, email string, userID int64, version int64) error
+	GetByID(ctx context.Context, id int64) (model.Account, error)
 	List(ctx context.Context, searchTerm string) ([]model.Account, error)
+	ListDeleted(ctx context.Context) ([]model.Account, error)
+	Restore(ctx context.Context, id int64) (model.Account, error)
 }
 
 // AccountHandler handles HTTP requests related to accounts
@@ -27,32 +35,248 @@ func NewAccountHandler(accountService AccountServiceInterface) *AccountHandler {
 	}
 }
 
+type createAccountRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Password string `json:"password"`
+}
+
 // Create handles the creation of a new account
 func (h *AccountHandler) Create(c *fiber.Ctx) error {
-	return nil
+	var req createAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	acc := model.Account{
+		Username: req.Username,
+		Email:    req.Email,
+		Role:     req.Role,
+	}
+
+	err := h.accountService.Create(c.UserContext(), acc, req.Password)
+	switch {
+	case errors.Is(err, service.ErrInvalidUsername),
+		errors.Is(err, service.ErrInvalidEmail),
+		errors.Is(err, service.ErrInvalidRole),
+		errors.Is(err, service.ErrEmptyPass),
+		errors.Is(err, service.ErrWeakPassword):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, repository.ErrUserAlreadyExists):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusCreated)
 }
 
-// Update handles the update of an existing account
+type updateAccountRequest struct {
+	Username string `json:"username"`
+}
+
+// Update handles the update of the current user's account.
+// The version to change comes from If-Match
 func (h *AccountHandler) Update(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req updateAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	version, err := h.accountService.Update(c.UserContext(), userID, req.Username, ifMatchVersion(c))
+	switch {
+	case errors.Is(err, service.ErrInvalidUsername):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, repository.ErrUserNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(fiber.HeaderETag, ETag(version))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+type updatePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
 }
 
-// UpdatePassword handles the update of an account's password
+// UpdatePassword handles the update of the current user's password
 func (h *AccountHandler) UpdatePassword(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req updatePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	err := h.accountService.UpdatePassword(c.UserContext(), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// Delete handles the deletion of an account
-func (h *AccountHandler) Delete(c *fiber.Ctx) error {
-	return nil
+type setCountryRequest struct {
+	Country string `json:"country"`
 }
 
-// GetByEmail handles the retrieval of an account by email
-func (h *AccountHandler) GetByEmail(c *fiber.Ctx) error {
-	return nil
+// GetCurrent handles the retrieval of the current user's account
+func (h *AccountHandler) GetCurrent(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	acc, err := h.accountService.GetByID(c.UserContext(), userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return sendVersioned(c, acc.Version, acc)
 }
 
-// List handles the listing of accounts
+// SetCountry handles the update of the current user's country of residence.
+// The version to change comes from If-Match
+func (h *AccountHandler) SetCountry(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req setCountryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	version, err := h.accountService.SetCountry(c.UserContext(), userID, req.Country, ifMatchVersion(c))
+	switch {
+	case errors.Is(err, service.ErrInvalidCountry):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, service.ErrCountryLocked):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(fiber.HeaderETag, ETag(version))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+type setEmailRequest struct {
+	Email string `json:"email"`
+}
+
+// SetEmail handles the change of the current user's email. A verification link is mailed to the new
+// address. The version to change comes from If-Match
+func (h *AccountHandler) SetEmail(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req setEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	version, err := h.accountService.SetEmail(c.UserContext(), userID, req.Email, ifMatchVersion(c))
+	switch {
+	case errors.Is(err, service.ErrInvalidEmail):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, repository.ErrUserAlreadyExists):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(fiber.HeaderETag, ETag(version))
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// Delete handles the deletion of the current user's account.
+// The version to delete comes from If-Match
+func (h *AccountHandler) Delete(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	err := h.accountService.Delete(c.UserContext(), c.Params("email"), userID, ifMatchVersion(c))
+	switch {
+	case errors.Is(err, service.ErrAccountAccessDenied):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, repository.ErrUserNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// List handles the search of accounts by username or email for administrators
 func (h *AccountHandler) List(c *fiber.Ctx) error {
-	return nil
+	accounts, err := h.accountService.List(c.UserContext(), c.Query("search"))
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if accounts == nil {
+		accounts = []model.Account{}
+	}
+
+	return c.JSON(accounts)
+}
+
+// ListDeleted handles the listing of accounts marked as deleted
+func (h *AccountHandler) ListDeleted(c *fiber.Ctx) error {
+	accounts, err := h.accountService.ListDeleted(c.UserContext())
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if accounts == nil {
+		accounts = []model.Account{}
+	}
+
+	return c.JSON(accounts)
+}
+
+// Restore handles the restoration of an account marked as deleted
+func (h *AccountHandler) Restore(c *fiber.Ctx) error {
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	acc, err := h.accountService.Restore(c.UserContext(), id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(acc)
 }
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHandler handles HTTP requests related to personal API keys of the current user
+type APIKeyHandler struct {
+	apiKeyService *service.APIKey
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService *service.APIKey) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createAPIKeyResponse struct {
+	model.APIKey
+	// Key is shown only in this response and cannot be retrieved later
+	Key string `json:"key"`
+}
+
+// Create handles minting of a new API key
+func (h *APIKeyHandler) Create(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req createAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	key, apiKey, err := h.apiKeyService.Create(c.UserContext(), userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAPIKeyName),
+			errors.Is(err, service.ErrInvalidAPIKeyScope),
+			errors.Is(err, service.ErrInvalidAPIKeyExpiry):
+			return errorResponse(c, fiber.StatusBadRequest, err)
+		case errors.Is(err, service.ErrTooManyAPIKeys):
+			return errorResponse(c, fiber.StatusConflict, err)
+		default:
+			return errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(createAPIKeyResponse{APIKey: apiKey, Key: key})
+}
+
+// List handles the listing of the current user's API keys
+func (h *APIKeyHandler) List(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	keys, err := h.apiKeyService.List(c.UserContext(), userID)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+
+	return c.JSON(keys)
+}
+
+// Revoke handles revocation of one of the current user's API keys
+func (h *APIKeyHandler) Revoke(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	err = h.apiKeyService.Revoke(c.UserContext(), userID, id)
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler handles HTTP requests of administrators to the audit log
+type AuditHandler struct {
+	auditService *service.Audit
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService *service.Audit) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// List handles querying of audit events. Supported filters: actor_id, action, target_type,
+// target_id, from and to (RFC 3339), before_id for paging and limit
+func (h *AuditHandler) List(c *fiber.Ctx) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	events, err := h.auditService.List(c.UserContext(), filter)
+	if errors.Is(err, service.ErrInvalidAuditFilter) {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if events == nil {
+		events = []model.AuditEvent{}
+	}
+
+	return c.JSON(events)
+}
+
+// Verify handles a check of the audit log hash chain
+func (h *AuditHandler) Verify(c *fiber.Ctx) error {
+	result, err := h.auditService.Verify(c.UserContext())
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(result)
+}
+
+func parseAuditFilter(c *fiber.Ctx) (model.AuditFilter, error) {
+	filter := model.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+	}
+
+	var err error
+	if filter.ActorID, err = queryInt64(c, "actor_id"); err != nil {
+		return model.AuditFilter{}, err
+	}
+	if filter.TargetID, err = queryInt64(c, "target_id"); err != nil {
+		return model.AuditFilter{}, err
+	}
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		return model.AuditFilter{}, err
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		return model.AuditFilter{}, err
+	}
+	if beforeID, err := queryInt64(c, "before_id"); err != nil {
+		return model.AuditFilter{}, err
+	} else if beforeID != nil {
+		filter.BeforeID = *beforeID
+	}
+	if filter.Limit, err = strconv.Atoi(c.Query("limit", "0")); err != nil {
+		return model.AuditFilter{}, fmt.Errorf("invalid limit: %w", err)
+	}
+
+	return filter, nil
+}
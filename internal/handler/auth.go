@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthHandler handles HTTP requests of the authentication flows
+type AuthHandler struct {
+	authService *service.Auth
+}
+
+// NewAuthHandler creates a new authentication handler
+func NewAuthHandler(authService *service.Auth) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+type loginRequest struct {
+	Email        string `json:"email"`
+	Password     string `json:"password"`
+	Role         string `json:"role"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+	DeviceName   string `json:"device_name"`
+}
+
+type stepUpRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Login handles password login with an optional second factor
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	var req loginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if req.Role == "" {
+		req.Role = "investor"
+	}
+
+	client := model.ClientInfo{
+		Device:    req.DeviceName,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+
+	token, claims, err := h.authService.Login(c.UserContext(), req.Email, req.Role, req.Password, req.Code, req.RecoveryCode, client)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.JSON(tokenResponse{AccessToken: token, TokenType: "Bearer", ExpiresAt: claims.ExpiresAt})
+}
+
+// StepUp handles re-confirmation of the second factor before sensitive actions
+func (h *AuthHandler) StepUp(c *fiber.Ctx) error {
+	current, ok := currentClaims(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req stepUpRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	token, claims, err := h.authService.StepUp(c.UserContext(), current, req.Code, req.RecoveryCode)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.JSON(tokenResponse{AccessToken: token, TokenType: "Bearer", ExpiresAt: claims.ExpiresAt})
+}
+
+// VerifyEmail handles confirmation of an account's email address
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req verifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	err := h.authService.VerifyEmail(c.UserContext(), req.Token)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ForgotPassword handles a request for a password reset link
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req forgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if req.Role == "" {
+		req.Role = "investor"
+	}
+
+	if err := h.authService.ForgotPassword(c.UserContext(), req.Email, req.Role); err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	// The same response is returned for unknown accounts to avoid leaking registered emails
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// ResetPassword handles setting a new password with a reset token
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req resetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	err := h.authService.ResetPassword(c.UserContext(), req.Token, req.Password)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func authErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials),
+		errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrSessionRevoked):
+		return errorResponse(c, fiber.StatusUnauthorized, err)
+	case errors.Is(err, service.ErrTwoFactorRequired):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error(), "two_factor_required": true})
+	case errors.Is(err, service.ErrStepUpRequired):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error(), "step_up_required": true})
+	case errors.Is(err, service.ErrTwoFactorNotEnabled),
+		errors.Is(err, service.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, service.ErrTwoFactorMandatory):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case errors.Is(err, service.ErrInvalidToken),
+		errors.Is(err, service.ErrEmptyPass),
+		errors.Is(err, service.ErrWeakPassword):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	default:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthHandler handles liveness and readiness probes
+type HealthHandler struct {
+	healthService *service.Health
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(healthService *service.Health) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Liveness reports that the process is running and serving requests. It does not check
+// dependencies, so that a database outage does not make the orchestrator restart the instance
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": service.HealthStatusUp})
+}
+
+// Readiness reports whether the instance can serve traffic, with a breakdown per dependency
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	result := h.healthService.Readiness(c.UserContext())
+	if !result.Ready {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(result)
+	}
+
+	return c.JSON(result)
+}
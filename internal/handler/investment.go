@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"errors"
+
+	"github.com/CryptoCrowd/internal/compliance"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
 	"github.com/CryptoCrowd/internal/service"
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
 )
 
 // InvestmentHandler handles HTTP requests related to investments
@@ -19,30 +25,338 @@ func NewInvestmentHandler(investmentService *service.Investment) *InvestmentHand
 
 // Create handles the creation of a new investment
 func (h *InvestmentHandler) Create(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var investment model.Investment
+	if err := c.BodyParser(&investment); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	investment.UserID = userID
+
+	err := h.investmentService.Create(c.UserContext(), investment)
+	var rulesErr *service.InvestmentRulesError
+	switch {
+	case errors.As(err, &rulesErr):
+		return rulesViolationResponse(c, rulesErr)
+	case errors.Is(err, service.ErrInvalidInvestmentUser),
+		errors.Is(err, service.ErrInvalidInvestmentProject),
+		errors.Is(err, service.ErrInvalidInvestmentAmount):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrEmailNotVerified):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, service.ErrKYCRequired):
+		return kycRequiredResponse(c, err)
+	case errors.Is(err, service.ErrSanctionsReview):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, service.ErrStepUpRequired):
+		return authErrorResponse(c, err)
+	case errors.Is(err, service.ErrProjectNotOpen):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+type checkInvestmentResponse struct {
+	Allowed    bool                   `json:"allowed"`
+	Violations []compliance.Violation `json:"violations"`
+}
+
+// Check handles a dry run of an investment against the compliance rules of the project
+func (h *InvestmentHandler) Check(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var investment model.Investment
+	if err := c.BodyParser(&investment); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	investment.UserID = userID
+
+	violations, err := h.investmentService.Check(c.UserContext(), investment)
+	switch {
+	case errors.Is(err, service.ErrInvalidInvestmentUser),
+		errors.Is(err, service.ErrInvalidInvestmentProject),
+		errors.Is(err, service.ErrInvalidInvestmentAmount):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrKYCRequired):
+		return kycRequiredResponse(c, err)
+	case errors.Is(err, service.ErrSanctionsReview):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, service.ErrProjectNotOpen):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if violations == nil {
+		violations = []compliance.Violation{}
+	}
+
+	return c.JSON(checkInvestmentResponse{Allowed: len(violations) == 0, Violations: violations})
+}
+
+// GetProjectRules handles retrieval of the investment rules of a project
+func (h *InvestmentHandler) GetProjectRules(c *fiber.Ctx) error {
+	projectID, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	rules, err := h.investmentService.GetProjectRules(c.UserContext(), projectID)
+	if errors.Is(err, repository.ErrProjectNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(rules)
 }
 
-// Update handles the update of an existing investment
+// SetProjectRules handles replacement of the investment rules of a project
+func (h *InvestmentHandler) SetProjectRules(c *fiber.Ctx) error {
+	projectID, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	var rules model.InvestmentRules
+	if err = c.BodyParser(&rules); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	updated, err := h.investmentService.SetProjectRules(c.UserContext(), projectID, rules)
+	switch {
+	case errors.Is(err, service.ErrInvalidInvestmentRules):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(updated)
+}
+
+// rulesViolationResponse reports the compliance rules an investment violates
+func rulesViolationResponse(c *fiber.Ctx, err *service.InvestmentRulesError) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":      service.ErrInvestmentRulesViolated.Error(),
+		"violations": err.Violations,
+	})
+}
+
+type updateInvestmentRequest struct {
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// Update handles the change of the amount of an investment of the current user.
+// The version to change comes from If-Match
 func (h *InvestmentHandler) Update(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	var req updateInvestmentRequest
+	if err = c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	version, err := h.investmentService.Update(c.UserContext(), id, userID, req.Amount, ifMatchVersion(c))
+	var rulesErr *service.InvestmentRulesError
+	switch {
+	case errors.As(err, &rulesErr):
+		return rulesViolationResponse(c, rulesErr)
+	case errors.Is(err, service.ErrInvalidInvestmentAmount):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, service.ErrInvestmentAccessDenied),
+		errors.Is(err, service.ErrEmailNotVerified),
+		errors.Is(err, service.ErrSanctionsReview):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, repository.ErrInvestmentNotFound),
+		errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrKYCRequired):
+		return kycRequiredResponse(c, err)
+	case errors.Is(err, service.ErrStepUpRequired):
+		return authErrorResponse(c, err)
+	case errors.Is(err, service.ErrProjectNotOpen):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(fiber.HeaderETag, ETag(version))
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// Delete handles the deletion of an investment
+// Delete handles the cancellation of an investment of the current user.
+// The version to delete comes from If-Match
 func (h *InvestmentHandler) Delete(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	err = h.investmentService.Delete(c.UserContext(), id, userID, ifMatchVersion(c))
+	switch {
+	case errors.Is(err, service.ErrInvestmentAccessDenied):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, repository.ErrInvestmentNotFound),
+		errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrProjectNotOpen):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// GetByID handles the retrieval of an investment by ID
+// GetByID handles the retrieval of an investment of the current user by ID
 func (h *InvestmentHandler) GetByID(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	investment, err := h.investmentService.GetByID(c.UserContext(), id, userID)
+	switch {
+	case errors.Is(err, service.ErrInvestmentAccessDenied):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, repository.ErrInvestmentNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return sendVersioned(c, investment.Version, investment)
 }
 
 // GetByUserID handles the retrieval of investments by user ID
 func (h *InvestmentHandler) GetByUserID(c *fiber.Ctx) error {
-	return nil
+	requestingUserID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	userID, err := paramInt64(c, "user_id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	investments, err := h.investmentService.GetByUserID(c.UserContext(), userID, requestingUserID)
+	if errors.Is(err, service.ErrInvestmentAccessDenied) {
+		return errorResponse(c, fiber.StatusForbidden, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if investments == nil {
+		investments = []model.Investment{}
+	}
+
+	return c.JSON(investments)
 }
 
-// GetByProjectID handles the retrieval of investments by project ID
+// GetByProjectID handles the retrieval of investments in a project by its owner or an administrator
 func (h *InvestmentHandler) GetByProjectID(c *fiber.Ctx) error {
-	return nil
+	requestingUserID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	projectID, err := paramInt64(c, "project_id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	investments, err := h.investmentService.GetByProjectID(c.UserContext(), projectID, requestingUserID)
+	switch {
+	case errors.Is(err, service.ErrInvestmentAccessDenied):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if investments == nil {
+		investments = []model.Investment{}
+	}
+
+	return c.JSON(investments)
+}
+
+// ListDeleted handles the listing of investments marked as deleted
+func (h *InvestmentHandler) ListDeleted(c *fiber.Ctx) error {
+	investments, err := h.investmentService.ListDeleted(c.UserContext())
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if investments == nil {
+		investments = []model.Investment{}
+	}
+
+	return c.JSON(investments)
+}
+
+// Restore handles the restoration of an investment marked as deleted
+func (h *InvestmentHandler) Restore(c *fiber.Ctx) error {
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	investment, err := h.investmentService.Restore(c.UserContext(), id)
+	var rulesErr *service.InvestmentRulesError
+	switch {
+	case errors.As(err, &rulesErr):
+		return rulesViolationResponse(c, rulesErr)
+	case errors.Is(err, repository.ErrInvestmentNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, repository.ErrProjectNotFound),
+		errors.Is(err, repository.ErrUserNotFound),
+		errors.Is(err, service.ErrProjectNotOpen):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case errors.Is(err, service.ErrKYCRequired):
+		return kycRequiredResponse(c, err)
+	case errors.Is(err, service.ErrSanctionsReview):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(investment)
 }
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// KYCHandler handles HTTP requests of identity verification
+type KYCHandler struct {
+	kycService *service.KYC
+}
+
+// NewKYCHandler creates a new KYC handler
+func NewKYCHandler(kycService *service.KYC) *KYCHandler {
+	return &KYCHandler{
+		kycService: kycService,
+	}
+}
+
+type reviewKYCRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// Overview handles retrieval of the current user's verification state
+func (h *KYCHandler) Overview(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	overview, err := h.kycService.Overview(c.UserContext(), userID)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if overview.Documents == nil {
+		overview.Documents = []model.KYCDocument{}
+	}
+
+	return c.JSON(overview)
+}
+
+// UploadDocument handles a multipart upload of a document with fields kind and file
+func (h *KYCHandler) UploadDocument(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	defer file.Close()
+
+	doc, err := h.kycService.UploadDocument(c.UserContext(), userID, c.FormValue("kind"), header.Filename, file)
+	if err != nil {
+		return kycErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(doc)
+}
+
+// Submit handles submission of the uploaded documents for verification
+func (h *KYCHandler) Submit(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	app, err := h.kycService.Submit(c.UserContext(), userID)
+	if err != nil {
+		return kycErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(app)
+}
+
+// ListApplications handles listing of applications by status, pending by default
+func (h *KYCHandler) ListApplications(c *fiber.Ctx) error {
+	apps, err := h.kycService.ListApplications(c.UserContext(), c.Query("status"))
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if apps == nil {
+		apps = []model.KYCApplication{}
+	}
+
+	return c.JSON(apps)
+}
+
+// GetApplication handles retrieval of an application with its documents
+func (h *KYCHandler) GetApplication(c *fiber.Ctx) error {
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	app, err := h.kycService.GetApplication(c.UserContext(), id)
+	if err != nil {
+		return kycErrorResponse(c, err)
+	}
+
+	return c.JSON(app)
+}
+
+// DownloadDocument handles download of a document file for manual review
+func (h *KYCHandler) DownloadDocument(c *fiber.Ctx) error {
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	doc, file, err := h.kycService.OpenDocument(c.UserContext(), id)
+	if err != nil {
+		return kycErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, doc.ContentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": doc.FileName}))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	// Fiber closes the file after the response body is written
+	return c.SendStream(file, int(doc.Size))
+}
+
+// Review handles an administrator's decision on an application
+func (h *KYCHandler) Review(c *fiber.Ctx) error {
+	reviewerID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	var req reviewKYCRequest
+	if err = c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	app, err := h.kycService.Review(c.UserContext(), reviewerID, id, req.Status, req.Reason)
+	if err != nil {
+		return kycErrorResponse(c, err)
+	}
+
+	return c.JSON(app)
+}
+
+// kycRequiredResponse reports that the action needs a verified identity
+func kycRequiredResponse(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error(), "kyc_required": true})
+}
+
+func kycErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidDocumentKind),
+		errors.Is(err, service.ErrUnsupportedDocumentType),
+		errors.Is(err, service.ErrKYCNoDocuments),
+		errors.Is(err, service.ErrInvalidKYCDecision):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, service.ErrDocumentTooLarge):
+		return errorResponse(c, fiber.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, service.ErrKYCApplicationNotFound),
+		errors.Is(err, service.ErrKYCDocumentNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrKYCAlreadySubmitted),
+		errors.Is(err, service.ErrKYCApplicationNotPending):
+		return errorResponse(c, fiber.StatusConflict, err)
+	default:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+}
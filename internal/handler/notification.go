@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationHandler handles HTTP requests related to the notification center
+type NotificationHandler struct {
+	notificationService *service.Notification
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *service.Notification) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+type markReadRequest struct {
+	IDs []int64 `json:"ids"`
+	All bool    `json:"all"`
+}
+
+// List handles the listing of the current user's notifications
+func (h *NotificationHandler) List(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	notifications, err := h.notificationService.List(c.UserContext(), userID, c.QueryBool("unread"))
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if notifications == nil {
+		notifications = []model.Notification{}
+	}
+
+	return c.JSON(notifications)
+}
+
+// MarkRead handles marking the current user's notifications as read
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req markReadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if !req.All && len(req.IDs) == 0 {
+		return errorResponse(c, fiber.StatusBadRequest, errors.New("either ids or all must be set"))
+	}
+
+	if err := h.notificationService.MarkRead(c.UserContext(), userID, req.IDs); err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetPreferences handles the retrieval of the current user's notification preferences
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	prefs, err := h.notificationService.GetPreferences(c.UserContext(), userID)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(prefs)
+}
+
+// UpdatePreferences handles the update of the current user's notification preferences
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var prefs model.NotificationPreferences
+	if err := c.BodyParser(&prefs); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	prefs.UserID = userID
+
+	err := h.notificationService.UpdatePreferences(c.UserContext(), prefs)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidNotificationLanguage) {
+			return errorResponse(c, fiber.StatusBadRequest, err)
+		}
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(prefs)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PrivacyHandler handles HTTP requests of users exercising their data protection rights
+type PrivacyHandler struct {
+	privacyService *service.Privacy
+}
+
+// NewPrivacyHandler creates a new privacy handler
+func NewPrivacyHandler(privacyService *service.Privacy) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// Export handles download of all data stored about the current user.
+// A ZIP archive is returned by default, format=json returns a single JSON document
+func (h *PrivacyHandler) Export(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	switch c.Query("format", "zip") {
+	case "json":
+		export, err := h.privacyService.Export(c.UserContext(), userID)
+		if err != nil {
+			return errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+		return c.JSON(export)
+	case "zip":
+		// The archive is built in memory so that a failure does not leave a truncated download
+		var buf bytes.Buffer
+		if err := h.privacyService.WriteExportZIP(c.UserContext(), userID, &buf); err != nil {
+			return errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+
+		fileName := fmt.Sprintf("cryptocrowd-data-%d-%s.zip", userID, time.Now().UTC().Format("20060102"))
+		c.Attachment(fileName)
+		return c.Send(buf.Bytes())
+	default:
+		return errorResponse(c, fiber.StatusBadRequest, errors.New("format must be zip or json"))
+	}
+}
+
+// RequestErasure handles scheduling of the current user's account erasure
+func (h *PrivacyHandler) RequestErasure(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	req, err := h.privacyService.RequestErasure(c.UserContext(), userID)
+	if errors.Is(err, service.ErrErasureAlreadyRequested) {
+		return errorResponse(c, fiber.StatusConflict, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(req)
+}
+
+// GetErasure handles retrieval of the current user's pending erasure request
+func (h *PrivacyHandler) GetErasure(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	req, err := h.privacyService.GetErasure(c.UserContext(), userID)
+	if errors.Is(err, service.ErrErasureRequestNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(req)
+}
+
+// CancelErasure handles cancellation of the current user's pending erasure request
+func (h *PrivacyHandler) CancelErasure(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	req, err := h.privacyService.CancelErasure(c.UserContext(), userID)
+	if errors.Is(err, service.ErrErasureRequestNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(req)
+}
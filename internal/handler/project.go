@@ -1,6 +1,10 @@
 package handler
 
 import (
+	"errors"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
 	"github.com/CryptoCrowd/internal/service"
 	"github.com/gofiber/fiber/v2"
 )
@@ -19,22 +23,126 @@ func NewProjectHandler(projectService *service.Project) *ProjectHandler {
 
 // Create handles the creation of a new project
 func (h *ProjectHandler) Create(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var project model.Project
+	if err := c.BodyParser(&project); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	project.OwnerID = userID
+
+	err := h.projectService.Create(c.UserContext(), project)
+	switch {
+	case errors.Is(err, service.ErrInvalidProjectName),
+		errors.Is(err, service.ErrInvalidProjectDescription),
+		errors.Is(err, service.ErrInvalidProjectOwner),
+		errors.Is(err, service.ErrInvalidProjectStatus),
+		errors.Is(err, service.ErrInvalidProjectAmount),
+		errors.Is(err, service.ErrInvalidProjectDeadline):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, service.ErrEmailNotVerified):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, service.ErrKYCRequired):
+		return kycRequiredResponse(c, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusCreated)
 }
 
-// Update handles the update of an existing project
+// Update handles the update of an existing project. The version to change comes from If-Match
 func (h *ProjectHandler) Update(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	var project model.Project
+	if err = c.BodyParser(&project); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	project.ID = id
+	project.Version = ifMatchVersion(c)
+
+	updated, err := h.projectService.Update(c.UserContext(), project, userID)
+	switch {
+	case errors.Is(err, service.ErrInvalidProjectName),
+		errors.Is(err, service.ErrInvalidProjectDescription),
+		errors.Is(err, service.ErrInvalidProjectAmount),
+		errors.Is(err, service.ErrInvalidProjectDeadline):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, service.ErrProjectAccessDenied):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrProjectLocked):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(fiber.HeaderETag, ETag(updated.Version))
+	return c.JSON(updated)
 }
 
-// Delete handles the deletion of a project
+// Delete handles the deletion of a project. The version to delete comes from If-Match
 func (h *ProjectHandler) Delete(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	err = h.projectService.Delete(c.UserContext(), id, userID, ifMatchVersion(c))
+	switch {
+	case errors.Is(err, service.ErrProjectAccessDenied):
+		return errorResponse(c, fiber.StatusForbidden, err)
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrProjectLocked):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // GetByID handles the retrieval of a project by ID
 func (h *ProjectHandler) GetByID(c *fiber.Ctx) error {
-	return nil
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	// The route is public, anonymous callers see approved projects only
+	userID, _ := currentUserID(c)
+	project, err := h.projectService.GetByID(c.UserContext(), id, userID)
+	if errors.Is(err, repository.ErrProjectNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return sendVersioned(c, project.Version, project)
 }
 
 // List handles the listing of projects
@@ -44,10 +152,92 @@ func (h *ProjectHandler) List(c *fiber.Ctx) error {
 
 // ListByOwnerID handles the listing of projects by owner ID
 func (h *ProjectHandler) ListByOwnerID(c *fiber.Ctx) error {
-	return nil
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	ownerID, err := paramInt64(c, "owner_id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	projects, err := h.projectService.ListByOwnerID(c.UserContext(), ownerID, userID, c.Query("search"))
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if projects == nil {
+		projects = []model.Project{}
+	}
+
+	return c.JSON(projects)
 }
 
 // GetPhotosByProjectID handles the retrieval of photos for a project
 func (h *ProjectHandler) GetPhotosByProjectID(c *fiber.Ctx) error {
 	return nil
 }
+
+type updateStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// UpdateStatus handles a moderation decision on a project
+func (h *ProjectHandler) UpdateStatus(c *fiber.Ctx) error {
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	var req updateStatusRequest
+	if err = c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	version := ifMatchVersion(c)
+	err = h.projectService.UpdateStatus(c.UserContext(), id, req.Status, version)
+	switch {
+	case errors.Is(err, service.ErrInvalidProjectStatus):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errorResponse(c, fiber.StatusPreconditionFailed, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(fiber.HeaderETag, ETag(version+1))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListDeleted handles the listing of projects marked as deleted
+func (h *ProjectHandler) ListDeleted(c *fiber.Ctx) error {
+	projects, err := h.projectService.ListDeleted(c.UserContext())
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if projects == nil {
+		projects = []model.Project{}
+	}
+
+	return c.JSON(projects)
+}
+
+// Restore handles the restoration of a project marked as deleted
+func (h *ProjectHandler) Restore(c *fiber.Ctx) error {
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	project, err := h.projectService.Restore(c.UserContext(), id)
+	if errors.Is(err, repository.ErrProjectNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(project)
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/realtime"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+const (
+	projectIDKey = "projectID"
+
+	// writeWait limits how long a single write to a slow client may block
+	writeWait = 10 * time.Second
+	// retryAfterSeconds is suggested to clients rejected because of connection limits
+	retryAfterSeconds = 5
+)
+
+// RealtimeHandler streams project funding progress over SSE and WebSocket
+type RealtimeHandler struct {
+	projectService *service.Project
+	hub            *realtime.Hub
+	pingInterval   time.Duration
+}
+
+// NewRealtimeHandler creates a new realtime handler
+func NewRealtimeHandler(projectService *service.Project, hub *realtime.Hub, pingInterval time.Duration) *RealtimeHandler {
+	return &RealtimeHandler{
+		projectService: projectService,
+		hub:            hub,
+		pingInterval:   pingInterval,
+	}
+}
+
+// Stream handles a Server-Sent Events subscription to a project's funding progress
+func (h *RealtimeHandler) Stream(c *fiber.Ctx) error {
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	// Subscribe before taking the snapshot so no update in between is lost
+	sub, err := h.hub.Subscribe(id)
+	if err != nil {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfterSeconds))
+		return errorResponse(c, fiber.StatusServiceUnavailable, err)
+	}
+
+	progress, err := h.projectService.GetProgress(c.UserContext(), id)
+	if err != nil {
+		sub.Close()
+		return progressErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		ticker := time.NewTicker(h.pingInterval)
+		defer ticker.Stop()
+
+		if writeEvent(w, progress) != nil {
+			return
+		}
+		for {
+			select {
+			case update, ok := <-sub.Updates():
+				if !ok || writeEvent(w, update) != nil {
+					return
+				}
+			case <-ticker.C:
+				// A comment line keeps proxies from closing an idle stream and detects gone clients
+				if _, err := w.WriteString(": ping\n\n"); err != nil || w.Flush() != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeEvent(w *bufio.Writer, progress model.ProjectProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// UpgradeWebSocket checks that the request is a WebSocket upgrade for an existing project
+func (h *RealtimeHandler) UpgradeWebSocket(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if _, err = h.projectService.GetProgress(c.UserContext(), id); err != nil {
+		return progressErrorResponse(c, err)
+	}
+
+	c.Locals(projectIDKey, id)
+	return c.Next()
+}
+
+// WebSocket handles a WebSocket subscription to a project's funding progress
+func (h *RealtimeHandler) WebSocket(conn *websocket.Conn) {
+	defer conn.Close()
+
+	id, _ := conn.Locals(projectIDKey).(int64)
+
+	sub, err := h.hub.Subscribe(id)
+	if err != nil {
+		msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error())
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+		return
+	}
+	defer sub.Close()
+
+	// The stream is one-way; reading is only needed to process control frames and notice disconnects
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeWait)
+	progress, err := h.projectService.GetProgress(ctx, id)
+	cancel()
+	if err != nil {
+		logger.Errorf("ошибка получения хода сбора средств проекта %d: %v", id, err)
+		return
+	}
+	if writeJSON(conn, progress) != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case update, ok := <-sub.Updates():
+			if !ok || writeJSON(conn, update) != nil {
+				return
+			}
+		case <-ticker.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)) != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeJSON(conn *websocket.Conn, v any) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(v)
+}
+
+func progressErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, repository.ErrProjectNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	return errorResponse(c, fiber.StatusInternalServerError, err)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RuntimeHandler handles HTTP requests of administrators changing settings of the running process
+type RuntimeHandler struct {
+	runtimeService *service.Runtime
+}
+
+// NewRuntimeHandler creates a new runtime handler
+func NewRuntimeHandler(runtimeService *service.Runtime) *RuntimeHandler {
+	return &RuntimeHandler{
+		runtimeService: runtimeService,
+	}
+}
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// GetLogLevel handles reading of the current log level
+func (h *RuntimeHandler) GetLogLevel(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"level": h.runtimeService.LogLevel()})
+}
+
+// SetLogLevel handles a change of the log level without a restart
+func (h *RuntimeHandler) SetLogLevel(c *fiber.Ctx) error {
+	var req logLevelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	err := h.runtimeService.SetLogLevel(c.UserContext(), req.Level)
+	if errors.Is(err, service.ErrInvalidLogLevel) {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"level": h.runtimeService.LogLevel()})
+}
+
+// ReloadConfig handles a reload of the configuration file, the same as SIGHUP
+func (h *RuntimeHandler) ReloadConfig(c *fiber.Ctx) error {
+	restartRequired, err := h.runtimeService.ReloadConfig(c.UserContext())
+	if errors.Is(err, service.ErrInvalidConfig) {
+		return errorResponse(c, fiber.StatusUnprocessableEntity, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if restartRequired == nil {
+		restartRequired = []string{}
+	}
+
+	return c.JSON(fiber.Map{"reloaded": true, "restart_required": restartRequired})
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ScreeningHandler handles HTTP requests of administrators reviewing sanctions screening hits
+type ScreeningHandler struct {
+	screeningService *service.Screening
+}
+
+// NewScreeningHandler creates a new screening handler
+func NewScreeningHandler(screeningService *service.Screening) *ScreeningHandler {
+	return &ScreeningHandler{
+		screeningService: screeningService,
+	}
+}
+
+type reviewScreeningHitRequest struct {
+	Status string `json:"status"`
+}
+
+// ListHits handles listing of screening hits by status, pending by default
+func (h *ScreeningHandler) ListHits(c *fiber.Ctx) error {
+	hits, err := h.screeningService.ListHits(c.UserContext(), c.Query("status"))
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if hits == nil {
+		hits = []model.ScreeningHit{}
+	}
+
+	return c.JSON(hits)
+}
+
+// Review handles an administrator's decision on a screening hit
+func (h *ScreeningHandler) Review(c *fiber.Ctx) error {
+	reviewerID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	var req reviewScreeningHitRequest
+	if err = c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	hit, err := h.screeningService.Review(c.UserContext(), reviewerID, id, req.Status)
+	switch {
+	case errors.Is(err, service.ErrInvalidScreeningDecision):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, service.ErrScreeningHitNotFound):
+		return errorResponse(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrScreeningHitNotPending):
+		return errorResponse(c, fiber.StatusConflict, err)
+	case err != nil:
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(hit)
+}
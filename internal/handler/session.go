@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionHandler handles HTTP requests related to login sessions of the current user
+type SessionHandler struct {
+	sessionService *service.Session
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessionService *service.Session) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// List handles the listing of the current user's active sessions
+func (h *SessionHandler) List(c *fiber.Ctx) error {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	sessions, err := h.sessionService.List(c.UserContext(), claims.UserID, claims.SessionID)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if sessions == nil {
+		sessions = []model.Session{}
+	}
+
+	return c.JSON(sessions)
+}
+
+// Revoke handles ending one of the current user's sessions
+func (h *SessionHandler) Revoke(c *fiber.Ctx) error {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	id, err := paramInt64(c, "id")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	err = h.sessionService.Revoke(c.UserContext(), claims.UserID, id)
+	if errors.Is(err, service.ErrSessionNotFound) {
+		return errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RevokeOthers handles ending all sessions of the current user except the current one
+func (h *SessionHandler) RevokeOthers(c *fiber.Ctx) error {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	if err := h.sessionService.RevokeOthers(c.UserContext(), claims.UserID, claims.SessionID); err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
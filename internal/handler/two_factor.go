@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorHandler handles HTTP requests related to two-factor authentication
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactor
+}
+
+// NewTwoFactorHandler creates a new two-factor authentication handler
+func NewTwoFactorHandler(twoFactorService *service.TwoFactor) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+type confirmTwoFactorRequest struct {
+	Code string `json:"code"`
+}
+
+// Enroll handles the generation of a new TOTP secret
+func (h *TwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	secret, uri, err := h.twoFactorService.Enroll(c.UserContext(), userID)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"secret": secret, "otpauth_uri": uri})
+}
+
+// Confirm handles the activation of two-factor authentication
+func (h *TwoFactorHandler) Confirm(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req confirmTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	codes, err := h.twoFactorService.Confirm(c.UserContext(), userID, req.Code)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+// Disable handles turning two-factor authentication off
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	if err := h.twoFactorService.Disable(c.UserContext(), userID); err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles the replacement of recovery codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.UserContext(), userID)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
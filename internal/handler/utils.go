@@ -1 +1,110 @@
 package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// UserIDKey is the fiber.Ctx locals key under which the router stores the authenticated user ID
+const UserIDKey = "userID"
+
+// IfMatchKey is the fiber.Ctx locals key under which the router stores the version from If-Match
+const IfMatchKey = "ifMatchVersion"
+
+var errUnauthorized = errors.New("authentication required")
+
+// errorResponse writes a JSON error body with the given status code
+func errorResponse(c *fiber.Ctx, status int, err error) error {
+	return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+}
+
+// currentUserID returns the ID of the authenticated user
+func currentUserID(c *fiber.Ctx) (int64, bool) {
+	id, ok := c.Locals(UserIDKey).(int64)
+	return id, ok
+}
+
+// currentClaims returns the access token claims of the authenticated user
+func currentClaims(c *fiber.Ctx) (auth.Claims, bool) {
+	return auth.ClaimsFromContext(c.UserContext())
+}
+
+// paramInt64 parses a positive integer route parameter
+func paramInt64(c *fiber.Ctx, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Params(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid " + name)
+	}
+	return id, nil
+}
+
+// queryInt64 parses an optional integer query parameter
+func queryInt64(c *fiber.Ctx, name string) (*int64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &value, nil
+}
+
+// queryTime parses an optional RFC 3339 query parameter
+func queryTime(c *fiber.Ctx, name string) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &value, nil
+}
+
+// ETag formats the version of a record as a strong entity tag
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseETag parses an entity tag produced by ETag. Weak tags are rejected
+// because If-Match requires strong comparison
+func ParseETag(tag string) (int64, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// ifMatchVersion returns the record version the client expects to change
+func ifMatchVersion(c *fiber.Ctx) int64 {
+	version, _ := c.Locals(IfMatchKey).(int64)
+	return version
+}
+
+// sendVersioned writes a record with its version as ETag. A client that already
+// holds this version in If-None-Match gets 304 Not Modified
+func sendVersioned(c *fiber.Ctx, version int64, body any) error {
+	tag := ETag(version)
+	c.Set(fiber.HeaderETag, tag)
+	if c.Get(fiber.HeaderIfNoneMatch) == tag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.JSON(body)
+}
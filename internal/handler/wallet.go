@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WalletHandler handles HTTP requests of Sign-In With Ethereum and wallet linking
+type WalletHandler struct {
+	walletService *service.WalletAuth
+}
+
+// NewWalletHandler creates a new wallet handler
+func NewWalletHandler(walletService *service.WalletAuth) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+	}
+}
+
+type nonceResponse struct {
+	Nonce     string `json:"nonce"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+type walletLoginRequest struct {
+	Message      string `json:"message"`
+	Signature    string `json:"signature"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+	DeviceName   string `json:"device_name"`
+}
+
+type linkWalletRequest struct {
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// Nonce handles issuing of a nonce for a message to be signed by the wallet
+func (h *WalletHandler) Nonce(c *fiber.Ctx) error {
+	nonce, expiresAt, err := h.walletService.Nonce(c.UserContext())
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(nonceResponse{Nonce: nonce, ExpiresAt: expiresAt.Unix()})
+}
+
+// Login handles login with a signed EIP-4361 message
+func (h *WalletHandler) Login(c *fiber.Ctx) error {
+	var req walletLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	client := model.ClientInfo{
+		Device:    req.DeviceName,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+
+	token, claims, err := h.walletService.Login(c.UserContext(), req.Message, req.Signature, req.Code, req.RecoveryCode, client)
+	if err != nil {
+		return walletErrorResponse(c, err)
+	}
+
+	return c.JSON(tokenResponse{AccessToken: token, TokenType: "Bearer", ExpiresAt: claims.ExpiresAt})
+}
+
+// Link handles linking of a wallet to the current user's account
+func (h *WalletHandler) Link(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	var req linkWalletRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	wallet, err := h.walletService.Link(c.UserContext(), userID, req.Message, req.Signature)
+	if err != nil {
+		return walletErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(wallet)
+}
+
+// List handles the listing of wallets linked to the current user's account
+func (h *WalletHandler) List(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, errUnauthorized)
+	}
+
+	wallets, err := h.walletService.List(c.UserContext(), userID)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if wallets == nil {
+		wallets = []model.Wallet{}
+	}
+
+	return c.JSON(wallets)
+}
+
+func walletErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidSIWEMessage):
+		return errorResponse(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, service.ErrInvalidSignature),
+		errors.Is(err, service.ErrInvalidNonce):
+		return errorResponse(c, fiber.StatusUnauthorized, err)
+	case errors.Is(err, service.ErrWalletAlreadyLinked):
+		return errorResponse(c, fiber.StatusConflict, err)
+	default:
+		return authErrorResponse(c, err)
+	}
+}
@@ -0,0 +1,158 @@
+package kyc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+func TestProviders(t *testing.T) {
+	submission := Submission{Application: model.KYCApplication{ID: 7}}
+
+	tests := []struct {
+		name     string
+		provider func() (Provider, error)
+		want     Decision
+		wantErr  bool
+	}{
+		{
+			name:     "manual leaves the application pending",
+			provider: func() (Provider, error) { return NewManualProvider(), nil },
+			want:     Decision{Status: model.KYCPending},
+		},
+		{
+			name:     "mock verifies",
+			provider: func() (Provider, error) { return NewMockProvider(model.KYCVerified) },
+			want:     Decision{Status: model.KYCVerified, Reference: "mock-7"},
+		},
+		{
+			name:     "mock rejects with a reason",
+			provider: func() (Provider, error) { return NewMockProvider(model.KYCRejected) },
+			want:     Decision{Status: model.KYCRejected, Reference: "mock-7", Reason: "отклонено mock-провайдером"},
+		},
+		{
+			name:     "mock leaves pending",
+			provider: func() (Provider, error) { return NewMockProvider(model.KYCPending) },
+			want:     Decision{Status: model.KYCPending, Reference: "mock-7"},
+		},
+		{
+			name:     "mock with an unknown decision",
+			provider: func() (Provider, error) { return NewMockProvider("approved") },
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.provider()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("provider: error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := p.Submit(context.Background(), submission)
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Submit = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// failingReader отдает часть данных и затем ошибку
+type failingReader struct {
+	sent bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("соединение разорвано")
+	}
+	r.sent = true
+	return copy(p, "частично"), nil
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "kyc")
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	if err = store.Save(ctx, "7/passport.jpg", strings.NewReader("passport")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "7", "passport.jpg"))
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("document permissions = %o, want 600", perm)
+	}
+
+	r, err := store.Open(ctx, "7/passport.jpg")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	content, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(content) != "passport" {
+		t.Fatalf("Open read %q, %v, want %q", content, err, "passport")
+	}
+
+	// Документ не перезаписывается повторной загрузкой под тем же ключом
+	if err = store.Save(ctx, "7/passport.jpg", strings.NewReader("other")); err == nil {
+		t.Fatal("Save over an existing document succeeded")
+	}
+
+	if err = store.Save(ctx, "7/selfie.jpg", &failingReader{}); err == nil {
+		t.Fatal("Save from a failing reader succeeded")
+	}
+	if _, err = store.Open(ctx, "7/selfie.jpg"); !errors.Is(err, ErrDocumentNotFound) {
+		t.Fatalf("Open after a failed Save: error = %v, want %v", err, ErrDocumentNotFound)
+	}
+
+	if err = store.Delete(ctx, "7/passport.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err = store.Open(ctx, "7/passport.jpg"); !errors.Is(err, ErrDocumentNotFound) {
+		t.Fatalf("Open after Delete: error = %v, want %v", err, ErrDocumentNotFound)
+	}
+	if err = store.Delete(ctx, "7/passport.jpg"); err != nil {
+		t.Fatalf("Delete of a missing document: %v", err)
+	}
+}
+
+func TestFileStoreRejectsKeysOutsideDir(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "kyc"))
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	for _, key := range []string{"../escape.jpg", "7/../../escape.jpg", "/etc/passwd", ""} {
+		t.Run(key, func(t *testing.T) {
+			if err := store.Save(ctx, key, strings.NewReader("x")); err == nil {
+				t.Error("Save accepted the key")
+			}
+			if _, err := store.Open(ctx, key); err == nil || errors.Is(err, ErrDocumentNotFound) {
+				t.Errorf("Open: error = %v, want an invalid key error", err)
+			}
+			if err := store.Delete(ctx, key); err == nil {
+				t.Error("Delete accepted the key")
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package kyc
+
+import (
+	"context"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// ManualProvider оставляет заявки на рассмотрение администратору
+type ManualProvider struct{}
+
+func NewManualProvider() *ManualProvider {
+	return &ManualProvider{}
+}
+
+func (p *ManualProvider) Name() string {
+	return "manual"
+}
+
+// Submit ставит заявку в очередь ручной проверки
+func (p *ManualProvider) Submit(_ context.Context, _ Submission) (Decision, error) {
+	return Decision{Status: model.KYCPending}, nil
+}
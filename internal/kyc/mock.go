@@ -0,0 +1,40 @@
+package kyc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// MockProvider сразу выносит заданное решение. Предназначен для тестов и локальной разработки
+type MockProvider struct {
+	decision string
+}
+
+// NewMockProvider создает провайдер, который принимает решение decision: verified, rejected или pending
+func NewMockProvider(decision string) (*MockProvider, error) {
+	switch decision {
+	case model.KYCVerified, model.KYCRejected, model.KYCPending:
+	default:
+		return nil, fmt.Errorf("неподдерживаемое решение mock-провайдера KYC: %s", decision)
+	}
+
+	return &MockProvider{decision: decision}, nil
+}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// Submit возвращает заранее заданное решение
+func (p *MockProvider) Submit(_ context.Context, submission Submission) (Decision, error) {
+	decision := Decision{
+		Status:    p.decision,
+		Reference: fmt.Sprintf("mock-%d", submission.Application.ID),
+	}
+	if p.decision == model.KYCRejected {
+		decision.Reason = "отклонено mock-провайдером"
+	}
+	return decision, nil
+}
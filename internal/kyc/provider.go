@@ -0,0 +1,28 @@
+package kyc
+
+import (
+	"context"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// Submission - заявка на проверку личности вместе с загруженными документами
+type Submission struct {
+	Application model.KYCApplication
+	Account     model.Account
+	Documents   []model.KYCDocument
+}
+
+// Decision - ответ провайдера на заявку. Статус model.KYCPending означает,
+// что решение будет принято позже, например администратором
+type Decision struct {
+	Status    string
+	Reference string
+	Reason    string
+}
+
+// Provider проверяет личность владельца аккаунта по документам
+type Provider interface {
+	Name() string
+	Submit(ctx context.Context, submission Submission) (Decision, error)
+}
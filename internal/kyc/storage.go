@@ -0,0 +1,102 @@
+package kyc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrDocumentNotFound возвращается, если файл документа отсутствует в хранилище
+	ErrDocumentNotFound = errors.New("файл документа не найден")
+)
+
+// DocumentStore хранит файлы документов KYC
+type DocumentStore interface {
+	Save(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// FileStore хранит документы в локальном каталоге
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore создает хранилище в каталоге dir, создавая его при необходимости.
+// Документы содержат персональные данные, поэтому доступ открыт только владельцу процесса
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога документов KYC: %w", err)
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+// Save записывает файл под ключом key. Частично записанный файл удаляется
+func (s *FileStore) Save(_ context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("ошибка создания каталога документа: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла документа: %w", err)
+	}
+
+	if _, err = io.Copy(file, r); err != nil {
+		file.Close()
+		os.Remove(path)
+		return fmt.Errorf("ошибка записи файла документа: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("ошибка записи файла документа: %w", err)
+	}
+	return nil
+}
+
+// Open открывает файл документа для чтения
+func (s *FileStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла документа: %w", err)
+	}
+	return file, nil
+}
+
+// Delete удаляет файл документа. Отсутствующий файл не считается ошибкой
+func (s *FileStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("ошибка удаления файла документа: %w", err)
+	}
+	return nil
+}
+
+// path возвращает путь к файлу ключа, не позволяя выйти за пределы каталога хранилища
+func (s *FileStore) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("недопустимый ключ документа: %s", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type fieldsKey struct{}
+
+// With возвращает контекст, в котором логгер запроса добавляет к записям поля keysAndValues.
+// Поля накапливаются при повторных вызовах. Значения fmt.Stringer вычисляются при каждом
+// вызове FromContext, что позволяет добавить поле раньше, чем станет известно его значение
+func With(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	return context.WithValue(ctx, fieldsKey{}, append(slices.Clip(fields), keysAndValues...))
+}
+
+// FromContext возвращает логгер с полями, сохраненными в контексте через With, и
+// идентификаторами трассировки, если в контексте есть спан.
+// Если полей нет, например в фоновой задаче, возвращает глобальный логгер
+func FromContext(ctx context.Context) Logger {
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(slices.Clip(fields), "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	if len(fields) == 0 {
+		return Global()
+	}
+
+	if l, ok := Global().(*zap.SugaredLogger); ok {
+		return l.With(fields...)
+	}
+	return Global()
+}
@@ -1,7 +1,8 @@
 package logger
 
 import (
-	"os"
+	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/CryptoCrowd/internal/config"
@@ -20,11 +21,20 @@ type Logger interface {
 	Warnf(template string, args ...interface{})
 	Errorf(template string, args ...interface{})
 	Fatalf(template string, args ...interface{})
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
 }
 
-// globalLogger хранит экземпляр глобального логгера
+// ErrInvalidLevel - неизвестный уровень логирования
+var ErrInvalidLevel = errors.New("неизвестный уровень логирования")
+
+// globalLogger хранит экземпляр глобального логгера, globalLevel - его уровень,
+// который можно менять во время работы
 var (
 	globalLogger Logger
+	globalLevel  = zap.NewAtomicLevel()
 	once         sync.Once
 )
 
@@ -32,11 +42,28 @@ var (
 func InitGlobalLogger(cfg *config.Config) error {
 	var err error
 	once.Do(func() {
-		globalLogger, err = NewSugaredLogger(cfg)
+		globalLevel.SetLevel(getLogLevel(cfg.Logger.Level))
+		globalLogger, err = newSugaredLogger(cfg, globalLevel)
 	})
 	return err
 }
 
+// Level возвращает текущий уровень глобального логгера
+func Level() string {
+	return globalLevel.Level().String()
+}
+
+// SetLevel меняет уровень глобального логгера без перезапуска
+func SetLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidLevel, level)
+	}
+
+	globalLevel.SetLevel(parsed)
+	return nil
+}
+
 // Global возвращает экземпляр глобального логгера
 func Global() Logger {
 	if globalLogger == nil {
@@ -59,8 +86,10 @@ func Errorf(template string, args ...interface{}) { Global().Errorf(template, ar
 func Fatalf(template string, args ...interface{}) { Global().Fatalf(template, args...) }
 
 func NewSugaredLogger(cfg *config.Config) (Logger, error) {
-	level := getLogLevel(cfg.Logger.Level)
+	return newSugaredLogger(cfg, zap.NewAtomicLevelAt(getLogLevel(cfg.Logger.Level)))
+}
 
+func newSugaredLogger(cfg *config.Config, level zap.AtomicLevel) (Logger, error) {
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "ts",
 		LevelKey:       "level",
@@ -76,30 +105,11 @@ func NewSugaredLogger(cfg *config.Config) (Logger, error) {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	var encoder zapcore.Encoder
-	if cfg.Logger.Encoding == "console" {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
-	} else {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	}
-
-	var writeSyncer zapcore.WriteSyncer
-	if cfg.Logger.OutputPath == "" {
-		file, err := os.OpenFile(cfg.Logger.OutputPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
-			return nil, err
-		}
-		writeSyncer = zapcore.AddSync(file)
-	} else {
-		writeSyncer = zapcore.AddSync(os.Stdout)
+	core, err := newCore(cfg.Logger, level, encoderConfig)
+	if err != nil {
+		return nil, err
 	}
 
-	core := zapcore.NewCore(
-		encoder,
-		writeSyncer,
-		level,
-	)
-
 	var logger *zap.Logger
 	if cfg.Logger.DevMode {
 		logger = zap.New(core, zap.Development(), zap.AddStacktrace(zapcore.ErrorLevel))
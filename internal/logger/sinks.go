@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/CryptoCrowd/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// newCore объединяет выводы логов. Запись попадает в вывод, если ее уровень проходит и общий
+// уровень level, который меняется во время работы, и собственный уровень вывода
+func newCore(cfg config.LoggerConfig, level zapcore.LevelEnabler, encoderConfig zapcore.EncoderConfig) (zapcore.Core, error) {
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		// Конфигурация собрана без config.Load и значений по умолчанию
+		sinks = []config.LogSinkConfig{{Type: "stdout", Encoding: cfg.Encoding}}
+	}
+
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for idx, sink := range sinks {
+		core, err := newSinkCore(sink, level, encoderConfig)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка создания вывода логов %d (%s): %w", idx, sink.Type, err)
+		}
+		cores = append(cores, core)
+	}
+
+	core := zapcore.NewTee(cores...)
+	if cfg.Sampling.Thereafter > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
+	return core, nil
+}
+
+func newSinkCore(sink config.LogSinkConfig, level zapcore.LevelEnabler, encoderConfig zapcore.EncoderConfig) (zapcore.Core, error) {
+	enabler := level
+	if sink.Level != "" {
+		minLevel := getLogLevel(sink.Level)
+		enabler = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l >= minLevel && level.Enabled(l)
+		})
+	}
+
+	var encoder zapcore.Encoder
+	if sink.Encoding == "console" {
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	} else {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	}
+
+	switch sink.Type {
+	case "stdout":
+		return zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), enabler), nil
+	case "stderr":
+		return zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), enabler), nil
+	case "file":
+		return zapcore.NewCore(encoder, newRotatingFile(sink), enabler), nil
+	case "syslog":
+		writer, err := syslog.Dial(sink.Network, sink.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, sink.Tag)
+		if err != nil {
+			return nil, err
+		}
+		return &syslogCore{LevelEnabler: enabler, encoder: encoder, writer: writer}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный тип вывода логов: %s", sink.Type)
+	}
+}
+
+// newRotatingFile открывает файл, который ротируется по размеру и, если задан
+// rotate_interval, по времени. Ротированные файлы старше max_age удаляются
+func newRotatingFile(sink config.LogSinkConfig) zapcore.WriteSyncer {
+	file := &lumberjack.Logger{
+		Filename:   sink.Path,
+		MaxSize:    sink.MaxSize,
+		MaxAge:     sink.MaxAge,
+		MaxBackups: sink.MaxBackups,
+		Compress:   sink.Compress,
+		LocalTime:  true,
+	}
+
+	if sink.RotateInterval > 0 {
+		// Логгер живет до завершения процесса, поэтому ротация не останавливается
+		go func() {
+			ticker := time.NewTicker(time.Duration(sink.RotateInterval) * time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := file.Rotate(); err != nil {
+					fmt.Fprintf(os.Stderr, "ошибка ротации файла логов %s: %v\n", sink.Path, err)
+				}
+			}
+		}()
+	}
+
+	return zapcore.AddSync(file)
+}
+
+// syslogCore пишет записи в syslog с приоритетом, соответствующим уровню записи
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslog.Writer
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &syslogCore{LevelEnabler: c.LevelEnabler, encoder: c.encoder.Clone(), writer: c.writer}
+	for _, field := range fields {
+		field.AddTo(clone.encoder)
+	}
+	return clone
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	msg := strings.TrimSuffix(buf.String(), "\n")
+	switch entry.Level {
+	case zapcore.DebugLevel:
+		return c.writer.Debug(msg)
+	case zapcore.InfoLevel:
+		return c.writer.Info(msg)
+	case zapcore.WarnLevel:
+		return c.writer.Warning(msg)
+	case zapcore.ErrorLevel:
+		return c.writer.Err(msg)
+	default:
+		return c.writer.Crit(msg)
+	}
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
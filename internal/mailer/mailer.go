@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CryptoCrowd/internal/config"
+)
+
+// Message - письмо, готовое к отправке
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New создает реализацию Mailer в соответствии с конфигурацией
+func New(cfg config.MailerConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg), nil
+	case "file":
+		return NewFileMailer(cfg.Dir, cfg.From)
+	default:
+		return nil, fmt.Errorf("неизвестный драйвер почты: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,133 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CryptoCrowd/internal/config"
+)
+
+func TestBuildMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      Message
+		wantHead []string
+		wantBody string
+	}{
+		{
+			name:     "ASCII subject",
+			msg:      Message{To: "alice@example.com", Subject: "Project approved", Body: "Hello!\nBye"},
+			wantHead: []string{"From: no-reply@cryptocrowd.local", "To: alice@example.com", "Subject: Project approved", "MIME-Version: 1.0", `Content-Type: text/plain; charset="UTF-8"`},
+			wantBody: "Hello!\r\nBye",
+		},
+		{
+			name:     "subject in Russian is Q-encoded",
+			msg:      Message{To: "boris@example.com", Subject: "Проект одобрен", Body: "Здравствуйте"},
+			wantHead: []string{"To: boris@example.com", "Subject: =?UTF-8?q?"},
+			wantBody: "Здравствуйте",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := string(buildMessage("no-reply@cryptocrowd.local", tt.msg))
+
+			head, body, ok := strings.Cut(raw, "\r\n\r\n")
+			if !ok {
+				t.Fatalf("message has no blank line between headers and body:\n%s", raw)
+			}
+			for _, want := range tt.wantHead {
+				if !strings.Contains(head, want) {
+					t.Errorf("headers do not contain %q:\n%s", want, head)
+				}
+			}
+			if strings.Contains(strings.ReplaceAll(raw, "\r\n", ""), "\n") {
+				t.Errorf("message contains a bare LF:\n%q", raw)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m, err := NewFileMailer(dir, "no-reply@cryptocrowd.local")
+	if err != nil {
+		t.Fatalf("NewFileMailer: %v", err)
+	}
+
+	msg := Message{To: "../alice@example.com", Subject: "Subject", Body: "Body"}
+	if err = m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("%d files in the mail directory, want 1", len(files))
+	}
+	// Адрес получателя входит в имя файла, поэтому разделители пути должны быть заменены
+	if name := files[0].Name(); !strings.HasSuffix(name, "_.._alice@example.com.eml") {
+		t.Fatalf("file name = %q, want the sanitized recipient", name)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if want := string(buildMessage("no-reply@cryptocrowd.local", msg)); string(content) != want {
+		t.Fatalf("file content = %q, want %q", content, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = m.Send(ctx, msg); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Send with a cancelled context: error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.MailerConfig
+		check   func(Mailer) bool
+		wantErr bool
+	}{
+		{
+			name: "smtp",
+			cfg:  config.MailerConfig{Driver: "smtp", SMTPHost: "smtp.example.com", SMTPPort: "587"},
+			check: func(m Mailer) bool {
+				smtpMailer, ok := m.(*SMTPMailer)
+				return ok && smtpMailer.addr == "smtp.example.com:587"
+			},
+		},
+		{
+			name: "file",
+			cfg:  config.MailerConfig{Driver: "file", Dir: t.TempDir()},
+			check: func(m Mailer) bool {
+				_, ok := m.(*FileMailer)
+				return ok
+			},
+		},
+		{name: "unknown driver", cfg: config.MailerConfig{Driver: "pigeon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New: error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !tt.check(m) {
+				t.Fatalf("New = %#v, unexpected mailer for driver %q", m, tt.cfg.Driver)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopspring/decimal"
+)
+
+// scrapeTimeout ограничивает запросы к базе данных при сборе метрик
+const scrapeTimeout = 5 * time.Second
+
+var (
+	poolAcquiredConns = prometheus.NewDesc(namespace+"_db_pool_acquired_connections",
+		"Число соединений, занятых запросами", []string{"pool"}, nil)
+	poolIdleConns = prometheus.NewDesc(namespace+"_db_pool_idle_connections",
+		"Число свободных соединений", []string{"pool"}, nil)
+	poolTotalConns = prometheus.NewDesc(namespace+"_db_pool_total_connections",
+		"Общее число открытых соединений", []string{"pool"}, nil)
+	poolMaxConns = prometheus.NewDesc(namespace+"_db_pool_max_connections",
+		"Максимальный размер пула", []string{"pool"}, nil)
+	poolAcquires = prometheus.NewDesc(namespace+"_db_pool_acquires_total",
+		"Число полученных из пула соединений", []string{"pool"}, nil)
+	poolEmptyAcquires = prometheus.NewDesc(namespace+"_db_pool_empty_acquires_total",
+		"Число запросов соединения, которым пришлось ждать, потому что свободных соединений не было",
+		[]string{"pool"}, nil)
+	poolAcquireWait = prometheus.NewDesc(namespace+"_db_pool_acquire_wait_seconds_total",
+		"Суммарное время ожидания соединения из пула", []string{"pool"}, nil)
+
+	projectsByStatus = prometheus.NewDesc(namespace+"_projects",
+		"Число проектов по статусам", []string{"status"}, nil)
+	investedAmount = prometheus.NewDesc(namespace+"_invested_amount",
+		"Общая сумма действующих инвестиций", nil, nil)
+)
+
+// PoolStats возвращает статистику пулов соединений по их названиям
+type PoolStats func() map[string]*pgxpool.Stat
+
+type poolCollector struct {
+	stats PoolStats
+}
+
+// NewPoolCollector создает сборщик статистики пулов соединений pgxpool
+func NewPoolCollector(stats PoolStats) prometheus.Collector {
+	return &poolCollector{stats: stats}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+	ch <- poolAcquires
+	ch <- poolEmptyAcquires
+	ch <- poolAcquireWait
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, stat := range c.stats() {
+		ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(poolAcquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds(), name)
+	}
+}
+
+// ProjectCounter считает проекты по статусам
+type ProjectCounter interface {
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+// InvestmentTotaler считает общую сумму действующих инвестиций
+type InvestmentTotaler interface {
+	SumAmount(ctx context.Context) (decimal.Decimal, error)
+}
+
+type businessCollector struct {
+	projects    ProjectCounter
+	investments InvestmentTotaler
+}
+
+// NewBusinessCollector создает сборщик бизнес-показателей, которые хранятся в базе данных
+// и не сбрасываются при перезапуске. Запросы выполняются при каждом сборе метрик
+func NewBusinessCollector(projects ProjectCounter, investments InvestmentTotaler) prometheus.Collector {
+	return &businessCollector{projects: projects, investments: investments}
+}
+
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- projectsByStatus
+	ch <- investedAmount
+}
+
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	counts, err := c.projects.CountByStatus(ctx)
+	if err != nil {
+		logger.Errorf("ошибка получения числа проектов для метрик: %v", err)
+		ch <- prometheus.NewInvalidMetric(projectsByStatus, err)
+	}
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(projectsByStatus, prometheus.GaugeValue, float64(count), status)
+	}
+
+	total, err := c.investments.SumAmount(ctx)
+	if err != nil {
+		logger.Errorf("ошибка получения суммы инвестиций для метрик: %v", err)
+		ch <- prometheus.NewInvalidMetric(investedAmount, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(investedAmount, prometheus.GaugeValue, total.InexactFloat64())
+}
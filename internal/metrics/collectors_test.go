@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+)
+
+type fixedProjects struct {
+	counts map[string]int64
+	err    error
+}
+
+func (p fixedProjects) CountByStatus(_ context.Context) (map[string]int64, error) {
+	return p.counts, p.err
+}
+
+type fixedInvestments struct {
+	total decimal.Decimal
+	err   error
+}
+
+func (i fixedInvestments) SumAmount(_ context.Context) (decimal.Decimal, error) {
+	return i.total, i.err
+}
+
+func TestBusinessCollector(t *testing.T) {
+	errDB := errors.New("база данных недоступна")
+
+	tests := []struct {
+		name        string
+		projects    fixedProjects
+		investments fixedInvestments
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:        "all metrics",
+			projects:    fixedProjects{counts: map[string]int64{"approved": 3, "pending": 1}},
+			investments: fixedInvestments{total: decimal.RequireFromString("1500.25")},
+			want: []string{
+				"cryptocrowd_invested_amount 1500.25",
+				`cryptocrowd_projects{status="approved"} 3`,
+				`cryptocrowd_projects{status="pending"} 1`,
+			},
+		},
+		{
+			name:        "projects unavailable",
+			projects:    fixedProjects{err: errDB},
+			investments: fixedInvestments{total: decimal.NewFromInt(10)},
+			want:        []string{"cryptocrowd_invested_amount 10"},
+			wantErr:     true,
+		},
+		{
+			name:        "investments unavailable",
+			projects:    fixedProjects{counts: map[string]int64{"approved": 3}},
+			investments: fixedInvestments{err: errDB},
+			want:        []string{`cryptocrowd_projects{status="approved"} 3`},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := prometheus.NewPedanticRegistry()
+			registry.MustRegister(NewBusinessCollector(tt.projects, tt.investments))
+
+			// Ошибка одного запроса возвращается вместе с остальными метриками
+			families, err := registry.Gather()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Gather: error = %v, want error %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, family := range families {
+				for _, metric := range family.GetMetric() {
+					var labels []string
+					for _, label := range metric.GetLabel() {
+						labels = append(labels, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+					}
+					series := family.GetName()
+					if len(labels) > 0 {
+						series += "{" + strings.Join(labels, ",") + "}"
+					}
+					got = append(got, fmt.Sprintf("%s %v", series, metric.GetGauge().GetValue()))
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("metrics = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolCollector(t *testing.T) {
+	// Пул без минимального числа соединений не подключается к базе данных до первого запроса
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/cryptocrowd?pool_max_conns=7")
+	if err != nil {
+		t.Fatalf("pgxpool.New: %v", err)
+	}
+	defer pool.Close()
+
+	collector := NewPoolCollector(func() map[string]*pgxpool.Stat {
+		return map[string]*pgxpool.Stat{"primary": pool.Stat(), "replica": pool.Stat()}
+	})
+
+	if got := testutil.CollectAndCount(collector); got != 14 {
+		t.Fatalf("%d pool metrics, want 7 for each of 2 pools", got)
+	}
+
+	expected := `
+# HELP cryptocrowd_db_pool_max_connections Максимальный размер пула
+# TYPE cryptocrowd_db_pool_max_connections gauge
+cryptocrowd_db_pool_max_connections{pool="primary"} 7
+cryptocrowd_db_pool_max_connections{pool="replica"} 7
+`
+	if err = testutil.CollectAndCompare(collector, strings.NewReader(expected), "cryptocrowd_db_pool_max_connections"); err != nil {
+		t.Fatal(err)
+	}
+}
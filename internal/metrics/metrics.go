@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shopspring/decimal"
+)
+
+// namespace - префикс имен всех метрик приложения
+const namespace = "cryptocrowd"
+
+// Registry содержит метрики приложения, а также метрики среды выполнения Go и процесса
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Длительность обработки HTTP-запросов по маршрутам и статусам ответа",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Число HTTP-запросов, обрабатываемых в данный момент",
+	})
+
+	migrationVersion = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "migration_version",
+		Help:      "Версия последней примененной миграции базы данных",
+	})
+
+	investmentsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "investments_created_total",
+		Help:      "Число инвестиций, созданных с момента запуска процесса",
+	})
+
+	// У платформы одна расчетная валюта, поэтому сумма не разбивается по валютам
+	investmentAmount = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "investment_amount_total",
+		Help:      "Сумма инвестиций, созданных с момента запуска процесса",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		httpRequestsInFlight,
+		migrationVersion,
+		investmentsCreated,
+		investmentAmount,
+	)
+}
+
+// Handler возвращает HTTP-обработчик, отдающий метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		// Ошибка одного сборщика, например недоступность базы данных, не должна скрывать остальные метрики
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// RequestStarted учитывает начало обработки HTTP-запроса
+func RequestStarted() {
+	httpRequestsInFlight.Inc()
+}
+
+// RequestFinished учитывает завершенный HTTP-запрос. route - шаблон маршрута, а не путь,
+// чтобы число рядов метрики не зависело от идентификаторов в URL
+func RequestFinished(method string, route string, status int, seconds float64) {
+	httpRequestsInFlight.Dec()
+	httpRequestDuration.WithLabelValues(method, route, statusLabel(status)).Observe(seconds)
+}
+
+func statusLabel(status int) string {
+	return strconv.Itoa(status)
+}
+
+// SetMigrationVersion сохраняет версию схемы базы данных
+func SetMigrationVersion(version int64) {
+	migrationVersion.Set(float64(version))
+}
+
+// InvestmentCreated учитывает созданную инвестицию
+func InvestmentCreated(amount decimal.Decimal) {
+	investmentsCreated.Inc()
+	investmentAmount.Add(amount.InexactFloat64())
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+)
+
+func TestRequestMetrics(t *testing.T) {
+	inFlight := testutil.ToFloat64(httpRequestsInFlight)
+
+	RequestStarted()
+	RequestStarted()
+	if got := testutil.ToFloat64(httpRequestsInFlight); got != inFlight+2 {
+		t.Fatalf("requests in flight = %v, want %v", got, inFlight+2)
+	}
+
+	RequestFinished("GET", "/projects/:id", 200, 0.02)
+	RequestFinished("GET", "/projects/:id", 404, 0.01)
+	if got := testutil.ToFloat64(httpRequestsInFlight); got != inFlight {
+		t.Fatalf("requests in flight = %v, want %v", got, inFlight)
+	}
+
+	// Маршрут передается шаблоном, поэтому разные идентификаторы дают один ряд на статус
+	if got := testutil.CollectAndCount(httpRequestDuration, namespace+"_http_request_duration_seconds"); got < 2 {
+		t.Fatalf("%d request duration series, want at least 2", got)
+	}
+}
+
+func TestInvestmentCreated(t *testing.T) {
+	created := testutil.ToFloat64(investmentsCreated)
+	amount := testutil.ToFloat64(investmentAmount)
+
+	InvestmentCreated(decimal.RequireFromString("150.5"))
+	InvestmentCreated(decimal.NewFromInt(50))
+
+	if got := testutil.ToFloat64(investmentsCreated); got != created+2 {
+		t.Fatalf("investments created = %v, want %v", got, created+2)
+	}
+	if got := testutil.ToFloat64(investmentAmount); got != amount+200.5 {
+		t.Fatalf("investment amount = %v, want %v", got, amount+200.5)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	SetMigrationVersion(20261019010000)
+	RequestStarted()
+	RequestFinished("POST", "/investments", 201, 0.1)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		"cryptocrowd_migration_version 2.026101901e+13",
+		`cryptocrowd_http_request_duration_seconds_count{method="POST",route="/investments",status="201"} 1`,
+		"cryptocrowd_http_requests_in_flight",
+		"cryptocrowd_investments_created_total",
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %q", want)
+		}
+	}
+}
@@ -3,11 +3,31 @@ package model
 import "time"
 
 type Account struct {
-	ID           int64      `json:"id,omitempty" db:"id"`
-	Username     string     `json:"username" db:"username"`
-	Email        string     `json:"email" db:"email"`
-	PasswordHash string     `json:"-" db:"password_hash"`
-	Role         string     `json:"role" db:"role"`
-	CreatedAt    *time.Time `json:"created_at,omitempty" db:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	ID              int64      `json:"id,omitempty" db:"id"`
+	Username        string     `json:"username" db:"username"`
+	Email           string     `json:"email" db:"email"`
+	PasswordHash    string     `json:"-" db:"password_hash"`
+	Role            string     `json:"role" db:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	KYCStatus       string     `json:"kyc_status" db:"kyc_status"`
+	Country         string     `json:"country,omitempty" db:"country"`
+	CreatedAt       *time.Time `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	Version         int64      `json:"version" db:"version"`
+}
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+type AccountToken struct {
+	ID        int64      `json:"id" db:"id"`
+	UserID    int64      `json:"user_id" db:"user_id"`
+	Purpose   string     `json:"purpose" db:"purpose"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt *time.Time `json:"created_at,omitempty" db:"created_at"`
 }
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	ScopeReadProjects    = "projects:read"
+	ScopeReadInvestments = "investments:read"
+	ScopeManageWebhooks  = "webhooks:manage"
+)
+
+// APIKeyScopes lists the scopes an API key can be granted
+var APIKeyScopes = []string{ScopeReadProjects, ScopeReadInvestments, ScopeManageWebhooks}
+
+type APIKey struct {
+	ID         int64      `db:"id" json:"id"`
+	UserID     int64      `db:"user_id" json:"user_id"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	LastUsedIP string     `db:"last_used_ip" json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  *time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+const (
+	AuditAccountCreate         = "account.create"
+	AuditAccountPasswordChange = "account.password_change"
+	AuditAccountCountryChange  = "account.country_change"
+	AuditAccountEmailChange    = "account.email_change"
+	AuditAccountDataExport     = "account.data_export"
+	AuditAccountErasureRequest = "account.erasure_request"
+	AuditAccountErasureCancel  = "account.erasure_cancel"
+	AuditAccountErase          = "account.erase"
+	AuditAccountRestore        = "account.restore"
+	AuditAccountDelete         = "account.delete"
+	AuditAccountUpdate         = "account.update"
+	AuditProjectCreate         = "project.create"
+	AuditProjectUpdate         = "project.update"
+	AuditProjectDelete         = "project.delete"
+	AuditProjectStatusChange   = "project.status_change"
+	AuditProjectRulesChange    = "project.investment_rules_change"
+	AuditProjectRestore        = "project.restore"
+	AuditInvestmentCreate      = "investment.create"
+	AuditInvestmentRestore     = "investment.restore"
+	AuditInvestmentDelete      = "investment.delete"
+	AuditInvestmentUpdate      = "investment.update"
+	AuditKYCDecision           = "kyc.decision"
+	AuditScreeningReview       = "screening.review"
+	AuditConfigReload          = "config.reload"
+	AuditConfigLogLevel        = "config.log_level"
+	AuditTargetAccount         = "account"
+	AuditTargetProject         = "project"
+	AuditTargetInvestment      = "investment"
+	AuditTargetConfig          = "config"
+)
+
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type AuditEvent struct {
+	ID         int64                  `db:"id" json:"id"`
+	ActorID    *int64                 `db:"actor_id" json:"actor_id"`
+	Action     string                 `db:"action" json:"action"`
+	TargetType string                 `db:"target_type" json:"target_type"`
+	TargetID   int64                  `db:"target_id" json:"target_id"`
+	Changes    map[string]AuditChange `db:"changes" json:"changes"`
+	IP         string                 `db:"ip" json:"ip"`
+	RequestID  string                 `db:"request_id" json:"request_id"`
+	CreatedAt  time.Time              `db:"created_at" json:"created_at"`
+	PrevHash   string                 `db:"prev_hash" json:"prev_hash"`
+	Hash       string                 `db:"hash" json:"hash"`
+}
+
+// AuditFilter selects audit events. Zero fields do not filter
+type AuditFilter struct {
+	ActorID    *int64
+	Action     string
+	TargetType string
+	TargetID   *int64
+	From       *time.Time
+	To         *time.Time
+	BeforeID   int64
+	Limit      int
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type IdempotencyKey struct {
+	ID                  int64     `db:"id"`
+	UserID              int64     `db:"user_id"`
+	Key                 string    `db:"key"`
+	Fingerprint         string    `db:"fingerprint"`
+	ResponseStatus      *int      `db:"response_status"`
+	ResponseContentType string    `db:"response_content_type"`
+	ResponseBody        []byte    `db:"response_body"`
+	CreatedAt           time.Time `db:"created_at"`
+	ExpiresAt           time.Time `db:"expires_at"`
+	LockedUntil         time.Time `db:"locked_until"`
+}
@@ -11,4 +11,21 @@ type Investment struct {
 	ProjectID  int64           `json:"project_id"`
 	Amount     decimal.Decimal `json:"amount"`
 	InvestedAt *time.Time      `json:"invested_at"`
+	DeletedAt  *time.Time      `json:"deleted_at,omitempty"`
+	Version    int64           `json:"version"`
+}
+
+// InvestmentTotals - суммы действующих инвестиций инвестора, по которым проверяются лимиты
+type InvestmentTotals struct {
+	Total     decimal.Decimal `db:"total"`
+	ThisYear  decimal.Decimal `db:"this_year"`
+	InProject decimal.Decimal `db:"in_project"`
+}
+
+type InvestmentRules struct {
+	AnnualCap        *decimal.Decimal `json:"annual_cap" db:"annual_cap"`
+	MinTicket        *decimal.Decimal `json:"min_ticket" db:"min_ticket"`
+	MaxTicket        *decimal.Decimal `json:"max_ticket" db:"max_ticket"`
+	MaxProjectShare  *decimal.Decimal `json:"max_project_share" db:"max_project_share"`
+	BlockedCountries []string         `json:"blocked_countries" db:"blocked_countries"`
 }
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const (
+	KYCUnverified = "unverified"
+	KYCPending    = "pending"
+	KYCVerified   = "verified"
+	KYCRejected   = "rejected"
+)
+
+const (
+	KYCDocumentPassport       = "passport"
+	KYCDocumentIDCard         = "id_card"
+	KYCDocumentProofOfAddress = "proof_of_address"
+	KYCDocumentSelfie         = "selfie"
+)
+
+// KYCDocumentKinds lists the document kinds accepted for verification
+var KYCDocumentKinds = []string{KYCDocumentPassport, KYCDocumentIDCard, KYCDocumentProofOfAddress, KYCDocumentSelfie}
+
+type KYCApplication struct {
+	ID          int64         `db:"id" json:"id"`
+	UserID      int64         `db:"user_id" json:"user_id"`
+	Status      string        `db:"status" json:"status"`
+	Provider    string        `db:"provider" json:"provider"`
+	ProviderRef string        `db:"provider_ref" json:"provider_ref,omitempty"`
+	Reason      string        `db:"reason" json:"reason,omitempty"`
+	ReviewerID  *int64        `db:"reviewer_id" json:"reviewer_id,omitempty"`
+	SubmittedAt *time.Time    `db:"submitted_at" json:"submitted_at"`
+	ReviewedAt  *time.Time    `db:"reviewed_at" json:"reviewed_at,omitempty"`
+	Documents   []KYCDocument `db:"-" json:"documents,omitempty"`
+}
+
+type KYCDocument struct {
+	ID            int64      `db:"id" json:"id"`
+	UserID        int64      `db:"user_id" json:"user_id"`
+	ApplicationID *int64     `db:"application_id" json:"application_id,omitempty"`
+	Kind          string     `db:"kind" json:"kind"`
+	FileName      string     `db:"file_name" json:"file_name"`
+	ContentType   string     `db:"content_type" json:"content_type"`
+	Size          int64      `db:"size" json:"size"`
+	SHA256        string     `db:"sha256" json:"sha256"`
+	StorageKey    string     `db:"storage_key" json:"-"`
+	UploadedAt    *time.Time `db:"uploaded_at" json:"uploaded_at"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+const (
+	NotificationProjectApproved = "project_approved"
+	NotificationProjectRejected = "project_rejected"
+	NotificationCampaignFailed  = "campaign_failed"
+
+	EmailVerification  = "email_verification"
+	EmailPasswordReset = "password_reset"
+)
+
+type Notification struct {
+	ID        int64      `db:"id" json:"id"`
+	UserID    int64      `db:"user_id" json:"user_id"`
+	Type      string     `db:"type" json:"type"`
+	Title     string     `db:"title" json:"title"`
+	Body      string     `db:"body" json:"body"`
+	ReadAt    *time.Time `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt *time.Time `db:"created_at" json:"created_at"`
+}
+
+type NotificationPreferences struct {
+	UserID       int64      `db:"user_id" json:"user_id"`
+	Language     string     `db:"language" json:"language"`
+	EmailEnabled bool       `db:"email_enabled" json:"email_enabled"`
+	MutedTypes   []string   `db:"muted_types" json:"muted_types"`
+	UpdatedAt    *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+const (
+	ErasurePending   = "pending"
+	ErasureCancelled = "cancelled"
+	ErasureCompleted = "completed"
+)
+
+type ErasureRequest struct {
+	ID          int64      `json:"id" db:"id"`
+	UserID      int64      `json:"user_id" db:"user_id"`
+	Status      string     `json:"status" db:"status"`
+	RequestedAt *time.Time `json:"requested_at" db:"requested_at"`
+	ScheduledAt *time.Time `json:"scheduled_at" db:"scheduled_at"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
@@ -22,4 +22,13 @@ type Project struct {
 	AmountRaised    decimal.Decimal `db:"amount_raised" json:"amount_raised"`
 	DeadlineAt      *time.Time      `db:"deadline_at" json:"deadline_at"`
 	CreatedAt       *time.Time      `db:"created_at" json:"created_at,omitempty"`
+	DeletedAt       *time.Time      `db:"deleted_at" json:"deleted_at,omitempty"`
+	Version         int64           `db:"version" json:"version"`
+}
+
+type ProjectProgress struct {
+	ProjectID    int64           `db:"project_id" json:"project_id"`
+	AmountRaised decimal.Decimal `db:"amount_raised" json:"amount_raised"`
+	Backers      int64           `db:"backers" json:"backers"`
+	Status       string          `db:"status" json:"status"`
 }
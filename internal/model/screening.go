@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+const (
+	ScreeningKindName    = "name"
+	ScreeningKindAddress = "address"
+
+	ScreeningPending   = "pending"
+	ScreeningConfirmed = "confirmed"
+	ScreeningDismissed = "dismissed"
+
+	ScreeningTriggerSignup     = "signup"
+	ScreeningTriggerWalletLink = "wallet_link"
+	ScreeningTriggerInvestment = "investment"
+	ScreeningTriggerRescreen   = "rescreen"
+)
+
+type ScreeningHit struct {
+	ID         int64      `json:"id" db:"id"`
+	UserID     int64      `json:"user_id" db:"user_id"`
+	Kind       string     `json:"kind" db:"kind"`
+	Value      string     `json:"value" db:"value"`
+	ListSource string     `json:"list_source" db:"list_source"`
+	EntryID    string     `json:"entry_id" db:"entry_id"`
+	EntryName  string     `json:"entry_name" db:"entry_name"`
+	Score      float64    `json:"score" db:"score"`
+	Trigger    string     `json:"trigger" db:"trigger"`
+	Status     string     `json:"status" db:"status"`
+	ReviewerID *int64     `json:"reviewer_id,omitempty" db:"reviewer_id"`
+	CreatedAt  *time.Time `json:"created_at" db:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+type ScreeningSubject struct {
+	UserID    int64    `db:"id"`
+	Username  string   `db:"username"`
+	Addresses []string `db:"addresses"`
+}
+
+type ScreeningRun struct {
+	ID          int64      `json:"id" db:"id"`
+	ListVersion string     `json:"list_version" db:"list_version"`
+	Screened    int64      `json:"screened" db:"screened"`
+	Hits        int64      `json:"hits" db:"hits"`
+	StartedAt   *time.Time `json:"started_at" db:"started_at"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+type Session struct {
+	ID         int64      `db:"id" json:"id"`
+	UserID     int64      `db:"user_id" json:"user_id"`
+	Device     string     `db:"device" json:"device"`
+	IP         string     `db:"ip" json:"ip"`
+	UserAgent  string     `db:"user_agent" json:"user_agent"`
+	CreatedAt  *time.Time `db:"created_at" json:"created_at"`
+	LastSeenAt *time.Time `db:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	Current    bool       `db:"-" json:"current"`
+}
+
+// ClientInfo описывает устройство, с которого выполняется вход
+type ClientInfo struct {
+	Device    string
+	IP        string
+	UserAgent string
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type TwoFactor struct {
+	UserID       int64      `db:"user_id" json:"user_id"`
+	Secret       string     `db:"secret" json:"-"`
+	EnabledAt    *time.Time `db:"enabled_at" json:"enabled_at,omitempty"`
+	LastUsedStep int64      `db:"last_used_step" json:"-"`
+	CreatedAt    *time.Time `db:"created_at" json:"created_at,omitempty"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type Wallet struct {
+	ID        int64      `db:"id" json:"id"`
+	UserID    int64      `db:"user_id" json:"user_id"`
+	Address   string     `db:"address" json:"address"`
+	ChainID   int64      `db:"chain_id" json:"chain_id"`
+	CreatedAt *time.Time `db:"created_at" json:"created_at"`
+}
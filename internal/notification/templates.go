@@ -0,0 +1,84 @@
+package notification
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+)
+
+const (
+	LanguageRU = "ru"
+	LanguageEN = "en"
+
+	// DefaultLanguage используется, если у пользователя не задан язык
+	DefaultLanguage = LanguageRU
+)
+
+var ErrUnknownTemplate = errors.New("unknown notification template")
+
+//go:embed templates/*/*.tmpl
+var templatesFS embed.FS
+
+// Templates хранит шаблоны уведомлений, сгруппированные по языку и типу
+type Templates struct {
+	byLang map[string]map[string]*template.Template
+}
+
+// LoadTemplates разбирает встроенные шаблоны уведомлений
+func LoadTemplates() (*Templates, error) {
+	t := &Templates{byLang: make(map[string]map[string]*template.Template)}
+
+	files, err := fs.Glob(templatesFS, "templates/*/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		lang := path.Base(path.Dir(file))
+		kind := strings.TrimSuffix(path.Base(file), ".tmpl")
+
+		tmpl, err := template.ParseFS(templatesFS, file)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка разбора шаблона %s: %w", file, err)
+		}
+
+		if t.byLang[lang] == nil {
+			t.byLang[lang] = make(map[string]*template.Template)
+		}
+		t.byLang[lang][kind] = tmpl
+	}
+
+	return t, nil
+}
+
+// SupportsLanguage сообщает, есть ли шаблоны на указанном языке
+func (t *Templates) SupportsLanguage(lang string) bool {
+	_, ok := t.byLang[lang]
+	return ok
+}
+
+// Render формирует заголовок и текст уведомления. Если шаблона на языке
+// пользователя нет, используется язык по умолчанию
+func (t *Templates) Render(kind string, lang string, data any) (string, string, error) {
+	tmpl, ok := t.byLang[lang][kind]
+	if !ok {
+		tmpl, ok = t.byLang[DefaultLanguage][kind]
+	}
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", ErrUnknownTemplate, kind)
+	}
+
+	var title, body strings.Builder
+	if err := tmpl.ExecuteTemplate(&title, "title", data); err != nil {
+		return "", "", fmt.Errorf("ошибка формирования заголовка уведомления: %w", err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", fmt.Errorf("ошибка формирования текста уведомления: %w", err)
+	}
+
+	return strings.TrimSpace(title.String()), strings.TrimSpace(body.String()), nil
+}
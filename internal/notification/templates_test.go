@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testData = map[string]any{
+	"Username":        "alice",
+	"ProjectName":     "Solar Farm",
+	"AmountRaised":    "150",
+	"AmountRequested": "1000",
+	"Deadline":        "2026-12-31",
+	"Link":            "https://cryptocrowd.local/confirm?token=abc",
+	"ExpiresAt":       "2026-10-19 12:00",
+}
+
+func TestRender(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		kind      string
+		lang      string
+		wantTitle string
+		wantBody  string
+		wantErr   error
+	}{
+		{
+			name:      "english",
+			kind:      "campaign_failed",
+			lang:      LanguageEN,
+			wantTitle: `Campaign "Solar Farm" did not reach its goal`,
+			wantBody:  "150 of 1000 was raised",
+		},
+		{
+			name:      "russian",
+			kind:      "campaign_failed",
+			lang:      LanguageRU,
+			wantTitle: "Сбор средств на «Solar Farm» не состоялся",
+			wantBody:  "Здравствуйте, alice!",
+		},
+		{
+			name:      "unsupported language falls back to russian",
+			kind:      "project_approved",
+			lang:      "de",
+			wantTitle: "Проект «Solar Farm» одобрен",
+		},
+		{
+			name:      "empty language falls back to russian",
+			kind:      "password_reset",
+			lang:      "",
+			wantTitle: "Сброс пароля",
+			wantBody:  "https://cryptocrowd.local/confirm?token=abc",
+		},
+		{
+			name:    "unknown template",
+			kind:    "project_exploded",
+			lang:    LanguageEN,
+			wantErr: ErrUnknownTemplate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, body, err := templates.Render(tt.kind, tt.lang, testData)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Render: error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", title, tt.wantTitle)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body does not contain %q:\n%s", tt.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestTemplatesComplete(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	kinds := []string{"campaign_failed", "email_verification", "password_reset", "project_approved", "project_rejected"}
+	for _, lang := range []string{LanguageRU, LanguageEN} {
+		if !templates.SupportsLanguage(lang) {
+			t.Fatalf("language %q is not supported", lang)
+		}
+		for _, kind := range kinds {
+			// Шаблон должен существовать на каждом языке, иначе пользователь молча получит письмо на русском
+			if _, ok := templates.byLang[lang][kind]; !ok {
+				t.Errorf("template %s is missing for language %q", kind, lang)
+				continue
+			}
+			title, body, err := templates.Render(kind, lang, testData)
+			if err != nil {
+				t.Errorf("Render(%s, %s): %v", kind, lang, err)
+				continue
+			}
+			if title == "" || body == "" {
+				t.Errorf("Render(%s, %s): empty title or body", kind, lang)
+			}
+			if strings.Contains(title+body, "<no value>") {
+				t.Errorf("Render(%s, %s) uses a field missing from the data:\n%s\n%s", kind, lang, title, body)
+			}
+		}
+	}
+
+	if templates.SupportsLanguage("de") {
+		t.Error("language \"de\" must not be supported")
+	}
+}
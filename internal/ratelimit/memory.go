@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	bucket Bucket
+	fullAt time.Time
+}
+
+// MemoryStore хранит корзины в памяти процесса. Подходит для одной реплики
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]memoryEntry),
+	}
+}
+
+// Take берет токен из корзины ключа
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.buckets[key]
+	if !ok {
+		entry.bucket = NewBucket(limit, now)
+	}
+
+	bucket, result := entry.bucket.Take(limit, now)
+	s.buckets[key] = memoryEntry{bucket: bucket, fullAt: bucket.FullAt(limit)}
+
+	return result, nil
+}
+
+// Prune удаляет полностью восполненные корзины
+func (s *MemoryStore) Prune(_ context.Context) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.buckets {
+		if now.After(entry.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Requests: 2, Period: time.Hour}
+
+	tests := []struct {
+		key         string
+		wantAllowed bool
+	}{
+		{key: "ip:10.0.0.1", wantAllowed: true},
+		{key: "ip:10.0.0.1", wantAllowed: true},
+		{key: "ip:10.0.0.1", wantAllowed: false},
+		// Корзины ключей независимы
+		{key: "ip:10.0.0.2", wantAllowed: true},
+	}
+
+	for idx, tt := range tests {
+		result, err := store.Take(ctx, tt.key, limit)
+		if err != nil {
+			t.Fatalf("request %d: Take: %v", idx+1, err)
+		}
+		if result.Allowed != tt.wantAllowed {
+			t.Fatalf("request %d for %s: allowed = %v, want %v", idx+1, tt.key, result.Allowed, tt.wantAllowed)
+		}
+	}
+}
+
+func TestMemoryStoreTakeConcurrent(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 50, Period: time.Hour}
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, _ := store.Take(context.Background(), "account:7", limit); result.Allowed {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != int64(limit.Requests) {
+		t.Fatalf("allowed %d concurrent requests, want %d", got, limit.Requests)
+	}
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	// Корзина с быстрым восполнением становится полной почти сразу, с медленным - нет
+	if _, err := store.Take(ctx, "refilled", Limit{Requests: 1000, Period: time.Millisecond}); err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if _, err := store.Take(ctx, "draining", Limit{Requests: 1, Period: time.Hour}); err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if err := store.Prune(ctx); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if _, ok := store.buckets["refilled"]; ok {
+		t.Fatal("full bucket was not pruned")
+	}
+	if _, ok := store.buckets["draining"]; !ok {
+		t.Fatal("bucket that is not full was pruned")
+	}
+}
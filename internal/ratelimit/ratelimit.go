@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Limit - параметры корзины токенов: емкость Requests, полностью восполняется за Period
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Policy хранит лимит, который можно заменить во время работы. Состояние корзин при этом
+// сохраняется: токены сверх новой емкости отбрасываются при следующем запросе
+type Policy struct {
+	limit atomic.Pointer[Limit]
+}
+
+func NewPolicy(limit Limit) *Policy {
+	p := &Policy{}
+	p.Set(limit)
+	return p
+}
+
+// Limit возвращает действующий лимит
+func (p *Policy) Limit() Limit {
+	return *p.limit.Load()
+}
+
+// Set заменяет лимит для следующих запросов
+func (p *Policy) Set(limit Limit) {
+	p.limit.Store(&limit)
+}
+
+// rate возвращает скорость восполнения токенов в секунду
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result - результат попытки взять токен из корзины
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset - время до полного восполнения корзины
+	Reset time.Duration
+	// RetryAfter - время до появления следующего токена, если запрос отклонен
+	RetryAfter time.Duration
+}
+
+// Bucket - состояние корзины токенов конкретного клиента
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// NewBucket возвращает полную корзину
+func NewBucket(limit Limit, now time.Time) Bucket {
+	return Bucket{Tokens: float64(limit.Requests), UpdatedAt: now}
+}
+
+// Take восполняет корзину за прошедшее время и пытается взять из нее один токен
+func (b Bucket) Take(limit Limit, now time.Time) (Bucket, Result) {
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+
+	elapsed := now.Sub(b.UpdatedAt).Seconds()
+	if elapsed < 0 {
+		// Часы разных реплик могут расходиться; не даем корзине уйти в будущее
+		elapsed = 0
+	}
+	tokens := math.Min(capacity, b.Tokens+elapsed*rate)
+
+	result := Result{Limit: limit.Requests}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / rate)
+
+	return Bucket{Tokens: tokens, UpdatedAt: now}, result
+}
+
+// FullAt возвращает момент, когда корзина восполнится полностью и ее можно забыть
+func (b Bucket) FullAt(limit Limit) time.Time {
+	return b.UpdatedAt.Add(seconds((float64(limit.Requests) - b.Tokens) / limit.rate()))
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// Store хранит корзины токенов. Take должен быть атомарным для одного ключа
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Prune удаляет полностью восполненные корзины, которые не отличаются от новых
+	Prune(ctx context.Context) error
+}
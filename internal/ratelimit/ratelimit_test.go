@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketTake(t *testing.T) {
+	// Емкость 10, восполнение - один токен в секунду
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name   string
+		bucket Bucket
+		now    time.Time
+		want   Result
+		tokens float64
+	}{
+		{
+			name:   "full bucket",
+			bucket: NewBucket(limit, start),
+			now:    start,
+			want:   Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second},
+			tokens: 9,
+		},
+		{
+			name:   "last token",
+			bucket: Bucket{Tokens: 1, UpdatedAt: start},
+			now:    start,
+			want:   Result{Allowed: true, Limit: 10, Remaining: 0, Reset: 10 * time.Second},
+			tokens: 0,
+		},
+		{
+			name:   "empty bucket",
+			bucket: Bucket{Tokens: 0, UpdatedAt: start},
+			now:    start,
+			want:   Result{Limit: 10, Remaining: 0, Reset: 10 * time.Second, RetryAfter: time.Second},
+			tokens: 0,
+		},
+		{
+			name:   "partly refilled, still rejected",
+			bucket: Bucket{Tokens: 0, UpdatedAt: start},
+			now:    start.Add(750 * time.Millisecond),
+			want:   Result{Limit: 10, Remaining: 0, Reset: 9250 * time.Millisecond, RetryAfter: 250 * time.Millisecond},
+			tokens: 0.75,
+		},
+		{
+			name:   "refilled over time",
+			bucket: Bucket{Tokens: 0, UpdatedAt: start},
+			now:    start.Add(3 * time.Second),
+			want:   Result{Allowed: true, Limit: 10, Remaining: 2, Reset: 8 * time.Second},
+			tokens: 2,
+		},
+		{
+			name:   "refill is capped at capacity",
+			bucket: Bucket{Tokens: 5, UpdatedAt: start},
+			now:    start.Add(time.Hour),
+			want:   Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second},
+			tokens: 9,
+		},
+		{
+			name:   "clock behind the bucket",
+			bucket: Bucket{Tokens: 0, UpdatedAt: start},
+			now:    start.Add(-time.Minute),
+			want:   Result{Limit: 10, Remaining: 0, Reset: 10 * time.Second, RetryAfter: time.Second},
+			tokens: 0,
+		},
+		{
+			name:   "tokens above a lowered capacity are dropped",
+			bucket: Bucket{Tokens: 20, UpdatedAt: start},
+			now:    start,
+			want:   Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second},
+			tokens: 9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, got := tt.bucket.Take(limit, tt.now)
+			if got != tt.want {
+				t.Fatalf("Take() result = %+v, want %+v", got, tt.want)
+			}
+			if bucket.Tokens != tt.tokens || !bucket.UpdatedAt.Equal(tt.now) {
+				t.Fatalf("Take() bucket = %+v, want %v tokens at %v", bucket, tt.tokens, tt.now)
+			}
+		})
+	}
+}
+
+func TestBucketDrainsAndRefills(t *testing.T) {
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	now := time.Unix(1700000000, 0)
+	bucket := NewBucket(limit, now)
+
+	var result Result
+	for idx := range limit.Requests {
+		if bucket, result = bucket.Take(limit, now); !result.Allowed {
+			t.Fatalf("request %d rejected within the limit", idx+1)
+		}
+	}
+	if bucket, result = bucket.Take(limit, now); result.Allowed {
+		t.Fatal("request over the limit allowed")
+	}
+
+	now = now.Add(result.RetryAfter)
+	if _, result = bucket.Take(limit, now); !result.Allowed {
+		t.Fatalf("request after RetryAfter rejected: %+v", result)
+	}
+}
+
+func TestBucketFullAt(t *testing.T) {
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+	start := time.Unix(1700000000, 0)
+
+	if got := (Bucket{Tokens: 4, UpdatedAt: start}).FullAt(limit); !got.Equal(start.Add(6 * time.Second)) {
+		t.Fatalf("FullAt() = %v, want %v", got, start.Add(6*time.Second))
+	}
+	if got := NewBucket(limit, start).FullAt(limit); !got.Equal(start) {
+		t.Fatalf("FullAt() of a full bucket = %v, want %v", got, start)
+	}
+}
+
+func TestPolicySet(t *testing.T) {
+	policy := NewPolicy(Limit{Requests: 10, Period: time.Minute})
+	policy.Set(Limit{Requests: 5, Period: time.Second})
+
+	if got := policy.Limit(); got != (Limit{Requests: 5, Period: time.Second}) {
+		t.Fatalf("Limit() = %+v after Set", got)
+	}
+}
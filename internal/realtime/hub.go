@@ -0,0 +1,141 @@
+package realtime
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+var (
+	// ErrTooManyConnections возвращается, когда достигнут общий лимит подписчиков
+	ErrTooManyConnections = errors.New("слишком много подключений")
+	// ErrTooManyProjectConnections возвращается, когда достигнут лимит подписчиков одного проекта
+	ErrTooManyProjectConnections = errors.New("слишком много подключений к проекту")
+)
+
+// Subscription - подписка клиента на обновления хода сбора средств по проекту.
+// Канал Updates хранит только последний снимок: если клиент не успевает читать,
+// устаревшие снимки вытесняются новыми, и медленный клиент не блокирует рассылку
+type Subscription struct {
+	projectID int64
+	updates   chan model.ProjectProgress
+	hub       *Hub
+	once      sync.Once
+}
+
+// Updates возвращает канал обновлений. Канал закрывается при отписке
+func (s *Subscription) Updates() <-chan model.ProjectProgress {
+	return s.updates
+}
+
+// Close отменяет подписку и освобождает слот подключения
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.remove(s)
+	})
+}
+
+// offer кладет снимок в канал, вытесняя непрочитанный. Вызывается под блокировкой хаба
+func (s *Subscription) offer(progress model.ProjectProgress) {
+	select {
+	case s.updates <- progress:
+		return
+	default:
+	}
+
+	select {
+	case <-s.updates:
+	default:
+	}
+	s.updates <- progress
+}
+
+// Hub рассылает обновления подписчикам в пределах одного процесса
+type Hub struct {
+	mu            sync.Mutex
+	subscribers   map[int64]map[*Subscription]struct{}
+	total         int
+	maxTotal      int
+	maxPerProject int
+}
+
+// NewHub создает хаб с ограничениями на число подключений. Нулевой лимит означает отсутствие ограничения
+func NewHub(maxTotal int, maxPerProject int) *Hub {
+	return &Hub{
+		subscribers:   make(map[int64]map[*Subscription]struct{}),
+		maxTotal:      maxTotal,
+		maxPerProject: maxPerProject,
+	}
+}
+
+// Subscribe регистрирует нового подписчика проекта
+func (h *Hub) Subscribe(projectID int64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.maxTotal > 0 && h.total >= h.maxTotal {
+		return nil, ErrTooManyConnections
+	}
+	if h.maxPerProject > 0 && len(h.subscribers[projectID]) >= h.maxPerProject {
+		return nil, ErrTooManyProjectConnections
+	}
+
+	sub := &Subscription{
+		projectID: projectID,
+		updates:   make(chan model.ProjectProgress, 1),
+		hub:       h,
+	}
+
+	if h.subscribers[projectID] == nil {
+		h.subscribers[projectID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[projectID][sub] = struct{}{}
+	h.total++
+
+	return sub, nil
+}
+
+// Publish рассылает снимок всем подписчикам проекта
+func (h *Hub) Publish(progress model.ProjectProgress) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[progress.ProjectID] {
+		sub.offer(progress)
+	}
+}
+
+// Close отключает всех подписчиков
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for projectID, subs := range h.subscribers {
+		for sub := range subs {
+			sub.once.Do(func() { close(sub.updates) })
+		}
+		delete(h.subscribers, projectID)
+	}
+	h.total = 0
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.subscribers[sub.projectID]
+	if !ok {
+		return
+	}
+	if _, ok = subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.projectID)
+	}
+	h.total--
+	close(sub.updates)
+}
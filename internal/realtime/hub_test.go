@@ -0,0 +1,154 @@
+package realtime
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+func progress(projectID int64, backers int64) model.ProjectProgress {
+	return model.ProjectProgress{ProjectID: projectID, AmountRaised: decimal.NewFromInt(backers * 100), Backers: backers, Status: "approved"}
+}
+
+func TestHubSubscribeLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxTotal      int
+		maxPerProject int
+		projects      []int64
+		wantErr       []error
+	}{
+		{name: "no limits", projects: []int64{1, 1, 1, 2}, wantErr: []error{nil, nil, nil, nil}},
+		{name: "total limit", maxTotal: 2, projects: []int64{1, 2, 3}, wantErr: []error{nil, nil, ErrTooManyConnections}},
+		{name: "project limit", maxPerProject: 1, projects: []int64{1, 2, 1}, wantErr: []error{nil, nil, ErrTooManyProjectConnections}},
+		{name: "total limit checked first", maxTotal: 1, maxPerProject: 1, projects: []int64{1, 1}, wantErr: []error{nil, ErrTooManyConnections}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(tt.maxTotal, tt.maxPerProject)
+			defer hub.Close()
+
+			for idx, projectID := range tt.projects {
+				_, err := hub.Subscribe(projectID)
+				if !errors.Is(err, tt.wantErr[idx]) {
+					t.Fatalf("Subscribe #%d to project %d: error = %v, want %v", idx, projectID, err, tt.wantErr[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestHubCloseReleasesSlot(t *testing.T) {
+	hub := NewHub(1, 0)
+	defer hub.Close()
+
+	sub, err := hub.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err = hub.Subscribe(2); !errors.Is(err, ErrTooManyConnections) {
+		t.Fatalf("Subscribe over the limit: error = %v, want %v", err, ErrTooManyConnections)
+	}
+
+	sub.Close()
+	// Повторная отписка не должна освобождать чужой слот или закрывать канал второй раз
+	sub.Close()
+	if _, ok := <-sub.Updates(); ok {
+		t.Fatal("Updates is not closed after Close")
+	}
+
+	if _, err = hub.Subscribe(2); err != nil {
+		t.Fatalf("Subscribe after Close: %v", err)
+	}
+	if _, err = hub.Subscribe(3); !errors.Is(err, ErrTooManyConnections) {
+		t.Fatalf("double Close released an extra slot: error = %v", err)
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub(0, 0)
+	defer hub.Close()
+
+	first, _ := hub.Subscribe(1)
+	second, _ := hub.Subscribe(1)
+	other, _ := hub.Subscribe(2)
+
+	hub.Publish(progress(1, 1))
+	for _, sub := range []*Subscription{first, second} {
+		if got := <-sub.Updates(); got.Backers != 1 {
+			t.Fatalf("received %+v, want the published snapshot", got)
+		}
+	}
+	select {
+	case got := <-other.Updates():
+		t.Fatalf("subscriber of another project received %+v", got)
+	default:
+	}
+
+	// Непрочитанный снимок вытесняется более новым, а Publish не блокируется
+	for backers := int64(2); backers <= 5; backers++ {
+		hub.Publish(progress(1, backers))
+	}
+	if got := <-first.Updates(); got.Backers != 5 {
+		t.Fatalf("slow subscriber received %+v, want the latest snapshot", got)
+	}
+	select {
+	case got := <-first.Updates():
+		t.Fatalf("stale snapshot %+v was kept", got)
+	default:
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub(0, 0)
+	subs := make([]*Subscription, 3)
+	for idx := range subs {
+		subs[idx], _ = hub.Subscribe(int64(idx % 2))
+	}
+
+	hub.Close()
+	for idx, sub := range subs {
+		if _, ok := <-sub.Updates(); ok {
+			t.Fatalf("Updates of subscriber %d is not closed", idx)
+		}
+		// Отписка после закрытия хаба не должна паниковать
+		sub.Close()
+	}
+	// Публикация в закрытый хаб ничего не делает
+	hub.Publish(progress(0, 1))
+
+	if _, err := hub.Subscribe(1); err != nil {
+		t.Fatalf("Subscribe after hub Close: %v", err)
+	}
+}
+
+func TestHubConcurrentPublishAndClose(t *testing.T) {
+	hub := NewHub(0, 0)
+	defer hub.Close()
+
+	var wg sync.WaitGroup
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range 100 {
+				sub, err := hub.Subscribe(int64(worker % 2))
+				if err != nil {
+					t.Errorf("Subscribe: %v", err)
+					return
+				}
+				hub.Publish(progress(int64(worker%2), int64(idx)))
+				sub.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hub.total != 0 || len(hub.subscribers) != 0 {
+		t.Fatalf("%d subscribers in %d projects left after all closed", hub.total, len(hub.subscribers))
+	}
+}
@@ -0,0 +1,72 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// ProgressChannel - канал PostgreSQL, в который триггер projects_progress_notify
+// публикует изменения собранной суммы и статуса проектов
+const ProgressChannel = "project_progress"
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Listen подписывается на ProgressChannel и передает полученные снимки в хаб.
+// Уведомления рассылаются всем репликам, поэтому каждый процесс видит инвестиции,
+// зафиксированные любым другим. При обрыве соединения подписка восстанавливается
+func Listen(ctx context.Context, pool *db.Pool, hub *Hub) {
+	delay := minReconnectDelay
+
+	for {
+		err := listen(ctx, pool, hub)
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.Errorf("ошибка прослушивания канала %s: %v, повтор через %s", ProgressChannel, err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func listen(ctx context.Context, pool *db.Pool, hub *Hub) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка получения соединения: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+ProgressChannel); err != nil {
+		return fmt.Errorf("ошибка подписки на канал: %w", err)
+	}
+	logger.Debugf("Подписка на канал %s установлена", ProgressChannel)
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			// Соединение в неизвестном состоянии, не возвращаем его в пул
+			conn.Conn().Close(context.Background())
+			return err
+		}
+
+		var progress model.ProjectProgress
+		if err = json.Unmarshal([]byte(notification.Payload), &progress); err != nil {
+			logger.Warnf("некорректное уведомление в канале %s: %v", ProgressChannel, err)
+			continue
+		}
+		hub.Publish(progress)
+	}
+}
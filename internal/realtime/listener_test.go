@@ -0,0 +1,70 @@
+package realtime
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+)
+
+// testDatabaseEnv - переменная окружения со строкой подключения к тестовой БД.
+// Без нее тест слушателя пропускается
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+func TestListen(t *testing.T) {
+	dbURL := os.Getenv(testDatabaseEnv)
+	if dbURL == "" {
+		t.Skipf("%s не задана, тест с БД пропущен", testDatabaseEnv)
+	}
+
+	pool, err := db.NewPool(context.Background(), dbURL, db.PoolConfig{})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	defer pool.Close()
+
+	hub := NewHub(0, 0)
+	defer hub.Close()
+	sub, err := hub.Subscribe(42)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Listen(ctx, pool, hub)
+		close(done)
+	}()
+
+	// LISTEN выполняется асинхронно, поэтому уведомления повторяются, пока одно не дойдет.
+	// Некорректное уведомление пропускается и не обрывает подписку
+	deadline := time.After(10 * time.Second)
+	for received := false; !received; {
+		for _, payload := range []string{"not json", `{"project_id":42,"amount_raised":"150.5","backers":3,"status":"approved"}`} {
+			if _, err = pool.Exec(context.Background(), "SELECT pg_notify($1, $2)", ProgressChannel, payload); err != nil {
+				t.Fatalf("pg_notify: %v", err)
+			}
+		}
+
+		select {
+		case got := <-sub.Updates():
+			if got.ProjectID != 42 || got.Backers != 3 || got.AmountRaised.String() != "150.5" || got.Status != "approved" {
+				t.Fatalf("received %+v", got)
+			}
+			received = true
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no progress update received")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not stop after the context was cancelled")
+	}
+}
@@ -19,6 +19,8 @@ var (
 	ErrUserAlreadyExists = errors.New("пользователь с таким email уже существует")
 	// ErrTransactionStartError определяет ошибку, которая возникает при ошибке начала транзакции
 	ErrTransactionStartError = errors.New("ошибка начала транзакции")
+	// ErrInvalidCredentials определяет ошибку, которая возникает при неверной паре email и пароль
+	ErrInvalidCredentials = errors.New("неверный email или пароль")
 )
 
 type PostgresAccount struct {
@@ -80,7 +82,7 @@ func (r *PostgresAccount) UpdatePassword(ctx context.Context, id int64, newPassw
 	defer tx.Rollback(ctx)
 
 	var exists bool
-	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 FOR UPDATE)", id).Scan(&exists)
+	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE)", id).Scan(&exists)
 	if err != nil {
 		return fmt.Errorf("ошибка проверки существования пользователя: %w", err)
 	}
@@ -99,7 +101,7 @@ func (r *PostgresAccount) UpdatePassword(ctx context.Context, id int64, newPassw
 	commandTag, err := tx.Exec(ctx, `
         UPDATE users
         SET password_hash = $2, updated_at = $3
-        WHERE id = $1`,
+        WHERE id = $1 AND deleted_at IS NULL`,
 		id,
 		passwordHash,
 		now,
@@ -115,24 +117,20 @@ func (r *PostgresAccount) UpdatePassword(ctx context.Context, id int64, newPassw
 	return tx.Commit(ctx)
 }
 
-// Delete удаляет пользователя по ID
-func (r *PostgresAccount) Delete(ctx context.Context, id int64) error {
+// Delete помечает пользователя удаленным, если его версия совпадает с version.
+// Данные сохраняются до окончательного удаления в PurgeDeleted
+func (r *PostgresAccount) Delete(ctx context.Context, id int64, version int64) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrTransactionStartError, err)
 	}
 	defer tx.Rollback(ctx)
 
-	var exists bool
-	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 FOR UPDATE)", id).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("ошибка проверки существования пользователя: %w", err)
-	}
-	if !exists {
-		return ErrUserNotFound
+	if err = lockVersion(ctx, tx, "users", id, version, ErrUserNotFound); err != nil {
+		return err
 	}
 
-	commandTag, err := tx.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	commandTag, err := tx.Exec(ctx, "UPDATE users SET deleted_at = $2, version = version + 1 WHERE id = $1", id, time.Now())
 	if err != nil {
 		return fmt.Errorf("ошибка удаления пользователя: %w", err)
 	}
@@ -148,7 +146,7 @@ func (r *PostgresAccount) Delete(ctx context.Context, id int64) error {
 func (r *PostgresAccount) GetByEmailAndRole(ctx context.Context, email string, role string) (model.Account, error) {
 	var user model.Account
 	err := pgxscan.Get(ctx, r.pool, &user,
-		`SELECT id, username, email, role, created_at, updated_at FROM users WHERE email = $1 and role = $2`,
+		`SELECT id, username, email, role, email_verified_at, kyc_status, country, created_at, updated_at, version FROM users WHERE email = $1 and role = $2 AND deleted_at IS NULL`,
 		email,
 		role,
 	)
@@ -163,10 +161,127 @@ func (r *PostgresAccount) GetByEmailAndRole(ctx context.Context, email string, r
 	return user, nil
 }
 
+// GetByID получает пользователя по ID
+func (r *PostgresAccount) GetByID(ctx context.Context, id int64) (model.Account, error) {
+	var user model.Account
+	err := pgxscan.Get(ctx, r.pool.Reader(ctx), &user,
+		`SELECT id, username, email, role, email_verified_at, kyc_status, country, created_at, updated_at, version FROM users WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Account{}, ErrUserNotFound
+		}
+		return model.Account{}, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	return user, nil
+}
+
+// MarkEmailVerified отмечает email пользователя подтвержденным
+func (r *PostgresAccount) MarkEmailVerified(ctx context.Context, id int64) error {
+	commandTag, err := r.pool.Exec(ctx,
+		"UPDATE users SET email_verified_at = $2, updated_at = $2 WHERE id = $1 AND email_verified_at IS NULL AND deleted_at IS NULL",
+		id, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка подтверждения email: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		var exists bool
+		err = r.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("ошибка проверки существования пользователя: %w", err)
+		}
+		if !exists {
+			return ErrUserNotFound
+		}
+	}
+
+	return nil
+}
+
+// UpdateUsername сохраняет имя пользователя, если его версия совпадает с version
+func (r *PostgresAccount) UpdateUsername(ctx context.Context, id int64, username string, version int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockVersion(ctx, tx, "users", id, version, ErrUserNotFound); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE users SET username = $2, updated_at = $3, version = version + 1 WHERE id = $1", id, username, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка обновления имени пользователя: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UpdateCountry сохраняет страну проживания пользователя, если его версия совпадает с version
+func (r *PostgresAccount) UpdateCountry(ctx context.Context, id int64, country string, version int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockVersion(ctx, tx, "users", id, version, ErrUserNotFound); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE users SET country = $2, updated_at = $3, version = version + 1 WHERE id = $1", id, country, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка обновления страны пользователя: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UpdateEmail заменяет email пользователя, если его версия совпадает с version.
+// Новый email считается неподтвержденным
+func (r *PostgresAccount) UpdateEmail(ctx context.Context, id int64, email string, version int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockVersion(ctx, tx, "users", id, version, ErrUserNotFound); err != nil {
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND role = (SELECT role FROM users WHERE id = $2) AND id <> $2)",
+		email, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки существования пользователя: %w", err)
+	}
+	if exists {
+		return ErrUserAlreadyExists
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE users SET email = $2, email_verified_at = NULL, updated_at = $3, version = version + 1 WHERE id = $1",
+		id, email, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка обновления email пользователя: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
 // List возвращает список всех пользователей
 func (r *PostgresAccount) List(ctx context.Context, searchTerm string) ([]model.Account, error) {
 	var users []model.Account
-	query := "SELECT id, username, email, role, created_at, updated_at FROM users WHERE 1=1"
+	query := "SELECT id, username, email, role, email_verified_at, kyc_status, country, created_at, updated_at, version FROM users WHERE deleted_at IS NULL"
 	var args []any
 
 	if searchTerm != "" {
@@ -178,9 +293,9 @@ func (r *PostgresAccount) List(ctx context.Context, searchTerm string) ([]model.
 
 	var err error
 	if len(args) > 0 {
-		err = pgxscan.Select(ctx, r.pool, &users, query, args...)
+		err = pgxscan.Select(ctx, r.pool.Reader(ctx), &users, query, args...)
 	} else {
-		err = pgxscan.Select(ctx, r.pool, &users, query)
+		err = pgxscan.Select(ctx, r.pool.Reader(ctx), &users, query)
 	}
 
 	if err != nil {
@@ -190,4 +305,89 @@ func (r *PostgresAccount) List(ctx context.Context, searchTerm string) ([]model.
 	return users, nil
 }
 
-// TODO: Add check password function
+// CheckPassword проверяет текущий пароль пользователя
+func (r *PostgresAccount) CheckPassword(ctx context.Context, id int64, password string) error {
+	var passwordHash string
+	err := r.pool.QueryRow(ctx, "SELECT password_hash FROM users WHERE id = $1 AND deleted_at IS NULL", id).Scan(&passwordHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	if !checkPassword(passwordHash, password) {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// Authenticate проверяет пароль пользователя с указанными email и ролью
+func (r *PostgresAccount) Authenticate(ctx context.Context, email string, role string, password string) (model.Account, error) {
+	var user model.Account
+	err := pgxscan.Get(ctx, r.pool, &user,
+		`SELECT id, username, email, password_hash, role, email_verified_at, kyc_status, country, created_at, updated_at, version FROM users WHERE email = $1 and role = $2 AND deleted_at IS NULL`,
+		email,
+		role,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Account{}, ErrInvalidCredentials
+		}
+		return model.Account{}, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	if !checkPassword(user.PasswordHash, password) {
+		return model.Account{}, ErrInvalidCredentials
+	}
+
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// ListDeleted возвращает пользователей, помеченных удаленными
+func (r *PostgresAccount) ListDeleted(ctx context.Context) ([]model.Account, error) {
+	var users []model.Account
+	err := pgxscan.Select(ctx, r.pool, &users, `
+        SELECT id, username, email, role, email_verified_at, kyc_status, country, created_at, updated_at, deleted_at, version
+        FROM users WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка удаленных пользователей: %w", err)
+	}
+	return users, nil
+}
+
+// Restore снимает с пользователя пометку об удалении
+func (r *PostgresAccount) Restore(ctx context.Context, id int64) (model.Account, error) {
+	var user model.Account
+	err := pgxscan.Get(ctx, r.pool, &user, `
+        UPDATE users SET deleted_at = NULL, updated_at = $2, version = version + 1
+        WHERE id = $1 AND deleted_at IS NOT NULL
+        RETURNING id, username, email, role, email_verified_at, kyc_status, country, created_at, updated_at, version`,
+		id, time.Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Account{}, ErrUserNotFound
+		}
+		return model.Account{}, fmt.Errorf("ошибка восстановления пользователя: %w", err)
+	}
+	return user, nil
+}
+
+// PurgeDeleted окончательно удаляет пользователей, помеченных удаленными до before.
+// Пользователи, у которых есть проекты, инвестиции, заявки KYC или результаты проверок
+// по санкционным спискам, сохраняются для финансовой и регуляторной отчетности
+func (r *PostgresAccount) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	commandTag, err := r.pool.Exec(ctx, `
+        DELETE FROM users u
+        WHERE u.deleted_at < $1
+          AND NOT EXISTS (SELECT 1 FROM projects WHERE owner_id = u.id)
+          AND NOT EXISTS (SELECT 1 FROM investments WHERE user_id = u.id)
+          AND NOT EXISTS (SELECT 1 FROM kyc_applications WHERE user_id = u.id)
+          AND NOT EXISTS (SELECT 1 FROM screening_hits WHERE user_id = u.id)`,
+		before)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка окончательного удаления пользователей: %w", err)
+	}
+	return commandTag.RowsAffected(), nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrTokenInvalid определяет ошибку, которая возникает, когда токен не найден, истек или уже использован
+	ErrTokenInvalid = errors.New("токен недействителен или истек")
+)
+
+type PostgresAccountToken struct {
+	pool *db.Pool
+}
+
+func NewPostgresAccountToken(pool *db.Pool) *PostgresAccountToken {
+	return &PostgresAccountToken{
+		pool: pool,
+	}
+}
+
+// Replace сохраняет новый токен и удаляет неиспользованные токены пользователя с тем же назначением,
+// чтобы действительной оставалась только последняя ссылка
+func (r *PostgresAccountToken) Replace(ctx context.Context, token model.AccountToken) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		"DELETE FROM account_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL",
+		token.UserID, token.Purpose)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления старых токенов: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO account_tokens (user_id, purpose, token_hash, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5)`,
+		token.UserID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка создания токена: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Consume атомарно помечает токен использованным и возвращает ID его владельца
+func (r *PostgresAccountToken) Consume(ctx context.Context, tokenHash string, purpose string) (int64, error) {
+	now := time.Now()
+
+	var userID int64
+	err := r.pool.QueryRow(ctx, `
+        UPDATE account_tokens
+        SET used_at = $3
+        WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+        RETURNING user_id`,
+		tokenHash,
+		purpose,
+		now,
+	).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrTokenInvalid
+		}
+		return 0, fmt.Errorf("ошибка использования токена: %w", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrAPIKeyNotFound определяет ошибку, которая возникает, когда ключ API не найден, отозван или истек
+	ErrAPIKeyNotFound = errors.New("ключ API не найден")
+)
+
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, last_used_ip, revoked_at, created_at"
+
+type PostgresAPIKey struct {
+	pool *db.Pool
+}
+
+func NewPostgresAPIKey(pool *db.Pool) *PostgresAPIKey {
+	return &PostgresAPIKey{
+		pool: pool,
+	}
+}
+
+// Create сохраняет новый ключ API и возвращает его с заполненными ID и временем создания
+func (r *PostgresAPIKey) Create(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	err := r.pool.QueryRow(ctx, `
+        INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at`,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		key.ExpiresAt,
+		time.Now(),
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("ошибка создания ключа API: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetActiveByHash возвращает действующий ключ API по хешу
+func (r *PostgresAPIKey) GetActiveByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	var key model.APIKey
+	err := pgxscan.Get(ctx, r.pool, &key,
+		`SELECT `+apiKeyColumns+` FROM api_keys
+        WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)`,
+		keyHash, time.Now(),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.APIKey{}, ErrAPIKeyNotFound
+		}
+		return model.APIKey{}, fmt.Errorf("ошибка получения ключа API: %w", err)
+	}
+	return key, nil
+}
+
+// Touch обновляет время и IP-адрес последнего использования ключа
+func (r *PostgresAPIKey) Touch(ctx context.Context, id int64, ip string) error {
+	_, err := r.pool.Exec(ctx,
+		"UPDATE api_keys SET last_used_at = $2, last_used_ip = $3 WHERE id = $1",
+		id, time.Now(), ip)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления ключа API: %w", err)
+	}
+	return nil
+}
+
+// ListByUserID возвращает неотозванные ключи API пользователя, включая истекшие
+func (r *PostgresAPIKey) ListByUserID(ctx context.Context, userID int64) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := pgxscan.Select(ctx, r.pool, &keys,
+		`SELECT `+apiKeyColumns+` FROM api_keys
+        WHERE user_id = $1 AND revoked_at IS NULL
+        ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка ключей API: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke отзывает ключ API пользователя
+func (r *PostgresAPIKey) Revoke(ctx context.Context, id int64, userID int64) error {
+	commandTag, err := r.pool.Exec(ctx,
+		"UPDATE api_keys SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		id, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва ключа API: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
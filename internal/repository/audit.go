@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CryptoCrowd/internal/audit"
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+const auditEventColumns = "id, actor_id, action, target_type, target_id, changes, ip, request_id, created_at, prev_hash, hash"
+
+type PostgresAudit struct {
+	pool *db.Pool
+}
+
+func NewPostgresAudit(pool *db.Pool) *PostgresAudit {
+	return &PostgresAudit{
+		pool: pool,
+	}
+}
+
+// Append добавляет событие в конец цепочки. Запись сериализуется блокировкой таблицы,
+// чтобы каждое событие ссылалось на хеш действительно последнего события
+func (r *PostgresAudit) Append(ctx context.Context, event model.AuditEvent) (model.AuditEvent, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return model.AuditEvent{}, fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "LOCK TABLE audit_events IN EXCLUSIVE MODE"); err != nil {
+		return model.AuditEvent{}, fmt.Errorf("ошибка блокировки журнала аудита: %w", err)
+	}
+
+	var prevHash []string
+	err = pgxscan.Select(ctx, tx, &prevHash, "SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1")
+	if err != nil {
+		return model.AuditEvent{}, fmt.Errorf("ошибка получения последнего события аудита: %w", err)
+	}
+	event.PrevHash = ""
+	if len(prevHash) > 0 {
+		event.PrevHash = prevHash[0]
+	}
+
+	// PostgreSQL хранит время с точностью до микросекунд; хешируем то же значение, что сохраним
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if event.Hash, err = audit.Hash(event); err != nil {
+		return model.AuditEvent{}, fmt.Errorf("ошибка вычисления хеша события аудита: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+        INSERT INTO audit_events (actor_id, action, target_type, target_id, changes, ip, request_id, created_at, prev_hash, hash)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id`,
+		event.ActorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.Changes,
+		event.IP,
+		event.RequestID,
+		event.CreatedAt,
+		event.PrevHash,
+		event.Hash,
+	).Scan(&event.ID)
+	if err != nil {
+		return model.AuditEvent{}, fmt.Errorf("ошибка записи события аудита: %w", err)
+	}
+
+	return event, tx.Commit(ctx)
+}
+
+// List возвращает события по фильтру, начиная с последних
+func (r *PostgresAudit) List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.ActorID != nil {
+		add("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		add("target_id = ?", *filter.TargetID)
+	}
+	if filter.From != nil {
+		add("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < ?", *filter.To)
+	}
+	if filter.BeforeID > 0 {
+		add("id < ?", filter.BeforeID)
+	}
+
+	query := `SELECT ` + auditEventColumns + ` FROM audit_events`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += " ORDER BY id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	var events []model.AuditEvent
+	if err := pgxscan.Select(ctx, r.pool, &events, query, args...); err != nil {
+		return nil, fmt.Errorf("ошибка получения событий аудита: %w", err)
+	}
+	return events, nil
+}
+
+// ListAfter возвращает до limit событий с ID больше afterID в порядке цепочки
+func (r *PostgresAudit) ListAfter(ctx context.Context, afterID int64, limit int) ([]model.AuditEvent, error) {
+	var events []model.AuditEvent
+	err := pgxscan.Select(ctx, r.pool, &events,
+		`SELECT `+auditEventColumns+` FROM audit_events WHERE id > $1 ORDER BY id LIMIT $2`,
+		afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения событий аудита: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+func TestAuditEventCommittedWithChange(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	accounts := NewPostgresAccount(pool)
+	audit := NewPostgresAudit(pool)
+	errAudit := errors.New("audit failed")
+
+	tests := []struct {
+		name       string
+		auditErr   error
+		wantStored bool
+	}{
+		{name: "audit event recorded", wantStored: true},
+		{name: "audit event failed", auditErr: errAudit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := fmt.Sprintf("audit-%d@example.com", time.Now().UnixNano())
+			var eventID int64
+
+			err := pool.InTx(ctx, func(ctx context.Context) error {
+				if err := accounts.Create(ctx, model.Account{Username: "test", Email: email, Role: "investor"}, "Secret-password-1"); err != nil {
+					return err
+				}
+				if tt.auditErr != nil {
+					return tt.auditErr
+				}
+				event, err := audit.Append(ctx, model.AuditEvent{Action: model.AuditAccountCreate, TargetType: model.AuditTargetAccount})
+				eventID = event.ID
+				return err
+			})
+			if !errors.Is(err, tt.auditErr) {
+				t.Fatalf("InTx: error = %v, want %v", err, tt.auditErr)
+			}
+
+			_, err = accounts.GetByEmailAndRole(ctx, email, "investor")
+			if stored := err == nil; stored != tt.wantStored {
+				t.Fatalf("account stored = %v, want %v (error %v)", stored, tt.wantStored, err)
+			}
+			if !tt.wantStored {
+				return
+			}
+
+			events, err := audit.ListAfter(ctx, eventID-1, 1)
+			if err != nil {
+				t.Fatalf("ListAfter: %v", err)
+			}
+			if len(events) != 1 || events[0].ID != eventID {
+				t.Fatalf("audit event %d was not committed with the change", eventID)
+			}
+		})
+	}
+}
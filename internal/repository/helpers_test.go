@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/migrate"
+	"github.com/shopspring/decimal"
+)
+
+// testDatabaseEnv - переменная окружения со строкой подключения к тестовой БД.
+// Без нее тесты репозиториев пропускаются
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+// testPool применяет миграции к тестовой БД и возвращает пул, который закрывается после теста
+func testPool(t *testing.T) *db.Pool {
+	t.Helper()
+
+	dbURL := os.Getenv(testDatabaseEnv)
+	if dbURL == "" {
+		t.Skipf("%s не задана, тест с БД пропущен", testDatabaseEnv)
+	}
+
+	if err := migrate.Run(dbURL, "up"); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := db.NewPool(context.Background(), dbURL, db.PoolConfig{})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// createTestAccount создает пользователя с уникальным email
+func createTestAccount(t *testing.T, repo *PostgresAccount, role string) model.Account {
+	t.Helper()
+	ctx := context.Background()
+
+	email := fmt.Sprintf("test-%d@example.com", time.Now().UnixNano())
+	err := repo.Create(ctx, model.Account{Username: "test", Email: email, Role: role}, "Secret-password-1")
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	acc, err := repo.GetByEmailAndRole(ctx, email, role)
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	return acc
+}
+
+// createTestProject создает одобренный проект, принимающий инвестиции
+func createTestProject(t *testing.T, repo *PostgresProject, ownerID int64) model.Project {
+	t.Helper()
+	ctx := context.Background()
+
+	deadline := time.Now().Add(24 * time.Hour)
+	id, err := repo.Create(ctx, model.Project{
+		OwnerID:         ownerID,
+		Status:          "approved",
+		Name:            "test project",
+		Description:     "test project",
+		AmountRequested: decimal.NewFromInt(10000),
+		DeadlineAt:      &deadline,
+	})
+	if err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	project, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	return project
+}
+
+// allowInvestment - ограничение, пропускающее любую инвестицию
+var allowInvestment = InvestmentGuard{Check: func(model.InvestmentTotals) error { return nil }}
+
+// containsID сообщает, есть ли в списке запись с указанным ID
+func containsID[T any](items []T, id int64, idOf func(T) int64) bool {
+	for _, item := range items {
+		if idOf(item) == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrIdempotencyKeyExists определяет ошибку, которая возникает, когда ключ идемпотентности уже используется
+	ErrIdempotencyKeyExists = errors.New("ключ идемпотентности уже используется")
+	// ErrIdempotencyKeyNotFound определяет ошибку, которая возникает, когда ключ идемпотентности не найден
+	ErrIdempotencyKeyNotFound = errors.New("ключ идемпотентности не найден")
+)
+
+const idempotencyKeyColumns = `id, user_id, key, fingerprint, response_status, response_content_type,
+    response_body, created_at, expires_at, locked_until`
+
+type PostgresIdempotency struct {
+	pool *db.Pool
+}
+
+func NewPostgresIdempotency(pool *db.Pool) *PostgresIdempotency {
+	return &PostgresIdempotency{
+		pool: pool,
+	}
+}
+
+// Reserve занимает ключ пользователя под запрос. Ключ с истекшим сроком занимается заново, как и
+// ключ запроса, который не завершился до locked_until. Такой ключ получает новый ID, чтобы
+// запрос, занимавший его раньше, уже не мог сохранить ответ или освободить ключ.
+// Возвращает ErrIdempotencyKeyExists, если ключ уже занят
+func (r *PostgresIdempotency) Reserve(ctx context.Context, key model.IdempotencyKey) (model.IdempotencyKey, error) {
+	var reserved model.IdempotencyKey
+	err := pgxscan.Get(ctx, r.pool, &reserved, `
+        INSERT INTO idempotency_keys (user_id, key, fingerprint, created_at, expires_at, locked_until)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id, key) DO UPDATE
+        SET id = nextval(pg_get_serial_sequence('idempotency_keys', 'id')),
+            fingerprint = EXCLUDED.fingerprint,
+            response_status = NULL,
+            response_content_type = '',
+            response_body = NULL,
+            created_at = EXCLUDED.created_at,
+            expires_at = EXCLUDED.expires_at,
+            locked_until = EXCLUDED.locked_until
+        WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+           OR (idempotency_keys.response_status IS NULL AND idempotency_keys.locked_until <= EXCLUDED.created_at)
+        RETURNING `+idempotencyKeyColumns,
+		key.UserID, key.Key, key.Fingerprint, key.CreatedAt, key.ExpiresAt, key.LockedUntil,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.IdempotencyKey{}, ErrIdempotencyKeyExists
+		}
+		return model.IdempotencyKey{}, fmt.Errorf("ошибка сохранения ключа идемпотентности: %w", err)
+	}
+	return reserved, nil
+}
+
+// Get возвращает ключ пользователя
+func (r *PostgresIdempotency) Get(ctx context.Context, userID int64, key string) (model.IdempotencyKey, error) {
+	var found model.IdempotencyKey
+	err := pgxscan.Get(ctx, r.pool, &found,
+		`SELECT `+idempotencyKeyColumns+` FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userID, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.IdempotencyKey{}, ErrIdempotencyKeyNotFound
+		}
+		return model.IdempotencyKey{}, fmt.Errorf("ошибка получения ключа идемпотентности: %w", err)
+	}
+	return found, nil
+}
+
+// Complete сохраняет ответ на запрос, занявший ключ
+func (r *PostgresIdempotency) Complete(ctx context.Context, id int64, status int, contentType string, body []byte) error {
+	_, err := r.pool.Exec(ctx, `
+        UPDATE idempotency_keys
+        SET response_status = $2, response_content_type = $3, response_body = $4
+        WHERE id = $1`,
+		id, status, contentType, body)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения ответа по ключу идемпотентности: %w", err)
+	}
+	return nil
+}
+
+// Release освобождает ключ, запрос по которому не выполнен, чтобы его можно было повторить
+func (r *PostgresIdempotency) Release(ctx context.Context, id int64) error {
+	if _, err := r.pool.Exec(ctx, "DELETE FROM idempotency_keys WHERE id = $1", id); err != nil {
+		return fmt.Errorf("ошибка освобождения ключа идемпотентности: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired удаляет ключи с истекшим сроком хранения
+func (r *PostgresIdempotency) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	commandTag, err := r.pool.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка удаления ключей идемпотентности: %w", err)
+	}
+	return commandTag.RowsAffected(), nil
+}
@@ -8,14 +8,26 @@ import (
 	"github.com/CryptoCrowd/internal/model"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
 	"time"
 )
 
 var (
 	ErrInvestmentNotFound = errors.New("инвестиция не найдена")
 	ErrInvestmentTxStart  = errors.New("ошибка начала транзакции")
+	ErrProjectClosed      = errors.New("проект не принимает инвестиции")
 )
 
+// InvestmentGuard проверяет лимиты инвестора при сохранении или восстановлении инвестиции.
+// Check вызывается внутри транзакции, пока инвестор и проект заблокированы, поэтому параллельные
+// инвестиции того же инвестора не могут вместе превысить лимит. Ошибка Check отменяет сохранение
+// и возвращается из Create или Restore
+type InvestmentGuard struct {
+	// YearStart - начало периода, за который считается InvestmentTotals.ThisYear
+	YearStart time.Time
+	Check     func(totals model.InvestmentTotals) error
+}
+
 type PostgresInvestment struct {
 	pool *db.Pool
 }
@@ -26,25 +38,141 @@ func NewPostgresInvestment(pool *db.Pool) *PostgresInvestment {
 	}
 }
 
-func (r *PostgresInvestment) Create(ctx context.Context, investment model.Investment) error {
+// Create проверяет лимиты инвестора через guard, сохраняет новую инвестицию, увеличивает
+// собранную проектом сумму и возвращает ID инвестиции. Если проект уже не принимает инвестиции,
+// возвращается ErrProjectClosed
+func (r *PostgresInvestment) Create(ctx context.Context, investment model.Investment, guard InvestmentGuard) (int64, error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrInvestmentTxStart, err)
+		return 0, fmt.Errorf("%w: %w", ErrInvestmentTxStart, err)
 	}
 	defer tx.Rollback(ctx)
 
+	// Инвестор блокируется раньше проекта, в том же порядке, что и при удалении пользователя
+	if err = lockRow(ctx, tx, "users", investment.UserID, ErrUserNotFound); err != nil {
+		return 0, err
+	}
+	if err = lockOpenProject(ctx, tx, investment.ProjectID); err != nil {
+		return 0, err
+	}
+
+	totals, err := investmentTotals(ctx, tx, investment.UserID, investment.ProjectID, guard.YearStart)
+	if err != nil {
+		return 0, err
+	}
+	if err = guard.Check(totals); err != nil {
+		return 0, err
+	}
+
 	now := time.Now()
 
-	_, err = tx.Exec(ctx, `
+	var id int64
+	err = tx.QueryRow(ctx, `
         INSERT INTO investments (user_id, project_id, amount, invested_at)
-        VALUES ($1, $2, $3, $4)`,
+        VALUES ($1, $2, $3, $4)
+        RETURNING id`,
 		investment.UserID,
 		investment.ProjectID,
 		investment.Amount,
 		now,
-	)
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания инвестиции: %w", err)
+	}
+
+	if err = addRaisedAmount(ctx, tx, investment.ProjectID, investment.Amount); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// Update меняет сумму инвестиции, если ее версия совпадает с investment.Version, и переносит
+// разницу в собранную проектом сумму. Лимиты инвестора проверяются через guard; в переданных
+// ему суммах учтена и текущая сумма инвестиции. Если проект уже не принимает инвестиции,
+// возвращается ErrProjectClosed
+func (r *PostgresInvestment) Update(ctx context.Context, investment model.Investment, guard InvestmentGuard) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvestmentTxStart, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Инвестор блокируется раньше инвестиции и проекта, в том же порядке, что и при создании
+	if err = lockRow(ctx, tx, "users", investment.UserID, ErrUserNotFound); err != nil {
+		return err
+	}
+
+	var current model.Investment
+	err = pgxscan.Get(ctx, tx, &current,
+		"SELECT id, user_id, project_id, amount, invested_at, version FROM investments WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE",
+		investment.ID, investment.UserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvestmentNotFound
+		}
+		return fmt.Errorf("ошибка проверки существования инвестиции: %w", err)
+	}
+	if current.Version != investment.Version {
+		return ErrVersionConflict
+	}
+	if err = lockOpenProject(ctx, tx, current.ProjectID); err != nil {
+		return err
+	}
+
+	totals, err := investmentTotals(ctx, tx, current.UserID, current.ProjectID, guard.YearStart)
+	if err != nil {
+		return err
+	}
+	if err = guard.Check(totals); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE investments SET amount = $2, version = version + 1 WHERE id = $1", investment.ID, investment.Amount)
 	if err != nil {
-		return fmt.Errorf("ошибка создания инвестиции: %w", err)
+		return fmt.Errorf("ошибка обновления инвестиции: %w", err)
+	}
+
+	if err = addRaisedAmount(ctx, tx, current.ProjectID, investment.Amount.Sub(current.Amount)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Delete помечает инвестицию удаленной, если ее версия совпадает с version,
+// и вычитает ее сумму из собранной проектом. Отменить инвестицию можно, только пока
+// проект принимает инвестиции, иначе возвращается ErrProjectClosed
+func (r *PostgresInvestment) Delete(ctx context.Context, id int64, version int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvestmentTxStart, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current model.Investment
+	err = pgxscan.Get(ctx, tx, &current,
+		"SELECT id, user_id, project_id, amount, invested_at, version FROM investments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvestmentNotFound
+		}
+		return fmt.Errorf("ошибка проверки существования инвестиции: %w", err)
+	}
+	if current.Version != version {
+		return ErrVersionConflict
+	}
+	if err = lockOpenProject(ctx, tx, current.ProjectID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE investments SET deleted_at = $2, version = version + 1 WHERE id = $1", id, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка удаления инвестиции: %w", err)
+	}
+
+	if err = addRaisedAmount(ctx, tx, current.ProjectID, current.Amount.Neg()); err != nil {
+		return err
 	}
 
 	return tx.Commit(ctx)
@@ -52,8 +180,8 @@ func (r *PostgresInvestment) Create(ctx context.Context, investment model.Invest
 
 func (r *PostgresInvestment) GetByID(ctx context.Context, id int64) (model.Investment, error) {
 	var investment model.Investment
-	err := pgxscan.Get(ctx, r.pool, &investment,
-		`SELECT id, user_id, project_id, amount, invested_at FROM investments WHERE id = $1`,
+	err := pgxscan.Get(ctx, r.pool.Reader(ctx), &investment,
+		`SELECT id, user_id, project_id, amount, invested_at, version FROM investments WHERE id = $1 AND deleted_at IS NULL`,
 		id,
 	)
 	if err != nil {
@@ -67,8 +195,8 @@ func (r *PostgresInvestment) GetByID(ctx context.Context, id int64) (model.Inves
 
 func (r *PostgresInvestment) GetByUserID(ctx context.Context, userID int64) ([]model.Investment, error) {
 	var investments []model.Investment
-	err := pgxscan.Select(ctx, r.pool, &investments,
-		`SELECT id, user_id, project_id, amount, invested_at FROM investments WHERE user_id = $1 ORDER BY invested_at DESC`,
+	err := pgxscan.Select(ctx, r.pool.Reader(ctx), &investments,
+		`SELECT id, user_id, project_id, amount, invested_at, version FROM investments WHERE user_id = $1 AND deleted_at IS NULL ORDER BY invested_at DESC`,
 		userID,
 	)
 	if err != nil {
@@ -80,7 +208,7 @@ func (r *PostgresInvestment) GetByUserID(ctx context.Context, userID int64) ([]m
 func (r *PostgresInvestment) GetByProjectID(ctx context.Context, projectID int64) ([]model.Investment, error) {
 	var investments []model.Investment
 	err := pgxscan.Select(ctx, r.pool, &investments,
-		`SELECT id, user_id, project_id, amount, invested_at FROM investments WHERE project_id = $1 ORDER BY invested_at DESC`,
+		`SELECT id, user_id, project_id, amount, invested_at, version FROM investments WHERE project_id = $1 AND deleted_at IS NULL ORDER BY invested_at DESC`,
 		projectID,
 	)
 	if err != nil {
@@ -88,3 +216,161 @@ func (r *PostgresInvestment) GetByProjectID(ctx context.Context, projectID int64
 	}
 	return investments, nil
 }
+
+// Totals возвращает суммы действующих инвестиций пользователя: всего, с yearStart и в проекте projectID
+func (r *PostgresInvestment) Totals(ctx context.Context, userID int64, projectID int64, yearStart time.Time) (model.InvestmentTotals, error) {
+	return investmentTotals(ctx, r.pool, userID, projectID, yearStart)
+}
+
+func investmentTotals(ctx context.Context, q pgxscan.Querier, userID int64, projectID int64, yearStart time.Time) (model.InvestmentTotals, error) {
+	var totals model.InvestmentTotals
+	err := pgxscan.Get(ctx, q, &totals, `
+        SELECT COALESCE(SUM(amount), 0) AS total,
+               COALESCE(SUM(amount) FILTER (WHERE invested_at >= $3), 0) AS this_year,
+               COALESCE(SUM(amount) FILTER (WHERE project_id = $2), 0) AS in_project
+        FROM investments WHERE user_id = $1 AND deleted_at IS NULL`,
+		userID, projectID, yearStart)
+	if err != nil {
+		return model.InvestmentTotals{}, fmt.Errorf("ошибка подсчета инвестиций пользователя: %w", err)
+	}
+	return totals, nil
+}
+
+// ListDeleted возвращает инвестиции, помеченные удаленными
+func (r *PostgresInvestment) ListDeleted(ctx context.Context) ([]model.Investment, error) {
+	var investments []model.Investment
+	err := pgxscan.Select(ctx, r.pool, &investments,
+		`SELECT id, user_id, project_id, amount, invested_at, deleted_at, version FROM investments WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка удаленных инвестиций: %w", err)
+	}
+	return investments, nil
+}
+
+// GetDeletedByID возвращает инвестицию, помеченную удаленной
+func (r *PostgresInvestment) GetDeletedByID(ctx context.Context, id int64) (model.Investment, error) {
+	var investment model.Investment
+	err := pgxscan.Get(ctx, r.pool, &investment,
+		`SELECT id, user_id, project_id, amount, invested_at, deleted_at, version FROM investments WHERE id = $1 AND deleted_at IS NOT NULL`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Investment{}, ErrInvestmentNotFound
+		}
+		return model.Investment{}, fmt.Errorf("ошибка получения удаленной инвестиции: %w", err)
+	}
+	return investment, nil
+}
+
+// Restore снимает с инвестиции пометку об удалении и возвращает ее сумму в собранную проектом.
+// Восстановленная инвестиция проверяется так же, как новая: проект должен принимать инвестиции
+// (иначе возвращается ErrProjectClosed), а лимиты инвестора проверяются через guard
+func (r *PostgresInvestment) Restore(ctx context.Context, id int64, guard InvestmentGuard) (model.Investment, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return model.Investment{}, fmt.Errorf("%w: %w", ErrInvestmentTxStart, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userID int64
+	err = tx.QueryRow(ctx, "SELECT user_id FROM investments WHERE id = $1 AND deleted_at IS NOT NULL", id).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Investment{}, ErrInvestmentNotFound
+		}
+		return model.Investment{}, fmt.Errorf("ошибка проверки существования инвестиции: %w", err)
+	}
+
+	// Инвестор блокируется раньше инвестиции и проекта, в том же порядке, что и при создании
+	if err = lockRow(ctx, tx, "users", userID, ErrUserNotFound); err != nil {
+		return model.Investment{}, err
+	}
+
+	var investment model.Investment
+	err = pgxscan.Get(ctx, tx, &investment,
+		"SELECT id, user_id, project_id, amount, invested_at, version FROM investments WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE", id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Investment{}, ErrInvestmentNotFound
+		}
+		return model.Investment{}, fmt.Errorf("ошибка проверки существования инвестиции: %w", err)
+	}
+	if err = lockOpenProject(ctx, tx, investment.ProjectID); err != nil {
+		return model.Investment{}, err
+	}
+
+	totals, err := investmentTotals(ctx, tx, investment.UserID, investment.ProjectID, guard.YearStart)
+	if err != nil {
+		return model.Investment{}, err
+	}
+	if err = guard.Check(totals); err != nil {
+		return model.Investment{}, err
+	}
+
+	err = tx.QueryRow(ctx, "UPDATE investments SET deleted_at = NULL, version = version + 1 WHERE id = $1 RETURNING version", id).
+		Scan(&investment.Version)
+	if err != nil {
+		return model.Investment{}, fmt.Errorf("ошибка восстановления инвестиции: %w", err)
+	}
+
+	if err = addRaisedAmount(ctx, tx, investment.ProjectID, investment.Amount); err != nil {
+		return model.Investment{}, err
+	}
+
+	return investment, tx.Commit(ctx)
+}
+
+// PurgeDeleted окончательно удаляет инвестиции, помеченные удаленными до before.
+// Срок хранения инвестиций задается отдельно с учетом требований к финансовой отчетности
+func (r *PostgresInvestment) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	commandTag, err := r.pool.Exec(ctx, "DELETE FROM investments WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка окончательного удаления инвестиций: %w", err)
+	}
+	return commandTag.RowsAffected(), nil
+}
+
+// SumAmount возвращает общую сумму действующих инвестиций. Значение используется только
+// в метриках, поэтому допускается чтение с реплики
+func (r *PostgresInvestment) SumAmount(ctx context.Context) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := pgxscan.Get(ctx, r.pool.Reader(db.WithStaleReads(ctx)), &total, "SELECT COALESCE(SUM(amount), 0) FROM investments WHERE deleted_at IS NULL")
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("ошибка получения суммы инвестиций: %w", err)
+	}
+	return total, nil
+}
+
+// lockOpenProject блокирует проект до конца транзакции и проверяет, что он принимает инвестиции:
+// одобрен и срок сбора не истек. Статус и срок читаются под блокировкой, поэтому проект
+// не может закрыться между проверкой и изменением собранной суммы
+func lockOpenProject(ctx context.Context, tx pgx.Tx, projectID int64) error {
+	var open bool
+	err := tx.QueryRow(ctx,
+		"SELECT status = 'approved' AND deadline_at >= now() FROM projects WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", projectID).Scan(&open)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProjectNotFound
+		}
+		return fmt.Errorf("ошибка блокировки проекта: %w", err)
+	}
+	if !open {
+		return ErrProjectClosed
+	}
+	return nil
+}
+
+// addRaisedAmount изменяет собранную проектом сумму в рамках транзакции.
+// Изменение amount_raised рассылает подписчикам уведомление project_progress
+func addRaisedAmount(ctx context.Context, tx pgx.Tx, projectID int64, delta decimal.Decimal) error {
+	commandTag, err := tx.Exec(ctx,
+		"UPDATE projects SET amount_raised = amount_raised + $2 WHERE id = $1 AND deleted_at IS NULL", projectID, delta)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления собранной суммы проекта: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrProjectNotFound
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+type PostgresInvestmentRules struct {
+	pool *db.Pool
+}
+
+func NewPostgresInvestmentRules(pool *db.Pool) *PostgresInvestmentRules {
+	return &PostgresInvestmentRules{
+		pool: pool,
+	}
+}
+
+// GetByProjectID возвращает правила инвестирования проекта.
+// Если правила для проекта не заданы, возвращаются пустые правила
+func (r *PostgresInvestmentRules) GetByProjectID(ctx context.Context, projectID int64) (model.InvestmentRules, error) {
+	var rules model.InvestmentRules
+	err := pgxscan.Get(ctx, r.pool, &rules, `
+        SELECT annual_cap, min_ticket, max_ticket, max_project_share, blocked_countries
+        FROM project_investment_rules WHERE project_id = $1`, projectID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.InvestmentRules{}, nil
+		}
+		return model.InvestmentRules{}, fmt.Errorf("ошибка получения правил инвестирования проекта: %w", err)
+	}
+	return rules, nil
+}
+
+// Upsert сохраняет правила инвестирования проекта, заменяя прежние
+func (r *PostgresInvestmentRules) Upsert(ctx context.Context, projectID int64, rules model.InvestmentRules) error {
+	blocked := rules.BlockedCountries
+	if blocked == nil {
+		blocked = []string{}
+	}
+
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO project_investment_rules
+            (project_id, annual_cap, min_ticket, max_ticket, max_project_share, blocked_countries, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (project_id) DO UPDATE
+        SET annual_cap = EXCLUDED.annual_cap,
+            min_ticket = EXCLUDED.min_ticket,
+            max_ticket = EXCLUDED.max_ticket,
+            max_project_share = EXCLUDED.max_project_share,
+            blocked_countries = EXCLUDED.blocked_countries,
+            updated_at = EXCLUDED.updated_at`,
+		projectID,
+		rules.AnnualCap,
+		rules.MinTicket,
+		rules.MaxTicket,
+		rules.MaxProjectShare,
+		blocked,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения правил инвестирования проекта: %w", err)
+	}
+	return nil
+}
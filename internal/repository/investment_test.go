@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+var errCapExceeded = errors.New("cap exceeded")
+
+// capGuard пропускает инвестицию, пока сумма инвестиций пользователя вместе с ней не превышает limit
+func capGuard(amount decimal.Decimal, limit int64) InvestmentGuard {
+	return InvestmentGuard{
+		YearStart: time.Date(time.Now().UTC().Year(), time.January, 1, 0, 0, 0, 0, time.UTC),
+		Check: func(totals model.InvestmentTotals) error {
+			if totals.Total.Add(amount).GreaterThan(decimal.NewFromInt(limit)) {
+				return errCapExceeded
+			}
+			return nil
+		},
+	}
+}
+
+func TestInvestmentCreateGuardSeesTotals(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	accounts := NewPostgresAccount(pool)
+	projects := NewPostgresProject(pool)
+	repo := NewPostgresInvestment(pool)
+
+	owner := createTestAccount(t, accounts, "startup")
+	investor := createTestAccount(t, accounts, "investor")
+	project := createTestProject(t, projects, owner.ID)
+	other := createTestProject(t, projects, owner.ID)
+
+	amount := decimal.NewFromInt(100)
+	if _, err := repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: other.ID, Amount: amount}, allowInvestment); err != nil {
+		t.Fatalf("Create in other project: %v", err)
+	}
+	if _, err := repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: project.ID, Amount: amount}, allowInvestment); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var seen model.InvestmentTotals
+	guard := capGuard(amount, 250)
+	check := guard.Check
+	guard.Check = func(totals model.InvestmentTotals) error {
+		seen = totals
+		return check(totals)
+	}
+	if _, err := repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: project.ID, Amount: amount}, guard); !errors.Is(err, errCapExceeded) {
+		t.Fatalf("Create above the cap: error = %v, want errCapExceeded", err)
+	}
+
+	want := model.InvestmentTotals{Total: decimal.NewFromInt(200), ThisYear: decimal.NewFromInt(200), InProject: amount}
+	if !seen.Total.Equal(want.Total) || !seen.ThisYear.Equal(want.ThisYear) || !seen.InProject.Equal(want.InProject) {
+		t.Fatalf("guard saw totals %+v, want %+v", seen, want)
+	}
+
+	totals, err := repo.Totals(ctx, investor.ID, project.ID, guard.YearStart)
+	if err != nil {
+		t.Fatalf("Totals: %v", err)
+	}
+	if !totals.Total.Equal(want.Total) || !totals.InProject.Equal(want.InProject) {
+		t.Fatalf("totals after rejected create = %+v, want %+v", totals, want)
+	}
+}
+
+func TestInvestmentCreateGuardConcurrent(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	accounts := NewPostgresAccount(pool)
+	projects := NewPostgresProject(pool)
+	repo := NewPostgresInvestment(pool)
+
+	owner := createTestAccount(t, accounts, "startup")
+	investor := createTestAccount(t, accounts, "investor")
+	project := createTestProject(t, projects, owner.ID)
+
+	// Каждая инвестиция укладывается в лимит отдельно, но две вместе его превышают
+	const attempts = 5
+	amount := decimal.NewFromInt(100)
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for idx := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[idx] = repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: project.ID, Amount: amount}, capGuard(amount, 150))
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, errCapExceeded):
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("created %d investments, want 1", created)
+	}
+
+	p, err := projects.GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if !p.AmountRaised.Equal(amount) {
+		t.Fatalf("amount raised = %s, want %s", p.AmountRaised, amount)
+	}
+}
+
+func TestInvestmentCreateLocksMissingRows(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	accounts := NewPostgresAccount(pool)
+	projects := NewPostgresProject(pool)
+	repo := NewPostgresInvestment(pool)
+
+	owner := createTestAccount(t, accounts, "startup")
+	investor := createTestAccount(t, accounts, "investor")
+	project := createTestProject(t, projects, owner.ID)
+	closed := createTestProject(t, projects, owner.ID)
+	if err := projects.UpdateStatus(ctx, closed.ID, "funded", closed.Version); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		investment model.Investment
+		wantErr    error
+	}{
+		{name: "missing investor", investment: model.Investment{UserID: -1, ProjectID: project.ID}, wantErr: ErrUserNotFound},
+		{name: "missing project", investment: model.Investment{UserID: investor.ID, ProjectID: -1}, wantErr: ErrProjectNotFound},
+		{name: "project no longer collecting", investment: model.Investment{UserID: investor.ID, ProjectID: closed.ID}, wantErr: ErrProjectClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.investment.Amount = decimal.NewFromInt(10)
+			if _, err := repo.Create(ctx, tt.investment, allowInvestment); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create: error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInvestmentChangesInClosedProject(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	accounts := NewPostgresAccount(pool)
+	projects := NewPostgresProject(pool)
+	repo := NewPostgresInvestment(pool)
+
+	owner := createTestAccount(t, accounts, "startup")
+	investor := createTestAccount(t, accounts, "investor")
+	project := createTestProject(t, projects, owner.ID)
+
+	create := func() model.Investment {
+		t.Helper()
+		id, err := repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: project.ID, Amount: decimal.NewFromInt(100)}, allowInvestment)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		investment, err := repo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		return investment
+	}
+	active := create()
+	deleted := create()
+	if err := repo.Delete(ctx, deleted.ID, deleted.Version); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	project, err := projects.GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if err = projects.UpdateStatus(ctx, project.ID, "funded", project.Version); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	if err = repo.Delete(ctx, active.ID, active.Version); !errors.Is(err, ErrProjectClosed) {
+		t.Fatalf("Delete: error = %v, want %v", err, ErrProjectClosed)
+	}
+	if _, err = repo.GetByID(ctx, active.ID); err != nil {
+		t.Fatalf("investment was deleted: %v", err)
+	}
+	if _, err = repo.Restore(ctx, deleted.ID, allowInvestment); !errors.Is(err, ErrProjectClosed) {
+		t.Fatalf("Restore: error = %v, want %v", err, ErrProjectClosed)
+	}
+	if _, err = repo.GetDeletedByID(ctx, deleted.ID); err != nil {
+		t.Fatalf("investment was restored: %v", err)
+	}
+}
+
+func TestInvestmentRestoreGuardSeesTotals(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	accounts := NewPostgresAccount(pool)
+	projects := NewPostgresProject(pool)
+	repo := NewPostgresInvestment(pool)
+
+	owner := createTestAccount(t, accounts, "startup")
+	investor := createTestAccount(t, accounts, "investor")
+	project := createTestProject(t, projects, owner.ID)
+
+	amount := decimal.NewFromInt(100)
+	id, err := repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: project.ID, Amount: amount}, allowInvestment)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	investment, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if err = repo.Delete(ctx, id, investment.Version); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// Пока инвестиция удалена, инвестор вкладывает в проект еще раз
+	if _, err = repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: project.ID, Amount: amount}, allowInvestment); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err = repo.Restore(ctx, id, capGuard(amount, 150)); !errors.Is(err, errCapExceeded) {
+		t.Fatalf("Restore: error = %v, want %v", err, errCapExceeded)
+	}
+	if _, err = repo.Restore(ctx, id, capGuard(amount, 200)); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+}
+
+func TestInvestmentUpdate(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	accounts := NewPostgresAccount(pool)
+	projects := NewPostgresProject(pool)
+	repo := NewPostgresInvestment(pool)
+
+	owner := createTestAccount(t, accounts, "startup")
+	investor := createTestAccount(t, accounts, "investor")
+	project := createTestProject(t, projects, owner.ID)
+
+	id, err := repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: project.ID, Amount: decimal.NewFromInt(100)}, allowInvestment)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	investment, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+
+	// В суммах для guard учтена текущая сумма инвестиции
+	updated := investment
+	updated.Amount = decimal.NewFromInt(250)
+	if err = repo.Update(ctx, updated, capGuard(decimal.NewFromInt(150), 200)); !errors.Is(err, errCapExceeded) {
+		t.Fatalf("Update: error = %v, want %v", err, errCapExceeded)
+	}
+	if err = repo.Update(ctx, updated, capGuard(decimal.NewFromInt(150), 250)); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err = repo.Update(ctx, updated, allowInvestment); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Update with a stale version: error = %v, want %v", err, ErrVersionConflict)
+	}
+
+	p, err := projects.GetByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if !p.AmountRaised.Equal(decimal.NewFromInt(250)) {
+		t.Fatalf("amount raised = %s, want 250", p.AmountRaised)
+	}
+}
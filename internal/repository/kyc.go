@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrKYCApplicationNotFound определяет ошибку, которая возникает, когда заявка KYC не найдена
+	ErrKYCApplicationNotFound = errors.New("заявка KYC не найдена")
+	// ErrKYCApplicationNotPending определяет ошибку, которая возникает при повторном решении по заявке
+	ErrKYCApplicationNotPending = errors.New("заявка KYC уже рассмотрена")
+	// ErrKYCDocumentNotFound определяет ошибку, которая возникает, когда документ KYC не найден
+	ErrKYCDocumentNotFound = errors.New("документ KYC не найден")
+	// ErrKYCNoDocuments определяет ошибку, которая возникает при подаче заявки без документов
+	ErrKYCNoDocuments = errors.New("не загружено ни одного документа")
+	// ErrKYCAlreadySubmitted определяет ошибку, которая возникает, когда заявка уже подана или одобрена
+	ErrKYCAlreadySubmitted = errors.New("заявка KYC уже подана")
+)
+
+const (
+	kycApplicationColumns = "id, user_id, status, provider, provider_ref, reason, reviewer_id, submitted_at, reviewed_at"
+	kycDocumentColumns    = "id, user_id, application_id, kind, file_name, content_type, size, sha256, storage_key, uploaded_at"
+)
+
+type PostgresKYC struct {
+	pool *db.Pool
+}
+
+func NewPostgresKYC(pool *db.Pool) *PostgresKYC {
+	return &PostgresKYC{
+		pool: pool,
+	}
+}
+
+// CreateDocument сохраняет сведения о загруженном документе, еще не включенном в заявку
+func (r *PostgresKYC) CreateDocument(ctx context.Context, doc model.KYCDocument) (model.KYCDocument, error) {
+	err := r.pool.QueryRow(ctx, `
+        INSERT INTO kyc_documents (user_id, kind, file_name, content_type, size, sha256, storage_key, uploaded_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, uploaded_at`,
+		doc.UserID,
+		doc.Kind,
+		doc.FileName,
+		doc.ContentType,
+		doc.Size,
+		doc.SHA256,
+		doc.StorageKey,
+		time.Now(),
+	).Scan(&doc.ID, &doc.UploadedAt)
+	if err != nil {
+		return model.KYCDocument{}, fmt.Errorf("ошибка сохранения документа KYC: %w", err)
+	}
+	return doc, nil
+}
+
+// GetDocument возвращает документ по ID
+func (r *PostgresKYC) GetDocument(ctx context.Context, id int64) (model.KYCDocument, error) {
+	var doc model.KYCDocument
+	err := pgxscan.Get(ctx, r.pool, &doc, `SELECT `+kycDocumentColumns+` FROM kyc_documents WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.KYCDocument{}, ErrKYCDocumentNotFound
+		}
+		return model.KYCDocument{}, fmt.Errorf("ошибка получения документа KYC: %w", err)
+	}
+	return doc, nil
+}
+
+// ListUnsubmittedDocuments возвращает документы пользователя, еще не включенные в заявку
+func (r *PostgresKYC) ListUnsubmittedDocuments(ctx context.Context, userID int64) ([]model.KYCDocument, error) {
+	var docs []model.KYCDocument
+	err := pgxscan.Select(ctx, r.pool, &docs,
+		`SELECT `+kycDocumentColumns+` FROM kyc_documents
+        WHERE user_id = $1 AND application_id IS NULL ORDER BY uploaded_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения документов KYC: %w", err)
+	}
+	return docs, nil
+}
+
+// ListApplicationDocuments возвращает документы заявки
+func (r *PostgresKYC) ListApplicationDocuments(ctx context.Context, applicationID int64) ([]model.KYCDocument, error) {
+	var docs []model.KYCDocument
+	err := pgxscan.Select(ctx, r.pool, &docs,
+		`SELECT `+kycDocumentColumns+` FROM kyc_documents
+        WHERE application_id = $1 ORDER BY uploaded_at`, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения документов KYC: %w", err)
+	}
+	return docs, nil
+}
+
+// CreateApplication подает заявку из всех еще не поданных документов пользователя
+// и переводит пользователя в статус pending
+func (r *PostgresKYC) CreateApplication(ctx context.Context, userID int64, provider string) (model.KYCApplication, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return model.KYCApplication{}, fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, "SELECT kyc_status FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.KYCApplication{}, ErrUserNotFound
+		}
+		return model.KYCApplication{}, fmt.Errorf("ошибка получения статуса KYC: %w", err)
+	}
+	if status == model.KYCPending || status == model.KYCVerified {
+		return model.KYCApplication{}, ErrKYCAlreadySubmitted
+	}
+
+	now := time.Now()
+	app := model.KYCApplication{
+		UserID:      userID,
+		Status:      model.KYCPending,
+		Provider:    provider,
+		SubmittedAt: &now,
+	}
+	err = tx.QueryRow(ctx, `
+        INSERT INTO kyc_applications (user_id, status, provider, submitted_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id`,
+		userID, app.Status, provider, now,
+	).Scan(&app.ID)
+	if err != nil {
+		return model.KYCApplication{}, fmt.Errorf("ошибка создания заявки KYC: %w", err)
+	}
+
+	commandTag, err := tx.Exec(ctx,
+		"UPDATE kyc_documents SET application_id = $2 WHERE user_id = $1 AND application_id IS NULL",
+		userID, app.ID)
+	if err != nil {
+		return model.KYCApplication{}, fmt.Errorf("ошибка привязки документов KYC: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return model.KYCApplication{}, ErrKYCNoDocuments
+	}
+
+	if _, err = tx.Exec(ctx, "UPDATE users SET kyc_status = $2, updated_at = $3 WHERE id = $1", userID, model.KYCPending, now); err != nil {
+		return model.KYCApplication{}, fmt.Errorf("ошибка обновления статуса KYC: %w", err)
+	}
+
+	return app, tx.Commit(ctx)
+}
+
+// SetProviderRef сохраняет идентификатор заявки у внешнего провайдера
+func (r *PostgresKYC) SetProviderRef(ctx context.Context, applicationID int64, ref string) error {
+	_, err := r.pool.Exec(ctx, "UPDATE kyc_applications SET provider_ref = $2 WHERE id = $1", applicationID, ref)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления заявки KYC: %w", err)
+	}
+	return nil
+}
+
+// ApplyDecision выносит решение по ожидающей заявке и обновляет статус пользователя
+func (r *PostgresKYC) ApplyDecision(ctx context.Context, app model.KYCApplication) (model.KYCApplication, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return model.KYCApplication{}, fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	var updated model.KYCApplication
+	err = pgxscan.Get(ctx, tx, &updated, `
+        UPDATE kyc_applications
+        SET status = $2, provider_ref = COALESCE(NULLIF($3, ''), provider_ref), reason = $4, reviewer_id = $5, reviewed_at = $6
+        WHERE id = $1 AND status = 'pending'
+        RETURNING `+kycApplicationColumns,
+		app.ID, app.Status, app.ProviderRef, app.Reason, app.ReviewerID, now,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.KYCApplication{}, r.missingApplication(ctx, tx, app.ID)
+		}
+		return model.KYCApplication{}, fmt.Errorf("ошибка обновления заявки KYC: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, "UPDATE users SET kyc_status = $2, updated_at = $3 WHERE id = $1", updated.UserID, updated.Status, now); err != nil {
+		return model.KYCApplication{}, fmt.Errorf("ошибка обновления статуса KYC: %w", err)
+	}
+
+	return updated, tx.Commit(ctx)
+}
+
+// missingApplication различает отсутствующую и уже рассмотренную заявку
+func (r *PostgresKYC) missingApplication(ctx context.Context, tx pgx.Tx, id int64) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM kyc_applications WHERE id = $1)", id).Scan(&exists); err != nil {
+		return fmt.Errorf("ошибка проверки существования заявки KYC: %w", err)
+	}
+	if exists {
+		return ErrKYCApplicationNotPending
+	}
+	return ErrKYCApplicationNotFound
+}
+
+// GetApplication возвращает заявку по ID
+func (r *PostgresKYC) GetApplication(ctx context.Context, id int64) (model.KYCApplication, error) {
+	var app model.KYCApplication
+	err := pgxscan.Get(ctx, r.pool, &app, `SELECT `+kycApplicationColumns+` FROM kyc_applications WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.KYCApplication{}, ErrKYCApplicationNotFound
+		}
+		return model.KYCApplication{}, fmt.Errorf("ошибка получения заявки KYC: %w", err)
+	}
+	return app, nil
+}
+
+// GetLatestApplication возвращает последнюю заявку пользователя
+func (r *PostgresKYC) GetLatestApplication(ctx context.Context, userID int64) (model.KYCApplication, error) {
+	var app model.KYCApplication
+	err := pgxscan.Get(ctx, r.pool, &app,
+		`SELECT `+kycApplicationColumns+` FROM kyc_applications WHERE user_id = $1 ORDER BY id DESC LIMIT 1`, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.KYCApplication{}, ErrKYCApplicationNotFound
+		}
+		return model.KYCApplication{}, fmt.Errorf("ошибка получения заявки KYC: %w", err)
+	}
+	return app, nil
+}
+
+// ListApplications возвращает заявки с заданным статусом, начиная с самых старых
+func (r *PostgresKYC) ListApplications(ctx context.Context, status string) ([]model.KYCApplication, error) {
+	var apps []model.KYCApplication
+	err := pgxscan.Select(ctx, r.pool, &apps,
+		`SELECT `+kycApplicationColumns+` FROM kyc_applications WHERE status = $1 ORDER BY submitted_at`, status)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка заявок KYC: %w", err)
+	}
+	return apps, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrPreferencesNotFound определяет ошибку, которая возникает, когда пользователь еще не сохранял настройки уведомлений
+	ErrPreferencesNotFound = errors.New("настройки уведомлений не найдены")
+)
+
+type PostgresNotification struct {
+	pool *db.Pool
+}
+
+func NewPostgresNotification(pool *db.Pool) *PostgresNotification {
+	return &PostgresNotification{
+		pool: pool,
+	}
+}
+
+func (r *PostgresNotification) Create(ctx context.Context, notification model.Notification) error {
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO notifications (user_id, type, title, body, created_at)
+        VALUES ($1, $2, $3, $4, $5)`,
+		notification.UserID,
+		notification.Type,
+		notification.Title,
+		notification.Body,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка создания уведомления: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUserID возвращает уведомления пользователя, начиная с самых новых
+func (r *PostgresNotification) ListByUserID(ctx context.Context, userID int64, unreadOnly bool) ([]model.Notification, error) {
+	var notifications []model.Notification
+	query := `SELECT id, user_id, type, title, body, read_at, created_at FROM notifications WHERE user_id = $1`
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC"
+
+	err := pgxscan.Select(ctx, r.pool, &notifications, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения уведомлений: %w", err)
+	}
+	return notifications, nil
+}
+
+// MarkRead отмечает уведомления пользователя прочитанными. Пустой список ids отмечает все уведомления
+func (r *PostgresNotification) MarkRead(ctx context.Context, userID int64, ids []int64) error {
+	query := `UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL`
+	args := []any{userID, time.Now()}
+
+	if len(ids) > 0 {
+		query += " AND id = ANY($3)"
+		args = append(args, ids)
+	}
+
+	_, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления уведомлений: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNotification) GetPreferences(ctx context.Context, userID int64) (model.NotificationPreferences, error) {
+	var prefs model.NotificationPreferences
+	err := pgxscan.Get(ctx, r.pool, &prefs,
+		`SELECT user_id, language, email_enabled, muted_types, updated_at FROM notification_preferences WHERE user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.NotificationPreferences{}, ErrPreferencesNotFound
+		}
+		return model.NotificationPreferences{}, fmt.Errorf("ошибка получения настроек уведомлений: %w", err)
+	}
+	return prefs, nil
+}
+
+func (r *PostgresNotification) UpsertPreferences(ctx context.Context, prefs model.NotificationPreferences) error {
+	mutedTypes := prefs.MutedTypes
+	if mutedTypes == nil {
+		mutedTypes = []string{}
+	}
+
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO notification_preferences (user_id, language, email_enabled, muted_types, updated_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (user_id) DO UPDATE
+        SET language = EXCLUDED.language, email_enabled = EXCLUDED.email_enabled,
+            muted_types = EXCLUDED.muted_types, updated_at = EXCLUDED.updated_at`,
+		prefs.UserID,
+		prefs.Language,
+		prefs.EmailEnabled,
+		mutedTypes,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения настроек уведомлений: %w", err)
+	}
+	return nil
+}
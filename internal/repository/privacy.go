@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrErasureRequestNotFound определяет ошибку, которая возникает, когда ожидающий запрос на удаление не найден
+	ErrErasureRequestNotFound = errors.New("запрос на удаление данных не найден")
+	// ErrErasureAlreadyRequested определяет ошибку, которая возникает при повторном запросе на удаление
+	ErrErasureAlreadyRequested = errors.New("удаление данных уже запрошено")
+)
+
+const erasureRequestColumns = "id, user_id, status, requested_at, scheduled_at, cancelled_at, completed_at"
+
+// exportSections - запросы разделов выгрузки персональных данных. Секреты (хеши паролей,
+// токенов и ключей, секреты 2FA) и ключи хранения файлов в выгрузку не попадают.
+// Результаты проверок по санкционным спискам не раскрываются пользователю
+var exportSections = map[string]string{
+	"account": `SELECT id, username, email, role, email_verified_at, kyc_status, country, created_at, updated_at
+        FROM users WHERE id = $1`,
+	"investments": `SELECT id, project_id, amount, invested_at FROM investments WHERE user_id = $1`,
+	"projects": `SELECT id, status, name, description, amount_requested, amount_raised, deadline_at, created_at
+        FROM projects WHERE owner_id = $1`,
+	"wallets":  `SELECT id, address, chain_id, created_at FROM account_wallets WHERE user_id = $1`,
+	"sessions": `SELECT id, device, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at FROM sessions WHERE user_id = $1`,
+	"api_keys": `SELECT id, name, prefix, scopes, expires_at, last_used_at, last_used_ip, revoked_at, created_at
+        FROM api_keys WHERE user_id = $1`,
+	"two_factor":               `SELECT enabled_at, created_at FROM account_mfa WHERE user_id = $1`,
+	"notifications":            `SELECT id, type, title, body, read_at, created_at FROM notifications WHERE user_id = $1`,
+	"notification_preferences": `SELECT language, email_enabled, muted_types, updated_at FROM notification_preferences WHERE user_id = $1`,
+	"kyc_applications": `SELECT id, status, provider, reason, submitted_at, reviewed_at
+        FROM kyc_applications WHERE user_id = $1`,
+	"kyc_documents": `SELECT id, application_id, kind, file_name, content_type, size, sha256, uploaded_at
+        FROM kyc_documents WHERE user_id = $1`,
+	"audit_events": `SELECT id, action, target_type, target_id, changes, ip, created_at FROM audit_events
+        WHERE actor_id = $1 OR (target_type = 'account' AND target_id = $1)`,
+	"erasure_requests": `SELECT id, status, requested_at, scheduled_at, cancelled_at, completed_at
+        FROM erasure_requests WHERE user_id = $1`,
+}
+
+type PostgresPrivacy struct {
+	pool *db.Pool
+}
+
+func NewPostgresPrivacy(pool *db.Pool) *PostgresPrivacy {
+	return &PostgresPrivacy{
+		pool: pool,
+	}
+}
+
+// Export возвращает все хранимые данные пользователя по разделам в виде JSON-массивов.
+// Разделы читаются в одной транзакции, чтобы выгрузка была согласованной
+func (r *PostgresPrivacy) Export(ctx context.Context, userID int64) (map[string]json.RawMessage, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	sections := make(map[string]json.RawMessage, len(exportSections))
+	for name, query := range exportSections {
+		var data []byte
+		err = tx.QueryRow(ctx, `SELECT COALESCE(json_agg(t), '[]'::json) FROM (`+query+`) t`, userID).Scan(&data)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка выгрузки раздела %s: %w", name, err)
+		}
+		sections[name] = data
+	}
+
+	return sections, tx.Commit(ctx)
+}
+
+// ListDocuments возвращает все документы KYC пользователя
+func (r *PostgresPrivacy) ListDocuments(ctx context.Context, userID int64) ([]model.KYCDocument, error) {
+	var docs []model.KYCDocument
+	err := pgxscan.Select(ctx, r.pool, &docs,
+		`SELECT `+kycDocumentColumns+` FROM kyc_documents WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения документов KYC: %w", err)
+	}
+	return docs, nil
+}
+
+// CreateErasureRequest создает запрос на удаление данных, исполняемый в scheduledAt
+func (r *PostgresPrivacy) CreateErasureRequest(ctx context.Context, userID int64, scheduledAt time.Time) (model.ErasureRequest, error) {
+	var req model.ErasureRequest
+	err := pgxscan.Get(ctx, r.pool, &req, `
+        INSERT INTO erasure_requests (user_id, status, requested_at, scheduled_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+        RETURNING `+erasureRequestColumns,
+		userID, model.ErasurePending, time.Now(), scheduledAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErasureRequest{}, ErrErasureAlreadyRequested
+		}
+		return model.ErasureRequest{}, fmt.Errorf("ошибка создания запроса на удаление данных: %w", err)
+	}
+	return req, nil
+}
+
+// GetPendingErasure возвращает ожидающий запрос пользователя на удаление данных
+func (r *PostgresPrivacy) GetPendingErasure(ctx context.Context, userID int64) (model.ErasureRequest, error) {
+	var req model.ErasureRequest
+	err := pgxscan.Get(ctx, r.pool, &req,
+		`SELECT `+erasureRequestColumns+` FROM erasure_requests WHERE user_id = $1 AND status = $2`,
+		userID, model.ErasurePending)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErasureRequest{}, ErrErasureRequestNotFound
+		}
+		return model.ErasureRequest{}, fmt.Errorf("ошибка получения запроса на удаление данных: %w", err)
+	}
+	return req, nil
+}
+
+// CancelErasure отменяет ожидающий запрос пользователя на удаление данных
+func (r *PostgresPrivacy) CancelErasure(ctx context.Context, userID int64) (model.ErasureRequest, error) {
+	var req model.ErasureRequest
+	err := pgxscan.Get(ctx, r.pool, &req, `
+        UPDATE erasure_requests SET status = $3, cancelled_at = $4
+        WHERE user_id = $1 AND status = $2
+        RETURNING `+erasureRequestColumns,
+		userID, model.ErasurePending, model.ErasureCancelled, time.Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErasureRequest{}, ErrErasureRequestNotFound
+		}
+		return model.ErasureRequest{}, fmt.Errorf("ошибка отмены запроса на удаление данных: %w", err)
+	}
+	return req, nil
+}
+
+// ListDueErasures возвращает ожидающие запросы, срок исполнения которых наступил
+func (r *PostgresPrivacy) ListDueErasures(ctx context.Context, now time.Time) ([]model.ErasureRequest, error) {
+	var reqs []model.ErasureRequest
+	err := pgxscan.Select(ctx, r.pool, &reqs,
+		`SELECT `+erasureRequestColumns+` FROM erasure_requests WHERE status = $1 AND scheduled_at <= $2 ORDER BY scheduled_at`,
+		model.ErasurePending, now)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения запросов на удаление данных: %w", err)
+	}
+	return reqs, nil
+}
+
+// Erase исполняет запрос на удаление: обезличивает пользователя и удаляет данные, не нужные
+// для финансовой и регуляторной отчетности. Инвестиции, проекты, решения KYC, результаты
+// проверок по санкционным спискам и журнал аудита сохраняются. Возвращает ключи файлов
+// документов KYC, которые нужно удалить из хранилища после фиксации транзакции
+func (r *PostgresPrivacy) Erase(ctx context.Context, requestID int64) ([]string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Запрос мог быть отменен после выборки, поэтому статус проверяется под блокировкой
+	var userID int64
+	err = tx.QueryRow(ctx,
+		"SELECT user_id FROM erasure_requests WHERE id = $1 AND status = $2 FOR UPDATE",
+		requestID, model.ErasurePending).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrErasureRequestNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения запроса на удаление данных: %w", err)
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(ctx, `
+        UPDATE users
+        SET username = 'deleted-' || id,
+            email = 'deleted-' || id || '@erased.invalid',
+            password_hash = '!',
+            country = '',
+            email_verified_at = NULL,
+            erased_at = $2,
+            updated_at = $2,
+            version = version + 1
+        WHERE id = $1`, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обезличивания пользователя: %w", err)
+	}
+
+	for _, table := range []string{
+		"sessions", "api_keys", "account_tokens", "account_mfa", "recovery_codes",
+		"notifications", "notification_preferences", "account_wallets",
+	} {
+		if _, err = tx.Exec(ctx, "DELETE FROM "+table+" WHERE user_id = $1", userID); err != nil {
+			return nil, fmt.Errorf("ошибка удаления данных из %s: %w", table, err)
+		}
+	}
+
+	rows, err := tx.Query(ctx, "DELETE FROM kyc_documents WHERE user_id = $1 RETURNING storage_key", userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка удаления документов KYC: %w", err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("ошибка удаления документов KYC: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE erasure_requests SET status = $2, completed_at = $3 WHERE id = $1",
+		requestID, model.ErasureCompleted, now)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления запроса на удаление данных: %w", err)
+	}
+
+	return keys, tx.Commit(ctx)
+}
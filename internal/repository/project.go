@@ -26,17 +26,20 @@ func NewPostgresProject(pool *db.Pool) *PostgresProject {
 	}
 }
 
-func (r *PostgresProject) Create(ctx context.Context, project model.Project) error {
+// Create сохраняет новый проект и возвращает его ID
+func (r *PostgresProject) Create(ctx context.Context, project model.Project) (int64, error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrProjectTxStart, err)
+		return 0, fmt.Errorf("%w: %w", ErrProjectTxStart, err)
 	}
 	defer tx.Rollback(ctx)
 
 	now := time.Now()
-	_, err = tx.Exec(ctx, `
+	var id int64
+	err = tx.QueryRow(ctx, `
         INSERT INTO projects (owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id`,
 		project.OwnerID,
 		project.Status,
 		project.Name,
@@ -45,14 +48,15 @@ func (r *PostgresProject) Create(ctx context.Context, project model.Project) err
 		project.AmountRaised,
 		project.DeadlineAt,
 		now,
-	)
+	).Scan(&id)
 	if err != nil {
-		return fmt.Errorf("ошибка создания проекта: %w", err)
+		return 0, fmt.Errorf("ошибка создания проекта: %w", err)
 	}
 
-	return tx.Commit(ctx)
+	return id, tx.Commit(ctx)
 }
 
+// Update сохраняет изменения проекта, если его версия совпадает с project.Version
 func (r *PostgresProject) Update(ctx context.Context, project model.Project) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
@@ -60,18 +64,13 @@ func (r *PostgresProject) Update(ctx context.Context, project model.Project) err
 	}
 	defer tx.Rollback(ctx)
 
-	var exists bool
-	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 FOR UPDATE)", project.ID).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("ошибка проверки существования проекта: %w", err)
-	}
-	if !exists {
-		return ErrProjectNotFound
+	if err = lockVersion(ctx, tx, "projects", project.ID, project.Version, ErrProjectNotFound); err != nil {
+		return err
 	}
 
 	_, err = tx.Exec(ctx, `
         UPDATE projects
-        SET status = $2, name = $3, description = $4, amount_requested = $5, deadline_at = $6
+        SET status = $2, name = $3, description = $4, amount_requested = $5, deadline_at = $6, version = version + 1
         WHERE id = $1`,
 		project.ID,
 		project.Status,
@@ -87,23 +86,88 @@ func (r *PostgresProject) Update(ctx context.Context, project model.Project) err
 	return tx.Commit(ctx)
 }
 
-func (r *PostgresProject) Delete(ctx context.Context, id int64) error {
+// UpdateStatus меняет статус проекта, если его версия совпадает с version
+func (r *PostgresProject) UpdateStatus(ctx context.Context, id int64, status string, version int64) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrProjectTxStart, err)
 	}
 	defer tx.Rollback(ctx)
 
-	var exists bool
-	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 FOR UPDATE)", id).Scan(&exists)
+	if err = lockVersion(ctx, tx, "projects", id, version, ErrProjectNotFound); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE projects SET status = $2, version = version + 1 WHERE id = $1", id, status)
 	if err != nil {
-		return fmt.Errorf("ошибка проверки существования проекта: %w", err)
+		return fmt.Errorf("ошибка обновления статуса проекта: %w", err)
 	}
-	if !exists {
-		return ErrProjectNotFound
+
+	return tx.Commit(ctx)
+}
+
+// FailExpired переводит в статус failed одобренные проекты, у которых истек срок сбора,
+// а запрошенная сумма не набрана, и возвращает их
+func (r *PostgresProject) FailExpired(ctx context.Context, now time.Time) ([]model.Project, error) {
+	var projects []model.Project
+	err := pgxscan.Select(ctx, r.pool, &projects, `
+        UPDATE projects
+        SET status = 'failed', version = version + 1
+        WHERE status = 'approved' AND deadline_at < $1 AND amount_raised < amount_requested AND deleted_at IS NULL
+        RETURNING id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at, version`,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка закрытия просроченных проектов: %w", err)
 	}
+	return projects, nil
+}
+
+// CountByStatus возвращает число проектов в каждом статусе. Значение используется только
+// в метриках, поэтому допускается чтение с реплики
+func (r *PostgresProject) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int64  `db:"count"`
+	}
+	err := pgxscan.Select(ctx, r.pool.Reader(db.WithStaleReads(ctx)), &rows,
+		"SELECT status, COUNT(*) AS count FROM projects WHERE deleted_at IS NULL GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета проектов по статусам: %w", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// ListBackerIDs возвращает ID пользователей, инвестировавших в проект
+func (r *PostgresProject) ListBackerIDs(ctx context.Context, projectID int64) ([]int64, error) {
+	var ids []int64
+	err := pgxscan.Select(ctx, r.pool, &ids,
+		`SELECT DISTINCT user_id FROM investments WHERE project_id = $1 AND deleted_at IS NULL`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения инвесторов проекта: %w", err)
+	}
+	return ids, nil
+}
 
-	_, err = tx.Exec(ctx, "DELETE FROM projects WHERE id = $1", id)
+// Delete помечает проект удаленным, если его версия совпадает с version.
+// Данные сохраняются до окончательного удаления в PurgeDeleted
+func (r *PostgresProject) Delete(ctx context.Context, id int64, version int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProjectTxStart, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockVersion(ctx, tx, "projects", id, version, ErrProjectNotFound); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE projects SET deleted_at = $2, version = version + 1 WHERE id = $1", id, time.Now())
 	if err != nil {
 		return fmt.Errorf("ошибка удаления проекта: %w", err)
 	}
@@ -113,8 +177,8 @@ func (r *PostgresProject) Delete(ctx context.Context, id int64) error {
 
 func (r *PostgresProject) GetByID(ctx context.Context, id int64) (model.Project, error) {
 	var project model.Project
-	err := pgxscan.Get(ctx, r.pool, &project,
-		`SELECT id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at FROM projects WHERE id = $1`,
+	err := pgxscan.Get(ctx, r.pool.Reader(ctx), &project,
+		`SELECT id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at, version FROM projects WHERE id = $1 AND deleted_at IS NULL`,
 		id,
 	)
 	if err != nil {
@@ -126,24 +190,42 @@ func (r *PostgresProject) GetByID(ctx context.Context, id int64) (model.Project,
 	return project, nil
 }
 
+// GetProgress возвращает текущий ход сбора средств по проекту
+func (r *PostgresProject) GetProgress(ctx context.Context, id int64) (model.ProjectProgress, error) {
+	var progress model.ProjectProgress
+	err := pgxscan.Get(ctx, r.pool, &progress, `
+        SELECT p.id AS project_id, p.amount_raised, p.status,
+               (SELECT COUNT(DISTINCT user_id) FROM investments WHERE project_id = p.id AND deleted_at IS NULL) AS backers
+        FROM projects p WHERE p.id = $1 AND p.deleted_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ProjectProgress{}, ErrProjectNotFound
+		}
+		return model.ProjectProgress{}, fmt.Errorf("ошибка получения хода сбора средств: %w", err)
+	}
+	return progress, nil
+}
+
 // TODO: Implement GetByIDs method
 
 func (r *PostgresProject) List(ctx context.Context, searchTerm string) ([]model.Project, error) {
 	var projects []model.Project
-	query := `SELECT id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at FROM projects`
+	query := `SELECT id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at, version FROM projects WHERE deleted_at IS NULL`
 	var args []any
 
 	if searchTerm != "" {
-		query += " WHERE (name ILIKE $1 OR description ILIKE $1)"
+		query += " AND (name ILIKE $1 OR description ILIKE $1)"
 		args = append(args, "%"+searchTerm+"%")
 	}
 	query += " ORDER BY created_at DESC"
 
 	var err error
 	if len(args) > 0 {
-		err = pgxscan.Select(ctx, r.pool, &projects, query, args...)
+		err = pgxscan.Select(ctx, r.pool.Reader(ctx), &projects, query, args...)
 	} else {
-		err = pgxscan.Select(ctx, r.pool, &projects, query)
+		err = pgxscan.Select(ctx, r.pool.Reader(ctx), &projects, query)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения списка проектов: %w", err)
@@ -153,7 +235,7 @@ func (r *PostgresProject) List(ctx context.Context, searchTerm string) ([]model.
 
 func (r *PostgresProject) ListByOwnerID(ctx context.Context, id int64, searchTerm string) ([]model.Project, error) {
 	var projects []model.Project
-	query := `SELECT id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at FROM projects WHERE owner_id = $1`
+	query := `SELECT id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at, version FROM projects WHERE owner_id = $1 AND deleted_at IS NULL`
 	var args []any
 
 	if searchTerm != "" {
@@ -164,9 +246,9 @@ func (r *PostgresProject) ListByOwnerID(ctx context.Context, id int64, searchTer
 
 	var err error
 	if len(args) > 0 {
-		err = pgxscan.Select(ctx, r.pool, &projects, query, append([]any{id}, args...)...)
+		err = pgxscan.Select(ctx, r.pool.Reader(ctx), &projects, query, append([]any{id}, args...)...)
 	} else {
-		err = pgxscan.Select(ctx, r.pool, &projects, query, id)
+		err = pgxscan.Select(ctx, r.pool.Reader(ctx), &projects, query, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения списка проектов: %w", err)
@@ -174,6 +256,49 @@ func (r *PostgresProject) ListByOwnerID(ctx context.Context, id int64, searchTer
 	return projects, nil
 }
 
+// ListDeleted возвращает проекты, помеченные удаленными
+func (r *PostgresProject) ListDeleted(ctx context.Context) ([]model.Project, error) {
+	var projects []model.Project
+	err := pgxscan.Select(ctx, r.pool, &projects, `
+        SELECT id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at, deleted_at, version
+        FROM projects WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка удаленных проектов: %w", err)
+	}
+	return projects, nil
+}
+
+// Restore снимает с проекта пометку об удалении
+func (r *PostgresProject) Restore(ctx context.Context, id int64) (model.Project, error) {
+	var project model.Project
+	err := pgxscan.Get(ctx, r.pool, &project, `
+        UPDATE projects SET deleted_at = NULL, version = version + 1
+        WHERE id = $1 AND deleted_at IS NOT NULL
+        RETURNING id, owner_id, status, name, description, amount_requested, amount_raised, deadline_at, created_at, version`,
+		id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Project{}, ErrProjectNotFound
+		}
+		return model.Project{}, fmt.Errorf("ошибка восстановления проекта: %w", err)
+	}
+	return project, nil
+}
+
+// PurgeDeleted окончательно удаляет проекты, помеченные удаленными до before.
+// Проекты, в которые были инвестиции, сохраняются для финансовой отчетности
+func (r *PostgresProject) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	commandTag, err := r.pool.Exec(ctx, `
+        DELETE FROM projects p
+        WHERE p.deleted_at < $1
+          AND NOT EXISTS (SELECT 1 FROM investments WHERE project_id = p.id)`,
+		before)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка окончательного удаления проектов: %w", err)
+	}
+	return commandTag.RowsAffected(), nil
+}
+
 func (r *PostgresProject) GetPhotosByProjectID(ctx context.Context, projectID int) ([]model.ProjectImage, error) {
 	var photos []model.ProjectImage
 	err := pgxscan.Select(ctx, r.pool, &photos,
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/ratelimit"
+)
+
+// PostgresRateLimit хранит корзины ограничения частоты запросов в PostgreSQL,
+// чтобы лимиты были общими для всех реплик
+type PostgresRateLimit struct {
+	pool *db.Pool
+}
+
+func NewPostgresRateLimit(pool *db.Pool) *PostgresRateLimit {
+	return &PostgresRateLimit{
+		pool: pool,
+	}
+}
+
+// Take атомарно берет токен из корзины ключа
+func (r *PostgresRateLimit) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	initial := ratelimit.NewBucket(limit, now)
+
+	// Создаем полную корзину, если ее нет, и блокируем строку до конца транзакции
+	var bucket ratelimit.Bucket
+	err = tx.QueryRow(ctx, `
+        INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at)
+        VALUES ($1, $2, $3, $3)
+        ON CONFLICT (key) DO UPDATE SET key = EXCLUDED.key
+        RETURNING tokens, updated_at`,
+		key, initial.Tokens, now,
+	).Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("ошибка получения корзины лимита: %w", err)
+	}
+
+	bucket, result := bucket.Take(limit, now)
+
+	_, err = tx.Exec(ctx,
+		"UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3, full_at = $4 WHERE key = $1",
+		key, bucket.Tokens, bucket.UpdatedAt, bucket.FullAt(limit))
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("ошибка обновления корзины лимита: %w", err)
+	}
+
+	return result, tx.Commit(ctx)
+}
+
+// Prune удаляет полностью восполненные корзины
+func (r *PostgresRateLimit) Prune(ctx context.Context) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM rate_limit_buckets WHERE full_at < $1", time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка удаления корзин лимита: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrScreeningHitNotFound определяет ошибку, которая возникает, когда совпадение не найдено
+	ErrScreeningHitNotFound = errors.New("совпадение со списком не найдено")
+	// ErrScreeningHitNotPending определяет ошибку, которая возникает при повторном решении по совпадению
+	ErrScreeningHitNotPending = errors.New("совпадение со списком уже рассмотрено")
+)
+
+const screeningHitColumns = "id, user_id, kind, value, list_source, entry_id, entry_name, score, trigger, status, reviewer_id, created_at, reviewed_at"
+
+// screeningSubjectQuery выбирает пользователей с адресами привязанных кошельков
+const screeningSubjectQuery = `
+    SELECT u.id, u.username, COALESCE(array_agg(w.address) FILTER (WHERE w.address IS NOT NULL), '{}') AS addresses
+    FROM users u
+    LEFT JOIN account_wallets w ON w.user_id = u.id`
+
+type PostgresScreening struct {
+	pool *db.Pool
+}
+
+func NewPostgresScreening(pool *db.Pool) *PostgresScreening {
+	return &PostgresScreening{
+		pool: pool,
+	}
+}
+
+// RecordHits сохраняет новые совпадения и возвращает их. Уже известные совпадения,
+// в том числе отклоненные, пропускаются
+func (r *PostgresScreening) RecordHits(ctx context.Context, hits []model.ScreeningHit) ([]model.ScreeningHit, error) {
+	if len(hits) == 0 {
+		return nil, nil
+	}
+
+	batch := &pgx.Batch{}
+	now := time.Now()
+	for _, hit := range hits {
+		batch.Queue(`
+            INSERT INTO screening_hits (user_id, kind, value, list_source, entry_id, entry_name, score, trigger, status, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+            ON CONFLICT (user_id, kind, value, list_source, entry_id) DO NOTHING
+            RETURNING `+screeningHitColumns,
+			hit.UserID, hit.Kind, hit.Value, hit.ListSource, hit.EntryID, hit.EntryName, hit.Score,
+			hit.Trigger, model.ScreeningPending, now,
+		)
+	}
+
+	results := r.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	var recorded []model.ScreeningHit
+	for range hits {
+		rows, err := results.Query()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сохранения совпадения со списком: %w", err)
+		}
+		var inserted []model.ScreeningHit
+		if err = pgxscan.ScanAll(&inserted, rows); err != nil {
+			return nil, fmt.Errorf("ошибка сохранения совпадения со списком: %w", err)
+		}
+		recorded = append(recorded, inserted...)
+	}
+	return recorded, nil
+}
+
+// HasOpenHits проверяет, есть ли у пользователя нерассмотренные или подтвержденные совпадения
+func (r *PostgresScreening) HasOpenHits(ctx context.Context, userID int64) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM screening_hits WHERE user_id = $1 AND status IN ($2, $3))",
+		userID, model.ScreeningPending, model.ScreeningConfirmed,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки совпадений со списками: %w", err)
+	}
+	return exists, nil
+}
+
+// ListHits возвращает совпадения с заданным статусом, начиная с самых старых
+func (r *PostgresScreening) ListHits(ctx context.Context, status string) ([]model.ScreeningHit, error) {
+	var hits []model.ScreeningHit
+	err := pgxscan.Select(ctx, r.pool, &hits,
+		`SELECT `+screeningHitColumns+` FROM screening_hits WHERE status = $1 ORDER BY created_at, id`, status)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения совпадений со списками: %w", err)
+	}
+	return hits, nil
+}
+
+// Review выносит решение по нерассмотренному совпадению
+func (r *PostgresScreening) Review(ctx context.Context, id int64, status string, reviewerID int64) (model.ScreeningHit, error) {
+	var hit model.ScreeningHit
+	err := pgxscan.Get(ctx, r.pool, &hit, `
+        UPDATE screening_hits SET status = $2, reviewer_id = $3, reviewed_at = $4
+        WHERE id = $1 AND status = $5
+        RETURNING `+screeningHitColumns,
+		id, status, reviewerID, time.Now(), model.ScreeningPending,
+	)
+	if err == nil {
+		return hit, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return model.ScreeningHit{}, fmt.Errorf("ошибка обновления совпадения со списком: %w", err)
+	}
+
+	var exists bool
+	if err = r.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM screening_hits WHERE id = $1)", id).Scan(&exists); err != nil {
+		return model.ScreeningHit{}, fmt.Errorf("ошибка проверки существования совпадения: %w", err)
+	}
+	if exists {
+		return model.ScreeningHit{}, ErrScreeningHitNotPending
+	}
+	return model.ScreeningHit{}, ErrScreeningHitNotFound
+}
+
+// GetSubject возвращает имя пользователя и адреса его кошельков для проверки
+func (r *PostgresScreening) GetSubject(ctx context.Context, userID int64) (model.ScreeningSubject, error) {
+	var subject model.ScreeningSubject
+	err := pgxscan.Get(ctx, r.pool, &subject, screeningSubjectQuery+` WHERE u.id = $1 GROUP BY u.id`, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ScreeningSubject{}, ErrUserNotFound
+		}
+		return model.ScreeningSubject{}, fmt.Errorf("ошибка получения данных пользователя для проверки: %w", err)
+	}
+	return subject, nil
+}
+
+// ListSubjects возвращает пользователей с ID больше afterID для постраничной повторной проверки
+func (r *PostgresScreening) ListSubjects(ctx context.Context, afterID int64, limit int) ([]model.ScreeningSubject, error) {
+	var subjects []model.ScreeningSubject
+	err := pgxscan.Select(ctx, r.pool, &subjects,
+		screeningSubjectQuery+` WHERE u.id > $1 GROUP BY u.id ORDER BY u.id LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения пользователей для проверки: %w", err)
+	}
+	return subjects, nil
+}
+
+// LastRunVersion возвращает версию списков последней завершенной повторной проверки
+func (r *PostgresScreening) LastRunVersion(ctx context.Context) (string, error) {
+	var version string
+	err := r.pool.QueryRow(ctx, "SELECT list_version FROM screening_runs ORDER BY id DESC LIMIT 1").Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("ошибка получения последней проверки: %w", err)
+	}
+	return version, nil
+}
+
+// CreateRun сохраняет итоги завершенной повторной проверки
+func (r *PostgresScreening) CreateRun(ctx context.Context, run model.ScreeningRun) error {
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO screening_runs (list_version, screened, hits, started_at, completed_at)
+        VALUES ($1, $2, $3, $4, $5)`,
+		run.ListVersion, run.Screened, run.Hits, run.StartedAt, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения итогов проверки: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrSessionNotFound определяет ошибку, которая возникает, когда сессия не найдена, отозвана или истекла
+	ErrSessionNotFound = errors.New("сессия не найдена")
+)
+
+const sessionColumns = "id, user_id, device, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at"
+
+type PostgresSession struct {
+	pool *db.Pool
+}
+
+func NewPostgresSession(pool *db.Pool) *PostgresSession {
+	return &PostgresSession{
+		pool: pool,
+	}
+}
+
+// Create сохраняет новую сессию и возвращает ее ID
+func (r *PostgresSession) Create(ctx context.Context, session model.Session) (int64, error) {
+	now := time.Now()
+
+	var id int64
+	err := r.pool.QueryRow(ctx, `
+        INSERT INTO sessions (user_id, device, ip, user_agent, created_at, last_seen_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $5, $6)
+        RETURNING id`,
+		session.UserID,
+		session.Device,
+		session.IP,
+		session.UserAgent,
+		now,
+		session.ExpiresAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания сессии: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetActive возвращает действующую сессию пользователя
+func (r *PostgresSession) GetActive(ctx context.Context, id int64, userID int64) (model.Session, error) {
+	var session model.Session
+	err := pgxscan.Get(ctx, r.pool, &session,
+		`SELECT `+sessionColumns+` FROM sessions
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > $3`,
+		id, userID, time.Now(),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Session{}, ErrSessionNotFound
+		}
+		return model.Session{}, fmt.Errorf("ошибка получения сессии: %w", err)
+	}
+	return session, nil
+}
+
+// Touch обновляет время последней активности и IP-адрес сессии
+func (r *PostgresSession) Touch(ctx context.Context, id int64, ip string) error {
+	_, err := r.pool.Exec(ctx,
+		"UPDATE sessions SET last_seen_at = $2, ip = $3 WHERE id = $1 AND revoked_at IS NULL",
+		id, time.Now(), ip)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления сессии: %w", err)
+	}
+	return nil
+}
+
+// Extend продлевает действующую сессию до expiresAt
+func (r *PostgresSession) Extend(ctx context.Context, id int64, userID int64, expiresAt time.Time) error {
+	commandTag, err := r.pool.Exec(ctx,
+		"UPDATE sessions SET expires_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		id, userID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("ошибка продления сессии: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// ListActive возвращает действующие сессии пользователя, начиная с последней активной
+func (r *PostgresSession) ListActive(ctx context.Context, userID int64) ([]model.Session, error) {
+	var sessions []model.Session
+	err := pgxscan.Select(ctx, r.pool, &sessions,
+		`SELECT `+sessionColumns+` FROM sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+        ORDER BY last_seen_at DESC`,
+		userID, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка сессий: %w", err)
+	}
+	return sessions, nil
+}
+
+// Revoke отзывает сессию пользователя
+func (r *PostgresSession) Revoke(ctx context.Context, id int64, userID int64) error {
+	commandTag, err := r.pool.Exec(ctx,
+		"UPDATE sessions SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		id, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва сессии: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllExcept отзывает все сессии пользователя, кроме указанной. Нулевой exceptID отзывает все
+func (r *PostgresSession) RevokeAllExcept(ctx context.Context, userID int64, exceptID int64) error {
+	_, err := r.pool.Exec(ctx,
+		"UPDATE sessions SET revoked_at = $3 WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL",
+		userID, exceptID, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва сессий: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+)
+
+var (
+	// ErrNonceInvalid определяет ошибку, которая возникает, когда nonce не выдавался, уже использован или истек
+	ErrNonceInvalid = errors.New("nonce недействителен")
+)
+
+type PostgresSIWENonce struct {
+	pool *db.Pool
+}
+
+func NewPostgresSIWENonce(pool *db.Pool) *PostgresSIWENonce {
+	return &PostgresSIWENonce{
+		pool: pool,
+	}
+}
+
+// Create сохраняет выданный nonce и удаляет истекшие
+func (r *PostgresSIWENonce) Create(ctx context.Context, nonce string, expiresAt time.Time) error {
+	now := time.Now()
+
+	if _, err := r.pool.Exec(ctx, "DELETE FROM siwe_nonces WHERE expires_at < $1", now); err != nil {
+		return fmt.Errorf("ошибка удаления истекших nonce: %w", err)
+	}
+
+	_, err := r.pool.Exec(ctx,
+		"INSERT INTO siwe_nonces (nonce, expires_at, created_at) VALUES ($1, $2, $3)",
+		nonce, expiresAt, now)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения nonce: %w", err)
+	}
+	return nil
+}
+
+// Consume атомарно помечает nonce использованным. Повторное использование возвращает ErrNonceInvalid
+func (r *PostgresSIWENonce) Consume(ctx context.Context, nonce string) error {
+	now := time.Now()
+
+	commandTag, err := r.pool.Exec(ctx, `
+        UPDATE siwe_nonces
+        SET used_at = $2
+        WHERE nonce = $1 AND used_at IS NULL AND expires_at > $2`,
+		nonce, now)
+	if err != nil {
+		return fmt.Errorf("ошибка использования nonce: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrNonceInvalid
+	}
+	return nil
+}
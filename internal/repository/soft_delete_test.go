@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+func accountID(acc model.Account) int64       { return acc.ID }
+func investmentID(inv model.Investment) int64 { return inv.ID }
+
+func TestAccountSoftDeleteRoundTrip(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewPostgresAccount(pool)
+	acc := createTestAccount(t, repo, "investor")
+
+	if err := repo.Delete(ctx, acc.ID, acc.Version+1); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Delete with stale version: error = %v, want ErrVersionConflict", err)
+	}
+	if err := repo.Delete(ctx, acc.ID, acc.Version); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := repo.GetByID(ctx, acc.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetByID after delete: error = %v, want ErrUserNotFound", err)
+	}
+	deleted, err := repo.ListDeleted(ctx)
+	if err != nil {
+		t.Fatalf("ListDeleted: %v", err)
+	}
+	if !containsID(deleted, acc.ID, accountID) {
+		t.Fatalf("ListDeleted does not contain account %d", acc.ID)
+	}
+
+	restored, err := repo.Restore(ctx, acc.ID)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.Version != acc.Version+2 {
+		t.Fatalf("version after restore = %d, want %d", restored.Version, acc.Version+2)
+	}
+	if _, err = repo.GetByID(ctx, acc.ID); err != nil {
+		t.Fatalf("GetByID after restore: %v", err)
+	}
+	if deleted, _ = repo.ListDeleted(ctx); containsID(deleted, acc.ID, accountID) {
+		t.Fatalf("ListDeleted still contains account %d after restore", acc.ID)
+	}
+	if _, err = repo.Restore(ctx, acc.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("second Restore: error = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestInvestmentSoftDeleteRoundTrip(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	accounts := NewPostgresAccount(pool)
+	projects := NewPostgresProject(pool)
+	repo := NewPostgresInvestment(pool)
+
+	owner := createTestAccount(t, accounts, "startup")
+	investor := createTestAccount(t, accounts, "investor")
+	project := createTestProject(t, projects, owner.ID)
+
+	id, err := repo.Create(ctx, model.Investment{UserID: investor.ID, ProjectID: project.ID, Amount: decimal.NewFromInt(250)}, allowInvestment)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	investment, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+
+	raised := func() decimal.Decimal {
+		t.Helper()
+		p, err := projects.GetByID(ctx, project.ID)
+		if err != nil {
+			t.Fatalf("get project: %v", err)
+		}
+		return p.AmountRaised
+	}
+	if got := raised(); !got.Equal(decimal.NewFromInt(250)) {
+		t.Fatalf("amount raised after create = %s, want 250", got)
+	}
+
+	if err = repo.Delete(ctx, id, investment.Version); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := raised(); !got.IsZero() {
+		t.Fatalf("amount raised after delete = %s, want 0", got)
+	}
+	deleted, err := repo.ListDeleted(ctx)
+	if err != nil {
+		t.Fatalf("ListDeleted: %v", err)
+	}
+	if !containsID(deleted, id, investmentID) {
+		t.Fatalf("ListDeleted does not contain investment %d", id)
+	}
+
+	if _, err = repo.Restore(ctx, id, allowInvestment); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := raised(); !got.Equal(decimal.NewFromInt(250)) {
+		t.Fatalf("amount raised after restore = %s, want 250", got)
+	}
+	if deleted, _ = repo.ListDeleted(ctx); containsID(deleted, id, investmentID) {
+		t.Fatalf("ListDeleted still contains investment %d after restore", id)
+	}
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrTwoFactorNotFound определяет ошибку, которая возникает, когда пользователь не начинал подключение 2FA
+	ErrTwoFactorNotFound = errors.New("двухфакторная аутентификация не настроена")
+	// ErrTOTPStepUsed определяет ошибку, которая возникает при повторном использовании кода TOTP
+	ErrTOTPStepUsed = errors.New("код уже использован")
+	// ErrRecoveryCodeInvalid определяет ошибку, которая возникает, когда код восстановления не найден или уже использован
+	ErrRecoveryCodeInvalid = errors.New("недействительный код восстановления")
+	// ErrTwoFactorAlreadyEnabled определяет ошибку, которая возникает при повторном подключении 2FA
+	ErrTwoFactorAlreadyEnabled = errors.New("двухфакторная аутентификация уже включена")
+)
+
+type PostgresTwoFactor struct {
+	pool *db.Pool
+}
+
+func NewPostgresTwoFactor(pool *db.Pool) *PostgresTwoFactor {
+	return &PostgresTwoFactor{
+		pool: pool,
+	}
+}
+
+func (r *PostgresTwoFactor) Get(ctx context.Context, userID int64) (model.TwoFactor, error) {
+	var tf model.TwoFactor
+	err := pgxscan.Get(ctx, r.pool, &tf,
+		`SELECT user_id, secret, enabled_at, last_used_step, created_at FROM account_mfa WHERE user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.TwoFactor{}, ErrTwoFactorNotFound
+		}
+		return model.TwoFactor{}, fmt.Errorf("ошибка получения настроек 2FA: %w", err)
+	}
+	return tf, nil
+}
+
+// SavePending сохраняет новый секрет, ожидающий подтверждения. Уже включенную 2FA не перезаписывает
+func (r *PostgresTwoFactor) SavePending(ctx context.Context, userID int64, secret string) error {
+	commandTag, err := r.pool.Exec(ctx, `
+        INSERT INTO account_mfa (user_id, secret, created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE
+        SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at
+        WHERE account_mfa.enabled_at IS NULL`,
+		userID,
+		secret,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения секрета 2FA: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+// Enable включает 2FA и заменяет коды восстановления пользователя
+func (r *PostgresTwoFactor) Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	commandTag, err := tx.Exec(ctx, `
+        UPDATE account_mfa SET enabled_at = $2, last_used_step = $3
+        WHERE user_id = $1 AND enabled_at IS NULL AND last_used_step < $3`,
+		userID, time.Now(), step)
+	if err != nil {
+		return fmt.Errorf("ошибка включения 2FA: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrTOTPStepUsed
+	}
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Disable отключает 2FA и удаляет коды восстановления
+func (r *PostgresTwoFactor) Disable(ctx context.Context, userID int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "DELETE FROM account_mfa WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("ошибка отключения 2FA: %w", err)
+	}
+	if _, err = tx.Exec(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("ошибка удаления кодов восстановления: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseStep фиксирует использованный шаг TOTP. Возвращает ErrTOTPStepUsed, если этот или более поздний шаг уже использован
+func (r *PostgresTwoFactor) UseStep(ctx context.Context, userID int64, step int64) error {
+	commandTag, err := r.pool.Exec(ctx,
+		"UPDATE account_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2",
+		userID, step)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения шага TOTP: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrTOTPStepUsed
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми
+func (r *PostgresTwoFactor) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseRecoveryCode атомарно помечает код восстановления использованным
+func (r *PostgresTwoFactor) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	commandTag, err := r.pool.Exec(ctx, `
+        UPDATE recovery_codes SET used_at = $3
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка использования кода восстановления: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("ошибка удаления кодов восстановления: %w", err)
+	}
+
+	now := time.Now()
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(ctx,
+			"INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)",
+			userID, hash, now)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения кода восстановления: %w", err)
+		}
+	}
+	return nil
+}
@@ -1,14 +1,50 @@
 package repository
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/jackc/pgx/v5"
 )
 
+// ErrVersionConflict определяет ошибку, которая возникает, когда запись изменили после того, как ее прочитал клиент
+var ErrVersionConflict = errors.New("запись была изменена другим запросом")
+
+// lockVersion блокирует неудаленную запись таблицы до конца транзакции и сверяет ее версию с ожидаемой
+func lockVersion(ctx context.Context, tx pgx.Tx, table string, id int64, version int64, notFound error) error {
+	var current int64
+	err := tx.QueryRow(ctx, "SELECT version FROM "+table+" WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound
+		}
+		return fmt.Errorf("ошибка проверки версии записи: %w", err)
+	}
+	if current != version {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// lockRow блокирует неудаленную запись таблицы до конца транзакции
+func lockRow(ctx context.Context, tx pgx.Tx, table string, id int64, notFound error) error {
+	var exists bool
+	err := tx.QueryRow(ctx, "SELECT true FROM "+table+" WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound
+		}
+		return fmt.Errorf("ошибка блокировки записи: %w", err)
+	}
+	return nil
+}
+
 // generateSalt генерирует случайную соль заданного размера
 func generateSalt(size int) ([]byte, error) {
 	salt := make([]byte, size)
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrWalletNotFound определяет ошибку, которая возникает, когда кошелек не привязан ни к одному аккаунту
+	ErrWalletNotFound = errors.New("кошелек не найден")
+	// ErrWalletAlreadyLinked определяет ошибку, которая возникает, когда кошелек уже привязан к аккаунту
+	ErrWalletAlreadyLinked = errors.New("кошелек уже привязан к аккаунту")
+)
+
+// unusablePasswordHash не соответствует формату соль:хеш, поэтому вход по паролю невозможен
+const unusablePasswordHash = "!"
+
+const walletColumns = "id, user_id, address, chain_id, created_at"
+
+type PostgresWallet struct {
+	pool *db.Pool
+}
+
+func NewPostgresWallet(pool *db.Pool) *PostgresWallet {
+	return &PostgresWallet{
+		pool: pool,
+	}
+}
+
+// GetByAddress возвращает кошелек по адресу в нижнем регистре
+func (r *PostgresWallet) GetByAddress(ctx context.Context, address string) (model.Wallet, error) {
+	var wallet model.Wallet
+	err := pgxscan.Get(ctx, r.pool, &wallet,
+		`SELECT `+walletColumns+` FROM account_wallets WHERE address = $1`, address)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Wallet{}, ErrWalletNotFound
+		}
+		return model.Wallet{}, fmt.Errorf("ошибка получения кошелька: %w", err)
+	}
+	return wallet, nil
+}
+
+// ListByUserID возвращает кошельки, привязанные к аккаунту
+func (r *PostgresWallet) ListByUserID(ctx context.Context, userID int64) ([]model.Wallet, error) {
+	var wallets []model.Wallet
+	err := pgxscan.Select(ctx, r.pool, &wallets,
+		`SELECT `+walletColumns+` FROM account_wallets WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка кошельков: %w", err)
+	}
+	return wallets, nil
+}
+
+// Link привязывает кошелек к существующему аккаунту
+func (r *PostgresWallet) Link(ctx context.Context, wallet model.Wallet) (model.Wallet, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return model.Wallet{}, fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	wallet, err = insertWallet(ctx, tx, wallet)
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	return wallet, tx.Commit(ctx)
+}
+
+// CreateAccount создает аккаунт инвестора без пароля и привязывает к нему кошелек
+func (r *PostgresWallet) CreateAccount(ctx context.Context, acc model.Account, wallet model.Wallet) (model.Wallet, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return model.Wallet{}, fmt.Errorf("%w: %w", ErrTransactionStartError, err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	err = tx.QueryRow(ctx, `
+        INSERT INTO users (username, email, password_hash, role, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $5)
+        ON CONFLICT (email, role) DO NOTHING
+        RETURNING id`,
+		acc.Username,
+		acc.Email,
+		unusablePasswordHash,
+		acc.Role,
+		now,
+	).Scan(&wallet.UserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Wallet{}, ErrUserAlreadyExists
+		}
+		return model.Wallet{}, fmt.Errorf("ошибка создания пользователя: %w", err)
+	}
+
+	wallet, err = insertWallet(ctx, tx, wallet)
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	return wallet, tx.Commit(ctx)
+}
+
+func insertWallet(ctx context.Context, tx pgx.Tx, wallet model.Wallet) (model.Wallet, error) {
+	err := tx.QueryRow(ctx, `
+        INSERT INTO account_wallets (user_id, address, chain_id, created_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (address) DO NOTHING
+        RETURNING id, created_at`,
+		wallet.UserID,
+		wallet.Address,
+		wallet.ChainID,
+		time.Now(),
+	).Scan(&wallet.ID, &wallet.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Wallet{}, ErrWalletAlreadyLinked
+		}
+		return model.Wallet{}, fmt.Errorf("ошибка привязки кошелька: %w", err)
+	}
+	return wallet, nil
+}
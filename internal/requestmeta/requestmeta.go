@@ -0,0 +1,22 @@
+package requestmeta
+
+import "context"
+
+// Meta - сведения о HTTP-запросе, в рамках которого выполняется операция
+type Meta struct {
+	RequestID string
+	IP        string
+}
+
+type metaKey struct{}
+
+// With сохраняет сведения о запросе в контексте
+func With(ctx context.Context, meta Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// FromContext возвращает сведения о запросе, сохраненные With. Для фоновых задач возвращает пустые сведения
+func FromContext(ctx context.Context) Meta {
+	meta, _ := ctx.Value(metaKey{}).(Meta)
+	return meta
+}
@@ -0,0 +1,85 @@
+package router
+
+import (
+	"sync"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// accessLog writes one structured entry per request after it has been handled. Errors returned
+// by handlers are turned into responses here, so that the entry has the final status.
+// Must be registered after requestMeta and before recover, so that panics are logged as 500
+func accessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if err = c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		fields := []interface{}{
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"ip", c.IP(),
+			"bytes_in", len(c.Request().Body()),
+			"bytes_out", len(c.Response().Body()),
+			"user_agent", c.Get(fiber.HeaderUserAgent),
+		}
+
+		// Error level would attach a stack trace of this middleware, which says nothing about the failure
+		if status >= fiber.StatusInternalServerError {
+			logger.FromContext(c.UserContext()).Warnw("request", fields...)
+		} else {
+			logger.FromContext(c.UserContext()).Infow("request", fields...)
+		}
+		return nil
+	}
+}
+
+// httpMetrics records the duration of every request by route and final status.
+// Must be registered before accessLog, which turns handler errors into responses
+func httpMetrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		metrics.RequestStarted()
+
+		err := c.Next()
+		metrics.RequestFinished(c.Method(), c.Route().Path, c.Response().StatusCode(), time.Since(start).Seconds())
+		return err
+	}
+}
+
+// routeField resolves the matched route pattern each time the request logger is taken: middleware
+// adds the field before Fiber knows which route will handle the request. Once the request is
+// finished the pattern is fixed, because the Fiber context is reused for other requests
+type routeField struct {
+	mu    sync.Mutex
+	c     *fiber.Ctx
+	route string
+}
+
+func (r *routeField) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.c != nil {
+		return r.c.Route().Path
+	}
+	return r.route
+}
+
+func (r *routeField) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.route = r.c.Route().Path
+	r.c = nil
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/CryptoCrowd/internal/handler"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// IdempotencyStore reserves idempotency keys and keeps the responses of requests made with them
+type IdempotencyStore interface {
+	Begin(ctx context.Context, userID int64, key string, fingerprint string) (model.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, id int64, status int, contentType string, body []byte) error
+	Release(ctx context.Context, id int64) error
+}
+
+// idempotent makes a route safe to retry with an Idempotency-Key header. The first request with
+// a key is executed and a successful response stored, a retry with the same key and body gets
+// the stored response. Failed requests release the key, since they did not change anything.
+// Requests without the header are executed as usual. Must be registered after requireUser
+func idempotent(store IdempotencyStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(headerIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+		userID, _ := c.Locals(handler.UserIDKey).(int64)
+
+		record, replay, err := store.Begin(c.UserContext(), userID, key, requestFingerprint(c))
+		switch {
+		case errors.Is(err, service.ErrInvalidIdempotencyKey):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if replay {
+			c.Set(headerIdempotentReplayed, "true")
+			c.Set(fiber.HeaderContentType, record.ResponseContentType)
+			return c.Status(*record.ResponseStatus).Send(record.ResponseBody)
+		}
+
+		completed := false
+		defer func() {
+			// Also runs when the handler panics, so that the key does not stay reserved
+			if completed {
+				return
+			}
+			if err := store.Release(context.WithoutCancel(c.UserContext()), record.ID); err != nil {
+				logger.FromContext(c.UserContext()).Errorf("Failed to release idempotency key of user %d: %v", userID, err)
+			}
+		}()
+
+		if err = c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status < fiber.StatusOK || status >= fiber.StatusMultipleChoices {
+			return nil
+		}
+
+		completed = true
+		err = store.Complete(context.WithoutCancel(c.UserContext()), record.ID, status,
+			string(c.Response().Header.ContentType()), c.Response().Body())
+		if err != nil {
+			// The request has been executed, a retry gets 409 until the reservation times out
+			logger.FromContext(c.UserContext()).Errorf("Failed to store response for idempotency key of user %d: %v", userID, err)
+		}
+		return nil
+	}
+}
+
+// requestFingerprint identifies a request by method, path and body
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method() + " " + c.Path() + "\n"))
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
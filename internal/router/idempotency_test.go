@@ -0,0 +1,187 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CryptoCrowd/internal/handler"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// memoryIdempotencyStore follows the contract of service.Idempotency for a single user
+type memoryIdempotencyStore struct {
+	records map[string]model.IdempotencyKey
+	nextID  int64
+}
+
+func (s *memoryIdempotencyStore) Begin(_ context.Context, _ int64, key string, fingerprint string) (model.IdempotencyKey, bool, error) {
+	existing, ok := s.records[key]
+	switch {
+	case !ok:
+		s.nextID++
+		record := model.IdempotencyKey{ID: s.nextID, Key: key, Fingerprint: fingerprint}
+		s.records[key] = record
+		return record, false, nil
+	case existing.Fingerprint != fingerprint:
+		return model.IdempotencyKey{}, false, service.ErrIdempotencyKeyReused
+	case existing.ResponseStatus == nil:
+		return model.IdempotencyKey{}, false, service.ErrIdempotencyKeyInProgress
+	}
+	return existing, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, id int64, status int, contentType string, body []byte) error {
+	for key, record := range s.records {
+		if record.ID == id {
+			record.ResponseStatus = &status
+			record.ResponseContentType = contentType
+			record.ResponseBody = append([]byte(nil), body...)
+			s.records[key] = record
+		}
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, id int64) error {
+	for key, record := range s.records {
+		if record.ID == id {
+			delete(s.records, key)
+		}
+	}
+	return nil
+}
+
+// newIdempotentApp serves POST /investments through the idempotent middleware. The handler
+// fails while fail is set and counts the requests it executed
+func newIdempotentApp(store IdempotencyStore, fail *bool, executed *int) *fiber.App {
+	app := fiber.New()
+	app.Post("/investments", func(c *fiber.Ctx) error {
+		c.Locals(handler.UserIDKey, int64(7))
+		return c.Next()
+	}, idempotent(store), func(c *fiber.Ctx) error {
+		*executed++
+		if *fail {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "rejected"})
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"id": *executed})
+	})
+	return app
+}
+
+func TestIdempotentMiddleware(t *testing.T) {
+	type request struct {
+		key          string
+		body         string
+		fail         bool
+		wantStatus   int
+		wantBody     string
+		wantReplayed bool
+		wantExecuted int
+	}
+
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "retry gets the stored response",
+			requests: []request{
+				{key: "k1", body: `{"amount":100}`, wantStatus: 201, wantBody: `{"id":1}`, wantExecuted: 1},
+				{key: "k1", body: `{"amount":100}`, wantStatus: 201, wantBody: `{"id":1}`, wantReplayed: true, wantExecuted: 1},
+			},
+		},
+		{
+			name: "key reused with another body",
+			requests: []request{
+				{key: "k1", body: `{"amount":100}`, wantStatus: 201, wantBody: `{"id":1}`, wantExecuted: 1},
+				{key: "k1", body: `{"amount":200}`, wantStatus: 422, wantExecuted: 1},
+			},
+		},
+		{
+			name: "failed request releases the key",
+			requests: []request{
+				{key: "k1", body: `{"amount":100}`, fail: true, wantStatus: 422, wantExecuted: 1},
+				{key: "k1", body: `{"amount":100}`, wantStatus: 201, wantBody: `{"id":2}`, wantExecuted: 2},
+			},
+		},
+		{
+			name: "requests without a key are executed every time",
+			requests: []request{
+				{body: `{"amount":100}`, wantStatus: 201, wantBody: `{"id":1}`, wantExecuted: 1},
+				{body: `{"amount":100}`, wantStatus: 201, wantBody: `{"id":2}`, wantExecuted: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryIdempotencyStore{records: make(map[string]model.IdempotencyKey)}
+			var fail bool
+			var executed int
+			app := newIdempotentApp(store, &fail, &executed)
+
+			for idx, r := range tt.requests {
+				fail = r.fail
+				req := httptest.NewRequest(fiber.MethodPost, "/investments", strings.NewReader(r.body))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				if r.key != "" {
+					req.Header.Set(headerIdempotencyKey, r.key)
+				}
+
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatalf("request %d: %v", idx+1, err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+
+				if resp.StatusCode != r.wantStatus {
+					t.Fatalf("request %d: status = %d, want %d (%s)", idx+1, resp.StatusCode, r.wantStatus, body)
+				}
+				if r.wantBody != "" && string(body) != r.wantBody {
+					t.Fatalf("request %d: body = %s, want %s", idx+1, body, r.wantBody)
+				}
+				if replayed := resp.Header.Get(headerIdempotentReplayed) == "true"; replayed != r.wantReplayed {
+					t.Fatalf("request %d: replayed = %v, want %v", idx+1, replayed, r.wantReplayed)
+				}
+				if executed != r.wantExecuted {
+					t.Fatalf("request %d: handler executed %d times, want %d", idx+1, executed, r.wantExecuted)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotentMiddlewareInProgress(t *testing.T) {
+	const body = `{"amount":100}`
+
+	// The first request with the key has reserved it and is still being executed
+	sum := sha256.Sum256([]byte("POST /investments\n" + body))
+	store := &memoryIdempotencyStore{records: map[string]model.IdempotencyKey{
+		"k1": {ID: 1, Key: "k1", Fingerprint: hex.EncodeToString(sum[:])},
+	}}
+	var fail bool
+	var executed int
+	app := newIdempotentApp(store, &fail, &executed)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/investments", strings.NewReader(body))
+	req.Header.Set(headerIdempotencyKey, "k1")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusConflict || executed != 0 {
+		t.Fatalf("status = %d, executed = %d, want 409 without executing the handler", resp.StatusCode, executed)
+	}
+	if _, ok := store.records["k1"]; !ok {
+		t.Fatal("key of the request in progress was released by the retry")
+	}
+}
@@ -0,0 +1,183 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/db"
+	"github.com/CryptoCrowd/internal/handler"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/requestmeta"
+	"github.com/CryptoCrowd/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// AccountProvider loads accounts for authorization checks
+type AccountProvider interface {
+	GetByID(ctx context.Context, id int64) (model.Account, error)
+}
+
+// Authenticator validates access tokens and the sessions they belong to
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string, ip string) (auth.Claims, error)
+}
+
+// APIKeyAuthenticator validates personal API keys of integrations
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string, ip string) (auth.Claims, error)
+}
+
+const maxRequestIDLength = 128
+
+// requireUser authenticates the caller by the Authorization header. A bearer token of a login
+// session is accepted on every route. An API key is accepted only if the route lists scopes
+// and the key grants all of them. Authenticated requests are also limited per account
+func requireUser(authenticator Authenticator, apiKeys APIKeyAuthenticator, perAccount *limiter, scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "authentication required"})
+		}
+
+		var (
+			claims auth.Claims
+			err    error
+		)
+		switch {
+		case strings.EqualFold(scheme, "Bearer"):
+			claims, err = authenticator.Authenticate(c.UserContext(), token, c.IP())
+		case strings.EqualFold(scheme, "ApiKey"):
+			if len(scopes) == 0 {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "api keys are not accepted on this route"})
+			}
+			claims, err = apiKeys.Authenticate(c.UserContext(), token, c.IP())
+		default:
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "authentication required"})
+		}
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, service.ErrSessionRevoked) || errors.Is(err, service.ErrInvalidAPIKey) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if !claims.HasScopes(scopes...) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":           service.ErrInsufficientAPIScope.Error(),
+				"required_scopes": scopes,
+			})
+		}
+
+		if ok, err := perAccount.allow(c, strconv.FormatInt(claims.UserID, 10)); !ok {
+			return err
+		}
+
+		c.Locals(handler.UserIDKey, claims.UserID)
+		c.SetUserContext(logger.With(auth.WithClaims(c.UserContext(), claims), "user_id", claims.UserID))
+		return c.Next()
+	}
+}
+
+// requestMeta stores the request ID and client address in the request context for auditing,
+// and a logger that adds the request ID and route to every entry written while handling it.
+// A request ID supplied by the client or a proxy in X-Request-ID is kept, otherwise a new one is generated
+func requestMeta() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = utils.UUIDv4()
+		}
+		c.Set(fiber.HeaderXRequestID, requestID)
+
+		route := &routeField{c: c}
+		defer route.finish()
+
+		ctx := requestmeta.With(c.UserContext(), requestmeta.Meta{
+			RequestID: requestID,
+			IP:        c.IP(),
+		})
+		c.SetUserContext(logger.With(ctx, "request_id", requestID, "route", route))
+		return c.Next()
+	}
+}
+
+// requireRole allows the request only if the calling user has one of the given roles.
+// Must be registered after requireUser
+func requireRole(accounts AccountProvider, roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals(handler.UserIDKey).(int64)
+
+		acc, err := accounts.GetByID(c.UserContext(), userID)
+		if err != nil || !slices.Contains(roles, acc.Role) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "access denied"})
+		}
+
+		return c.Next()
+	}
+}
+
+// allowStaleReads lets the handler read from the replica. Only for read-only listings: reads
+// that feed authorization or checks before a write must see the latest data on the primary.
+// Must be registered after the authentication middleware, which reads from the primary
+func allowStaleReads() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(db.WithStaleReads(c.UserContext()))
+		return c.Next()
+	}
+}
+
+// requireIfMatch rejects writes without If-Match holding the ETag of the version the client
+// last read, so that concurrent editors cannot silently overwrite each other's changes.
+// The version is checked against the stored one when the change is saved
+func requireIfMatch() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tag := c.Get(fiber.HeaderIfMatch)
+		if tag == "" {
+			return c.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{"error": "If-Match header is required"})
+		}
+
+		version, ok := handler.ParseETag(tag)
+		if !ok {
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"error": "If-Match does not match the current version"})
+		}
+
+		c.Locals(handler.IfMatchKey, version)
+		return c.Next()
+	}
+}
+
+// requireTwoFactor allows the request only if the caller logged in with a second factor.
+// Must be registered after requireUser
+func requireTwoFactor() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := auth.ClaimsFromContext(c.UserContext())
+		if !ok || claims.MFAAt == 0 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":               "two-factor authentication required",
+				"two_factor_required": true,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// requireClientCert allows the request only over TLS with a client certificate verified against
+// the configured CA. The server asks for certificates without requiring them, so that public
+// routes stay reachable for clients that have none
+func requireClientCert() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		state := c.Context().TLSConnectionState()
+		if state == nil || len(state.VerifiedChains) == 0 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "client certificate required"})
+		}
+
+		return c.Next()
+	}
+}
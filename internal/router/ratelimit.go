@@ -0,0 +1,87 @@
+package router
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+)
+
+// RateLimits configures the token bucket limits enforced by the router.
+// Policies may be changed while the server is running
+type RateLimits struct {
+	Store ratelimit.Store
+	// IP limits every API request per client address
+	IP *ratelimit.Policy
+	// Account limits requests of an authenticated account across all its clients
+	Account *ratelimit.Policy
+	// Auth and Accounts are stricter per address limits of brute-force prone route groups
+	Auth     *ratelimit.Policy
+	Accounts *ratelimit.Policy
+}
+
+// limiter takes tokens from the buckets of one policy
+type limiter struct {
+	store  ratelimit.Store
+	policy *ratelimit.Policy
+	name   string
+}
+
+func newLimiter(store ratelimit.Store, policy *ratelimit.Policy, name string) *limiter {
+	return &limiter{store: store, policy: policy, name: name}
+}
+
+// allow takes a token for the key and sets the RateLimit headers. If the request is rejected,
+// the 429 response is already written and allow returns false. Store failures let requests through
+func (l *limiter) allow(c *fiber.Ctx, key string) (bool, error) {
+	result, err := l.store.Take(c.UserContext(), l.name+":"+key, l.policy.Limit())
+	if err != nil {
+		logger.FromContext(c.UserContext()).Errorf("Rate limit store failed for %s: %v", l.name, err)
+		return true, nil
+	}
+
+	if !result.Allowed {
+		setRateLimitHeaders(c, result, true)
+		c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+		return false, c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many requests"})
+	}
+
+	setRateLimitHeaders(c, result, false)
+	return true, nil
+}
+
+// limitByIP rejects requests of client addresses that exhausted their bucket
+func limitByIP(l *limiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if ok, err := l.allow(c, c.IP()); !ok {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// setRateLimitHeaders reports the most restrictive of the limits applied to the request
+func setRateLimitHeaders(c *fiber.Ctx, result ratelimit.Result, force bool) {
+	if current := c.GetRespHeader(headerRateLimitRemaining); current != "" && !force {
+		if remaining, err := strconv.Atoi(current); err == nil && remaining <= result.Remaining {
+			return
+		}
+	}
+
+	c.Set(headerRateLimitLimit, strconv.Itoa(result.Limit))
+	c.Set(headerRateLimitRemaining, strconv.Itoa(result.Remaining))
+	c.Set(headerRateLimitReset, strconv.FormatInt(ceilSeconds(result.Reset), 10))
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
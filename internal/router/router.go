@@ -1,18 +1,58 @@
 package router
 
 import (
+	"time"
+
 	"github.com/CryptoCrowd/internal/handler"
+	"github.com/CryptoCrowd/internal/model"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/websocket/v2"
 )
 
+// Handlers groups the HTTP handlers served by the router
+type Handlers struct {
+	Account      *handler.AccountHandler
+	Project      *handler.ProjectHandler
+	Investment   *handler.InvestmentHandler
+	Notification *handler.NotificationHandler
+	Realtime     *handler.RealtimeHandler
+	Auth         *handler.AuthHandler
+	TwoFactor    *handler.TwoFactorHandler
+	Session      *handler.SessionHandler
+	Wallet       *handler.WalletHandler
+	APIKey       *handler.APIKeyHandler
+	Audit        *handler.AuditHandler
+	KYC          *handler.KYCHandler
+	Screening    *handler.ScreeningHandler
+	Privacy      *handler.PrivacyHandler
+	Runtime      *handler.RuntimeHandler
+	Health       *handler.HealthHandler
+}
+
+// Options holds the HTTP server settings applied to the Fiber app
+type Options struct {
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	BodyLimit    int // in bytes
+	// Client addresses are taken from ProxyHeader only on requests coming from TrustedProxies
+	TrustedProxies []string
+	ProxyHeader    string
+	// AdminClientCert requires a verified TLS client certificate on administration routes
+	AdminClientCert bool
+}
+
 // SetupRouter configures the Fiber router with all routes
 func SetupRouter(
-	accountHandler *handler.AccountHandler,
-	projectHandler *handler.ProjectHandler,
-	investmentHandler *handler.InvestmentHandler,
+	h Handlers,
+	accountProvider AccountProvider,
+	authenticator Authenticator,
+	apiKeys APIKeyAuthenticator,
+	limits RateLimits,
+	idempotency IdempotencyStore,
+	opts Options,
 ) *fiber.App {
 	app := fiber.New(fiber.Config{
 		// Enable strict routing
@@ -20,49 +60,141 @@ func SetupRouter(
 		// Enable case-sensitive routing
 		CaseSensitive: true,
 		// Set app name
-		AppName: "CryptoCrowd API",
+		AppName:      "CryptoCrowd API",
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
+		IdleTimeout:  opts.IdleTimeout,
+		BodyLimit:    opts.BodyLimit,
+		// Without trusted proxies the header is ignored and c.IP() is the peer address
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          opts.TrustedProxies,
+		ProxyHeader:             opts.ProxyHeader,
 	})
 
 	// Middleware
+	app.Use(traceRequests())
+	app.Use(requestMeta())
+	app.Use(httpMetrics())
+	app.Use(accessLog())
 	app.Use(recover.New())
-	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-User-ID",
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-Request-ID, If-Match, If-None-Match, Idempotency-Key",
+		ExposeHeaders: "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, X-Request-ID, ETag, Idempotent-Replayed",
 	}))
 
+	perAccount := newLimiter(limits.Store, limits.Account, "account")
+	authenticated := requireUser(authenticator, apiKeys, perAccount)
+	// Routes that integrations may also call with an API key granting the scope
+	withScope := func(scope string) fiber.Handler {
+		return requireUser(authenticator, apiKeys, perAccount, scope)
+	}
+	// Administrators must always be logged in with a second factor
+	adminOnly := []fiber.Handler{authenticated, requireRole(accountProvider, "admin"), requireTwoFactor()}
+	if opts.AdminClientCert {
+		adminOnly = append([]fiber.Handler{requireClientCert()}, adminOnly...)
+	}
+
+	// Orchestrator probes, also outside of /api
+	app.Get("/healthz", h.Health.Liveness)
+	app.Get("/readyz", h.Health.Readiness)
+
 	// API routes
-	api := app.Group("/api")
+	api := app.Group("/api", limitByIP(newLimiter(limits.Store, limits.IP, "ip")))
 	v1 := api.Group("/v1")
 
+	// Auth routes
+	auth := v1.Group("/auth", limitByIP(newLimiter(limits.Store, limits.Auth, "auth")))
+	auth.Post("/login", h.Auth.Login)
+	auth.Post("/step-up", authenticated, h.Auth.StepUp)
+	auth.Post("/verify-email", h.Auth.VerifyEmail)
+	auth.Post("/forgot-password", h.Auth.ForgotPassword)
+	auth.Post("/reset-password", h.Auth.ResetPassword)
+	auth.Post("/siwe/nonce", h.Wallet.Nonce)
+	auth.Post("/siwe/login", h.Wallet.Login)
+
 	// Account routes
-	accounts := v1.Group("/accounts")
-	accounts.Post("/", accountHandler.Create)
-	accounts.Put("/", accountHandler.Update)
-	accounts.Put("/password", accountHandler.UpdatePassword)
-	accounts.Delete("/:email", accountHandler.Delete)
-	accounts.Get("/:email", accountHandler.GetByEmail)
-	accounts.Get("/", accountHandler.List)
+	accounts := v1.Group("/accounts", limitByIP(newLimiter(limits.Store, limits.Accounts, "accounts")))
+	accounts.Post("/", h.Account.Create)
+	accounts.Put("/", authenticated, requireIfMatch(), h.Account.Update)
+	accounts.Put("/password", authenticated, h.Account.UpdatePassword)
+	accounts.Delete("/:email", authenticated, requireIfMatch(), h.Account.Delete)
 
 	// Project routes
 	projects := v1.Group("/projects")
-	projects.Post("/", projectHandler.Create)
-	projects.Put("/:id", projectHandler.Update)
-	projects.Delete("/:id", projectHandler.Delete)
-	projects.Get("/:id", projectHandler.GetByID)
-	projects.Get("/", projectHandler.List)
-	projects.Get("/owner/:owner_id", projectHandler.ListByOwnerID)
-	projects.Get("/:id/photos", projectHandler.GetPhotosByProjectID)
+	projects.Post("/", authenticated, h.Project.Create)
+	projects.Put("/:id", authenticated, requireIfMatch(), h.Project.Update)
+	projects.Put("/:id/status", append(adminOnly, requireIfMatch(), h.Project.UpdateStatus)...)
+	projects.Delete("/:id", authenticated, requireIfMatch(), h.Project.Delete)
+	projects.Get("/:id", h.Project.GetByID)
+	projects.Get("/", allowStaleReads(), h.Project.List)
+	projects.Get("/owner/:owner_id", withScope(model.ScopeReadProjects), allowStaleReads(), h.Project.ListByOwnerID)
+	projects.Get("/:id/photos", h.Project.GetPhotosByProjectID)
+	projects.Get("/:id/progress/stream", h.Realtime.Stream)
+	projects.Get("/:id/progress/ws", h.Realtime.UpgradeWebSocket, websocket.New(h.Realtime.WebSocket))
 
 	// Investment routes
 	investments := v1.Group("/investments")
-	investments.Post("/", investmentHandler.Create)
-	investments.Put("/:id", investmentHandler.Update)
-	investments.Delete("/:id", investmentHandler.Delete)
-	investments.Get("/:id", investmentHandler.GetByID)
-	investments.Get("/user/:user_id", investmentHandler.GetByUserID)
-	investments.Get("/project/:project_id", investmentHandler.GetByProjectID)
+	investments.Post("/", authenticated, idempotent(idempotency), h.Investment.Create)
+	investments.Post("/check", authenticated, h.Investment.Check)
+	investments.Put("/:id", authenticated, requireIfMatch(), h.Investment.Update)
+	investments.Delete("/:id", authenticated, requireIfMatch(), h.Investment.Delete)
+	investments.Get("/:id", authenticated, h.Investment.GetByID)
+	investments.Get("/user/:user_id", withScope(model.ScopeReadInvestments), allowStaleReads(), h.Investment.GetByUserID)
+	investments.Get("/project/:project_id", authenticated, h.Investment.GetByProjectID)
+
+	// Current user routes
+	me := v1.Group("/me", authenticated)
+	me.Get("/", h.Account.GetCurrent)
+	me.Get("/notifications", h.Notification.List)
+	me.Patch("/notifications", h.Notification.MarkRead)
+	me.Get("/notification-preferences", h.Notification.GetPreferences)
+	me.Put("/notification-preferences", h.Notification.UpdatePreferences)
+	me.Post("/2fa/enroll", h.TwoFactor.Enroll)
+	me.Post("/2fa/confirm", h.TwoFactor.Confirm)
+	me.Delete("/2fa", h.TwoFactor.Disable)
+	me.Post("/2fa/recovery-codes", h.TwoFactor.RegenerateRecoveryCodes)
+	me.Get("/sessions", h.Session.List)
+	me.Delete("/sessions/:id", h.Session.Revoke)
+	me.Post("/sessions/revoke-others", h.Session.RevokeOthers)
+	me.Get("/wallets", h.Wallet.List)
+	me.Post("/wallets", h.Wallet.Link)
+	me.Get("/api-keys", h.APIKey.List)
+	me.Post("/api-keys", h.APIKey.Create)
+	me.Delete("/api-keys/:id", h.APIKey.Revoke)
+	me.Put("/country", requireIfMatch(), h.Account.SetCountry)
+	me.Put("/email", requireIfMatch(), h.Account.SetEmail)
+	me.Get("/kyc", h.KYC.Overview)
+	me.Post("/kyc/documents", h.KYC.UploadDocument)
+	me.Post("/kyc/submit", h.KYC.Submit)
+	me.Get("/data-export", h.Privacy.Export)
+	me.Get("/erasure", h.Privacy.GetErasure)
+	me.Post("/erasure", h.Privacy.RequestErasure)
+	me.Delete("/erasure", h.Privacy.CancelErasure)
+
+	// Administration routes
+	admin := v1.Group("/admin", adminOnly...)
+	admin.Get("/audit-events", h.Audit.List)
+	admin.Get("/audit-events/verify", h.Audit.Verify)
+	admin.Get("/projects/:id/investment-rules", h.Investment.GetProjectRules)
+	admin.Put("/projects/:id/investment-rules", h.Investment.SetProjectRules)
+	admin.Get("/kyc/applications", h.KYC.ListApplications)
+	admin.Get("/kyc/applications/:id", h.KYC.GetApplication)
+	admin.Post("/kyc/applications/:id/review", h.KYC.Review)
+	admin.Get("/kyc/documents/:id/file", h.KYC.DownloadDocument)
+	admin.Get("/screening/hits", h.Screening.ListHits)
+	admin.Get("/accounts", allowStaleReads(), h.Account.List)
+	admin.Get("/deleted/accounts", h.Account.ListDeleted)
+	admin.Post("/deleted/accounts/:id/restore", h.Account.Restore)
+	admin.Get("/deleted/projects", h.Project.ListDeleted)
+	admin.Post("/deleted/projects/:id/restore", h.Project.Restore)
+	admin.Get("/deleted/investments", h.Investment.ListDeleted)
+	admin.Post("/deleted/investments/:id/restore", h.Investment.Restore)
+	admin.Post("/screening/hits/:id/review", h.Screening.Review)
+	admin.Get("/log-level", h.Runtime.GetLogLevel)
+	admin.Put("/log-level", h.Runtime.SetLogLevel)
+	admin.Post("/config/reload", h.Runtime.ReloadConfig)
 
 	return app
 }
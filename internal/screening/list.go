@@ -0,0 +1,161 @@
+package screening
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry - запись санкционного списка: лицо с именами-псевдонимами и/или адресами кошельков
+type Entry struct {
+	Source    string   `json:"-"`
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases"`
+	Addresses []string `json:"addresses"`
+}
+
+// Колонки CSV-файла. Поддерживаются имена колонок собственного формата и выгрузки OFAC SDN
+var (
+	idColumns      = []string{"id", "uid", "ent_num"}
+	nameColumns    = []string{"name", "sdn_name"}
+	aliasColumns   = []string{"aliases", "alt_names"}
+	addressColumns = []string{"addresses", "address", "wallet", "digital_currency_address"}
+)
+
+// listSeparator разделяет несколько значений в одной ячейке CSV
+const listSeparator = ";"
+
+// loadFiles читает списки из файлов и возвращает записи и версию - хеш содержимого всех файлов
+func loadFiles(paths []string) ([]Entry, string, error) {
+	var entries []Entry
+	hash := sha256.New()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка чтения списка %s: %w", path, err)
+		}
+		hash.Write([]byte(path))
+		hash.Write(data)
+
+		source := filepath.Base(path)
+		var loaded []Entry
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			loaded, err = parseCSV(data)
+		case ".json":
+			loaded, err = parseJSON(data)
+		default:
+			err = fmt.Errorf("неподдерживаемый формат файла")
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка разбора списка %s: %w", path, err)
+		}
+
+		for idx := range loaded {
+			loaded[idx].Source = source
+		}
+		entries = append(entries, loaded...)
+	}
+
+	return entries, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// parseJSON разбирает массив записей в формате Entry
+func parseJSON(data []byte) ([]Entry, error) {
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseCSV разбирает CSV с заголовком. Псевдонимы и адреса в одной ячейке разделяются точкой с запятой
+func parseCSV(data []byte) ([]Entry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения заголовка: %w", err)
+	}
+	idCol := findColumn(header, idColumns)
+	nameCol := findColumn(header, nameColumns)
+	aliasCol := findColumn(header, aliasColumns)
+	addressCol := findColumn(header, addressColumns)
+	if nameCol < 0 && addressCol < 0 {
+		return nil, fmt.Errorf("нет колонки с именем или адресом")
+	}
+
+	var entries []Entry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := Entry{
+			ID:        cell(record, idCol),
+			Name:      cell(record, nameCol),
+			Aliases:   splitCell(cell(record, aliasCol)),
+			Addresses: splitCell(cell(record, addressCol)),
+		}
+		if entry.ID == "" {
+			entry.ID = fmt.Sprintf("line-%d", line)
+		}
+		if entry.Name == "" && len(entry.Addresses) == 0 {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func findColumn(header []string, names []string) int {
+	for idx, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		for _, name := range names {
+			if column == name {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+func cell(record []string, idx int) string {
+	if idx < 0 || idx >= len(record) {
+		return ""
+	}
+	// В выгрузках OFAC пустые значения обозначаются как -0-
+	value := strings.TrimSpace(record[idx])
+	if value == "-0-" {
+		return ""
+	}
+	return value
+}
+
+func splitCell(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var parts []string
+	for _, part := range strings.Split(value, listSeparator) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,109 @@
+package screening
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// minNameLength - имена короче этой длины после нормализации не сравниваются нечетко,
+// чтобы короткие имена пользователей не давали ложных совпадений
+const minNameLength = 4
+
+// NormalizeName приводит имя к нижнему регистру, оставляет только буквы и цифры
+// и разделяет слова одним пробелом
+func NormalizeName(name string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		space = true
+	}
+	return b.String()
+}
+
+// NormalizeAddress приводит адрес к виду для точного сравнения. Шестнадцатеричные адреса
+// EVM не зависят от регистра, остальные форматы (например, base58) сравниваются как есть
+func NormalizeAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if len(address) > 2 && (strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X")) {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// nameSimilarity возвращает сходство нормализованных имен от 0 до 1. Имена сравниваются
+// целиком и с упорядоченными словами, чтобы порядок имени и фамилии не влиял на результат
+func nameSimilarity(a string, b string) float64 {
+	if a == b {
+		return 1
+	}
+	return max(jaroWinkler(a, b), jaroWinkler(sortTokens(a), sortTokens(b)))
+}
+
+func sortTokens(name string) string {
+	tokens := strings.Fields(name)
+	slices.Sort(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// jaroWinkler вычисляет сходство Джаро-Винклера
+func jaroWinkler(a string, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := max(len(s1), len(s2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		lo, hi := max(0, i-window), min(len(s2), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
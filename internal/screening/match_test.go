@@ -0,0 +1,72 @@
+package screening
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "case and punctuation", in: "  Ivanov, Ivan I.  ", want: "ivanov ivan i"},
+		{name: "hyphen splits words", in: "Jean-Pierre", want: "jean pierre"},
+		{name: "cyrillic", in: "ИВАНОВ Иван", want: "иванов иван"},
+		{name: "digits kept", in: "Agent 007", want: "agent 007"},
+		{name: "no letters", in: "--- !!", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.in); got != tt.want {
+				t.Fatalf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "EVM address is case-insensitive", in: " 0xAbCdEF0123 ", want: "0xabcdef0123"},
+		{name: "upper-case prefix", in: "0XABCD", want: "0xabcd"},
+		{name: "base58 keeps case", in: "1BoatSLRHtKNngkdXEeobR76b53LETtpyT", want: "1BoatSLRHtKNngkdXEeobR76b53LETtpyT"},
+		{name: "bare prefix", in: "0x", want: "0x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeAddress(tt.in); got != tt.want {
+				t.Fatalf("NormalizeAddress(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{name: "identical", a: "ivan ivanov", b: "ivan ivanov", want: 1},
+		{name: "word order does not matter", a: "ivanov ivan", b: "ivan ivanov", want: 1},
+		{name: "transposition", a: "martha", b: "marhta", want: 0.961},
+		{name: "common prefix", a: "dwayne", b: "duane", want: 0.84},
+		{name: "nothing in common", a: "abc", b: "xyz", want: 0},
+		{name: "empty", a: "", b: "ivan", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nameSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 0.001 {
+				t.Fatalf("nameSimilarity(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
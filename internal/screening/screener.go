@@ -0,0 +1,149 @@
+package screening
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// Match - совпадение проверяемого значения с записью списка
+type Match struct {
+	Kind      string
+	Value     string
+	Source    string
+	EntryID   string
+	EntryName string
+	// Score - сходство имени от 0 до 1, для адресов всегда 1
+	Score float64
+}
+
+// indexedEntry - запись с заранее нормализованными именами
+type indexedEntry struct {
+	entry *Entry
+	names []string
+}
+
+// Screener проверяет имена и адреса по санкционным спискам из локальных файлов.
+// Списки можно перечитать без остановки приложения
+type Screener struct {
+	paths     []string
+	threshold float64
+
+	mu        sync.RWMutex
+	version   string
+	names     []indexedEntry
+	addresses map[string][]*Entry
+}
+
+// NewScreener загружает списки из файлов. threshold - минимальное сходство имен для совпадения
+func NewScreener(paths []string, threshold float64) (*Screener, error) {
+	s := &Screener{
+		paths:     slices.Clone(paths),
+		threshold: threshold,
+	}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload перечитывает файлы и сообщает, изменились ли списки. При ошибке остаются прежние списки
+func (s *Screener) Reload() (bool, error) {
+	entries, version, err := loadFiles(s.paths)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	unchanged := version == s.version
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	names := make([]indexedEntry, 0, len(entries))
+	addresses := make(map[string][]*Entry)
+	for idx := range entries {
+		entry := &entries[idx]
+
+		indexed := indexedEntry{entry: entry}
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			if normalized := NormalizeName(name); normalized != "" {
+				indexed.names = append(indexed.names, normalized)
+			}
+		}
+		if len(indexed.names) > 0 {
+			names = append(names, indexed)
+		}
+
+		for _, address := range entry.Addresses {
+			key := NormalizeAddress(address)
+			addresses[key] = append(addresses[key], entry)
+		}
+	}
+
+	s.mu.Lock()
+	s.version = version
+	s.names = names
+	s.addresses = addresses
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Version возвращает хеш загруженных списков
+func (s *Screener) Version() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
+}
+
+// MatchName нечетко сравнивает имя со всеми именами и псевдонимами списков.
+// Для каждой записи возвращается лучшее совпадение не ниже порога
+func (s *Screener) MatchName(name string) []Match {
+	normalized := NormalizeName(name)
+	if len([]rune(normalized)) < minNameLength {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []Match
+	for _, indexed := range s.names {
+		best := 0.0
+		for _, candidate := range indexed.names {
+			best = max(best, nameSimilarity(normalized, candidate))
+		}
+		if best >= s.threshold {
+			matches = append(matches, Match{
+				Kind:      model.ScreeningKindName,
+				Value:     name,
+				Source:    indexed.entry.Source,
+				EntryID:   indexed.entry.ID,
+				EntryName: indexed.entry.Name,
+				Score:     best,
+			})
+		}
+	}
+	return matches
+}
+
+// MatchAddress ищет точное совпадение адреса кошелька
+func (s *Screener) MatchAddress(address string) []Match {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []Match
+	for _, entry := range s.addresses[NormalizeAddress(address)] {
+		matches = append(matches, Match{
+			Kind:      model.ScreeningKindAddress,
+			Value:     address,
+			Source:    entry.Source,
+			EntryID:   entry.ID,
+			EntryName: entry.Name,
+			Score:     1,
+		})
+	}
+	return matches
+}
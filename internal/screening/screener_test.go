@@ -0,0 +1,229 @@
+package screening
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+func writeList(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Entry
+		wantErr bool
+	}{
+		{
+			name: "own format",
+			data: "id,name,aliases,addresses\n" +
+				"E1,Ivan Ivanov,Ivan I.; Vanya ,0xAB;0xCD\n",
+			want: []Entry{{ID: "E1", Name: "Ivan Ivanov", Aliases: []string{"Ivan I.", "Vanya"}, Addresses: []string{"0xAB", "0xCD"}}},
+		},
+		{
+			name: "OFAC export with BOM and -0- placeholders",
+			data: "\ufeffent_num,SDN_Name,alt_names,digital_currency_address\n" +
+				"36,BANK X,-0-,-0-\n",
+			want: []Entry{{ID: "36", Name: "BANK X"}},
+		},
+		{
+			name: "missing id and empty rows",
+			data: "name,wallet\n" +
+				",\n" +
+				",0xEF\n",
+			want: []Entry{{ID: "line-3", Addresses: []string{"0xEF"}}},
+		},
+		{
+			name:    "no name or address column",
+			data:    "id,country\nE1,RU\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty file",
+			data:    "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCSV([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCSV: error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.EqualFunc(got, tt.want, equalEntries) {
+				t.Fatalf("parseCSV = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalEntries(a Entry, b Entry) bool {
+	return a.Source == b.Source && a.ID == b.ID && a.Name == b.Name &&
+		slices.Equal(a.Aliases, b.Aliases) && slices.Equal(a.Addresses, b.Addresses)
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	csvList := writeList(t, dir, "ofac.csv", "id,name\nE1,Ivan Ivanov\n")
+	jsonList := writeList(t, dir, "local.JSON", `[{"id":"L1","name":"Petr Petrov","addresses":["0x01"]}]`)
+
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []Entry
+		wantErr bool
+	}{
+		{
+			name:  "csv and json",
+			paths: []string{csvList, jsonList},
+			want: []Entry{
+				{Source: "ofac.csv", ID: "E1", Name: "Ivan Ivanov"},
+				{Source: "local.JSON", ID: "L1", Name: "Petr Petrov", Addresses: []string{"0x01"}},
+			},
+		},
+		{name: "no lists"},
+		{name: "missing file", paths: []string{filepath.Join(dir, "missing.csv")}, wantErr: true},
+		{name: "unsupported format", paths: []string{writeList(t, dir, "list.xml", "<list/>")}, wantErr: true},
+		{name: "broken json", paths: []string{writeList(t, dir, "broken.json", "{")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, version, err := loadFiles(tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadFiles: error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !slices.EqualFunc(got, tt.want, equalEntries) {
+				t.Fatalf("loadFiles = %+v, want %+v", got, tt.want)
+			}
+			if version == "" {
+				t.Fatal("loadFiles returned an empty version")
+			}
+		})
+	}
+}
+
+func TestScreenerMatch(t *testing.T) {
+	dir := t.TempDir()
+	list := writeList(t, dir, "sanctions.csv", "id,name,aliases,addresses\n"+
+		"E1,Ivan Ivanov,Vanya Grozny,0xAbCd\n"+
+		"E2,Petr Petrov,,1BoatSLRHtKNngkdXEeobR76b53LETtpyT\n"+
+		"E3,Li,,\n")
+
+	s, err := NewScreener([]string{list}, 0.92)
+	if err != nil {
+		t.Fatalf("NewScreener: %v", err)
+	}
+
+	names := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "exact", value: "Ivan Ivanov", want: []string{"E1"}},
+		{name: "reordered and punctuated", value: "IVANOV, Ivan", want: []string{"E1"}},
+		{name: "alias", value: "vanya grozny", want: []string{"E1"}},
+		{name: "typo", value: "Petr Petrof", want: []string{"E2"}},
+		{name: "different person", value: "Anna Smirnova"},
+		// Короткие имена не сравниваются, иначе пользователь Li совпал бы с записью E3
+		{name: "too short", value: "Li"},
+	}
+	for _, tt := range names {
+		t.Run("name "+tt.name, func(t *testing.T) {
+			if got := matchedIDs(s.MatchName(tt.value)); !slices.Equal(got, tt.want) {
+				t.Fatalf("MatchName(%q) matched %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	addresses := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "EVM in other case", value: "0xABCD", want: []string{"E1"}},
+		{name: "base58 exact", value: "1BoatSLRHtKNngkdXEeobR76b53LETtpyT", want: []string{"E2"}},
+		{name: "base58 in other case", value: "1boatslrhtknngkdxeeobr76b53lettpyt"},
+		{name: "unknown", value: "0x1234"},
+	}
+	for _, tt := range addresses {
+		t.Run("address "+tt.name, func(t *testing.T) {
+			matches := s.MatchAddress(tt.value)
+			if got := matchedIDs(matches); !slices.Equal(got, tt.want) {
+				t.Fatalf("MatchAddress(%q) matched %v, want %v", tt.value, got, tt.want)
+			}
+			for _, m := range matches {
+				if m.Kind != model.ScreeningKindAddress || m.Score != 1 || m.Source != "sanctions.csv" {
+					t.Fatalf("MatchAddress(%q) = %+v", tt.value, m)
+				}
+			}
+		})
+	}
+}
+
+func matchedIDs(matches []Match) []string {
+	var ids []string
+	for _, m := range matches {
+		ids = append(ids, m.EntryID)
+	}
+	return ids
+}
+
+func TestScreenerReload(t *testing.T) {
+	dir := t.TempDir()
+	list := writeList(t, dir, "sanctions.csv", "id,name\nE1,Ivan Ivanov\n")
+
+	s, err := NewScreener([]string{list}, 0.92)
+	if err != nil {
+		t.Fatalf("NewScreener: %v", err)
+	}
+	initial := s.Version()
+
+	changed, err := s.Reload()
+	if err != nil || changed {
+		t.Fatalf("Reload of unchanged lists = %v, %v, want false, nil", changed, err)
+	}
+
+	writeList(t, dir, "sanctions.csv", "id,name\nE2,Petr Petrov\n")
+	changed, err = s.Reload()
+	if err != nil || !changed {
+		t.Fatalf("Reload of changed lists = %v, %v, want true, nil", changed, err)
+	}
+	if s.Version() == initial {
+		t.Fatal("version did not change after reload")
+	}
+	if len(s.MatchName("Ivan Ivanov")) != 0 || len(s.MatchName("Petr Petrov")) != 1 {
+		t.Fatal("reload did not replace the lists")
+	}
+
+	// Ошибка чтения оставляет прежние списки
+	updated := s.Version()
+	if err = os.Remove(list); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err = s.Reload(); err == nil {
+		t.Fatal("Reload of a missing list succeeded")
+	}
+	if s.Version() != updated || len(s.MatchName("Petr Petrov")) != 1 {
+		t.Fatal("failed reload replaced the lists")
+	}
+
+	if _, err = NewScreener([]string{list}, 0.92); err == nil {
+		t.Fatal("NewScreener with a missing list succeeded")
+	}
+}
@@ -3,60 +3,354 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"regexp"
+	"slices"
+	"strings"
 
+	"github.com/CryptoCrowd/internal/compliance"
+	"github.com/CryptoCrowd/internal/logger"
 	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
 )
 
 var (
-	ErrInvalidUsername = errors.New("invalid username")
-	ErrInvalidEmail    = errors.New("invalid email")
-	ErrInvalidRole     = errors.New("invalid role")
-	ErrEmptyPass       = errors.New("password cannot be empty")
+	ErrInvalidUsername     = errors.New("invalid username")
+	ErrInvalidEmail        = errors.New("invalid email")
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrEmptyPass           = errors.New("password cannot be empty")
+	ErrInvalidCountry      = errors.New("country must be an ISO 3166-1 alpha-2 code")
+	ErrCountryLocked       = errors.New("country cannot be changed after identity verification")
+	ErrAccountAccessDenied = errors.New("accounts of other users cannot be changed")
 )
 
 type AccountRepository interface {
 	Create(ctx context.Context, acc model.Account, plainPassword string) error
-	Update(ctx context.Context, acc model.Account) error
-	UpdatePassword(ctx context.Context, email string, newPassword string) error
-	Delete(ctx context.Context, email string) error
-	GetByEmail(ctx context.Context, email string) (model.Account, error)
+	UpdatePassword(ctx context.Context, id int64, newPassword string) error
+	Delete(ctx context.Context, id int64, version int64) error
+	GetByEmailAndRole(ctx context.Context, email string, role string) (model.Account, error)
+	GetByID(ctx context.Context, id int64) (model.Account, error)
 	List(ctx context.Context, searchTerm string) ([]model.Account, error)
+	CheckPassword(ctx context.Context, id int64, password string) error
+	UpdateCountry(ctx context.Context, id int64, country string, version int64) error
+	UpdateUsername(ctx context.Context, id int64, username string, version int64) error
+	UpdateEmail(ctx context.Context, id int64, email string, version int64) error
+	ListDeleted(ctx context.Context) ([]model.Account, error)
+	Restore(ctx context.Context, id int64) (model.Account, error)
 }
+
+// SessionRevoker ends login sessions of an account
+type SessionRevoker interface {
+	RevokeAll(ctx context.Context, userID int64) error
+}
+
+// EmailVerifier sends email verification links to new accounts
+type EmailVerifier interface {
+	SendVerificationEmail(ctx context.Context, userID int64) error
+}
+
 type Account struct {
 	repo        AccountRepository
+	verifier    EmailVerifier
+	sessions    SessionRevoker
+	auditor     Auditor
+	screener    SanctionsScreener
 	emailRegexp *regexp.Regexp
 }
 
-func NewAccount(repo AccountRepository) *Account {
-	reg, _ := regexp.Compile(`^[a-zA-Z0–9._%+-]+@[a-zA-Z0–9.-]+\.[a-zA-Z]{2,}$`)
+// signupRoles lists the roles that can be chosen at registration
+var signupRoles = []string{"startup", "investor"}
+
+func NewAccount(
+	repo AccountRepository,
+	verifier EmailVerifier,
+	sessions SessionRevoker,
+	auditor Auditor,
+	screener SanctionsScreener,
+) *Account {
+	reg, _ := regexp.Compile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
 	return &Account{
 		repo:        repo,
+		verifier:    verifier,
+		auditor:     auditor,
+		screener:    screener,
+		sessions:    sessions,
 		emailRegexp: reg,
 	}
 }
 
 func (a *Account) Create(ctx context.Context, acc model.Account, plainPassword string) error {
+	ctx, span := tracing.Start(ctx, "Account.Create")
+	defer span.End()
+
+	if strings.TrimSpace(acc.Username) == "" {
+		logger.FromContext(ctx).Error("Invalid username")
+		return fmt.Errorf("%w", ErrInvalidUsername)
+	}
+	if !a.emailRegexp.MatchString(acc.Email) {
+		logger.FromContext(ctx).Error("Invalid email")
+		return fmt.Errorf("%w", ErrInvalidEmail)
+	}
+	if !slices.Contains(signupRoles, acc.Role) {
+		logger.FromContext(ctx).Error("Invalid role")
+		return fmt.Errorf("%w", ErrInvalidRole)
+	}
+	if err := validatePassword(ctx, plainPassword); err != nil {
+		return err
+	}
+
+	var created model.Account
+	err := a.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := a.repo.Create(ctx, acc, plainPassword); err != nil {
+			return err
+		}
+
+		var err error
+		if created, err = a.repo.GetByEmailAndRole(ctx, acc.Email, acc.Role); err != nil {
+			return err
+		}
+		return a.auditor.Record(ctx, model.AuditAccountCreate, model.AuditTargetAccount, created.ID, nil, created)
+	})
+	if err != nil {
+		return err
+	}
+
+	// A hit only puts the account on hold for review, signup itself is not refused
+	err = a.screener.ScreenAccount(ctx, created.ID, model.ScreeningTriggerSignup)
+	if err != nil && !errors.Is(err, ErrSanctionsReview) {
+		logger.FromContext(ctx).Errorf("Failed to screen new user %d: %v", created.ID, err)
+	}
+
+	// The account is usable without a verified email, so a mail failure must not fail signup
+	if err = a.verifier.SendVerificationEmail(ctx, created.ID); err != nil {
+		logger.FromContext(ctx).Errorf("Failed to send verification email to user %d: %v", created.ID, err)
+	}
+
 	return nil
 }
 
-func (a *Account) Update(ctx context.Context, acc model.Account) error {
-	return nil
+// UpdatePassword changes the password after checking the current one and ends all sessions of the account
+func (a *Account) UpdatePassword(ctx context.Context, userID int64, currentPassword string, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "Account.UpdatePassword")
+	defer span.End()
+
+	if err := validatePassword(ctx, newPassword); err != nil {
+		return err
+	}
+
+	err := a.repo.CheckPassword(ctx, userID, currentPassword)
+	if errors.Is(err, repository.ErrInvalidCredentials) {
+		logger.FromContext(ctx).Errorf("Invalid current password for user %d", userID)
+		return fmt.Errorf("%w", ErrInvalidCredentials)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = a.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := a.repo.UpdatePassword(ctx, userID, newPassword); err != nil {
+			return err
+		}
+		return a.auditor.Record(ctx, model.AuditAccountPasswordChange, model.AuditTargetAccount, userID, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.sessions.RevokeAll(ctx, userID)
 }
 
-func (a *Account) UpdatePassword(ctx context.Context, email string, newPassword string) error {
-	return nil
+// SetCountry sets the country of residence used for jurisdiction rules and returns the new
+// version of the account. It is fixed once the identity of the account is verified
+func (a *Account) SetCountry(ctx context.Context, userID int64, country string, version int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "Account.SetCountry")
+	defer span.End()
+
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if !compliance.IsCountryCode(country) {
+		logger.FromContext(ctx).Error("Invalid country")
+		return 0, fmt.Errorf("%w", ErrInvalidCountry)
+	}
+
+	acc, err := a.repo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if acc.Version != version {
+		return 0, repository.ErrVersionConflict
+	}
+	if acc.Country == country {
+		return acc.Version, nil
+	}
+	if acc.KYCStatus == model.KYCVerified {
+		logger.FromContext(ctx).Errorf("Country change of verified user %d rejected", userID)
+		return 0, fmt.Errorf("%w", ErrCountryLocked)
+	}
+
+	err = a.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := a.repo.UpdateCountry(ctx, userID, country, version); err != nil {
+			return err
+		}
+		return a.auditor.Record(ctx, model.AuditAccountCountryChange, model.AuditTargetAccount, userID,
+			map[string]any{"country": acc.Country}, map[string]any{"country": country})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version + 1, nil
 }
 
-func (a *Account) Delete(ctx context.Context, email string) error {
-	return nil
+// Update changes the username of the calling user and returns the new version of the account
+func (a *Account) Update(ctx context.Context, userID int64, username string, version int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "Account.Update")
+	defer span.End()
+
+	username = strings.TrimSpace(username)
+	if username == "" {
+		logger.FromContext(ctx).Error("Invalid username")
+		return 0, fmt.Errorf("%w", ErrInvalidUsername)
+	}
+
+	acc, err := a.repo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if acc.Version != version {
+		return 0, repository.ErrVersionConflict
+	}
+	if acc.Username == username {
+		return acc.Version, nil
+	}
+
+	err = a.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := a.repo.UpdateUsername(ctx, userID, username, version); err != nil {
+			return err
+		}
+		return a.auditor.Record(ctx, model.AuditAccountUpdate, model.AuditTargetAccount, userID,
+			map[string]any{"username": acc.Username}, map[string]any{"username": username})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
+
+// SetEmail replaces the email of the calling user and mails a verification link to the new address.
+// Accounts created by wallet login start with a placeholder email and set a real one this way.
+// Repeating the request with the current unverified email sends a new link. Returns the new version
+func (a *Account) SetEmail(ctx context.Context, userID int64, email string, version int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "Account.SetEmail")
+	defer span.End()
+
+	email = strings.TrimSpace(email)
+	if !a.emailRegexp.MatchString(email) || strings.HasSuffix(strings.ToLower(email), "@"+walletEmailDomain) {
+		logger.FromContext(ctx).Error("Invalid email")
+		return 0, fmt.Errorf("%w", ErrInvalidEmail)
+	}
+
+	acc, err := a.repo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if acc.Version != version {
+		return 0, repository.ErrVersionConflict
+	}
+
+	if acc.Email != email {
+		err = a.auditor.Change(ctx, func(ctx context.Context) error {
+			if err := a.repo.UpdateEmail(ctx, userID, email, version); err != nil {
+				return err
+			}
+			return a.auditor.Record(ctx, model.AuditAccountEmailChange, model.AuditTargetAccount, userID,
+				map[string]any{"email": acc.Email}, map[string]any{"email": email})
+		})
+		if err != nil {
+			return 0, err
+		}
+		version++
+	} else if acc.EmailVerifiedAt != nil {
+		return version, nil
+	}
+
+	if err = a.verifier.SendVerificationEmail(ctx, userID); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// Delete marks the account of the calling user as deleted and ends all its sessions.
+// email must be the email of the account, so that a stale or mistyped request cannot
+// delete it. The data is kept until the retention period ends and can be restored by an administrator
+func (a *Account) Delete(ctx context.Context, email string, userID int64, version int64) error {
+	ctx, span := tracing.Start(ctx, "Account.Delete")
+	defer span.End()
+
+	acc, err := a.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(acc.Email, strings.TrimSpace(email)) {
+		logger.FromContext(ctx).Errorf("User %d tried to delete another account", userID)
+		return fmt.Errorf("%w", ErrAccountAccessDenied)
+	}
+
+	err = a.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := a.repo.Delete(ctx, userID, version); err != nil {
+			return err
+		}
+		return a.auditor.Record(ctx, model.AuditAccountDelete, model.AuditTargetAccount, userID, acc, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Infof("Account %d deleted", userID)
+	return a.sessions.RevokeAll(ctx, userID)
 }
 
-func (a *Account) GetByEmail(ctx context.Context, email string) (model.Account, error) {
-	return model.Account{}, nil
+func (a *Account) GetByID(ctx context.Context, id int64) (model.Account, error) {
+	ctx, span := tracing.Start(ctx, "Account.GetByID")
+	defer span.End()
+
+	return a.repo.GetByID(ctx, id)
 }
 
+// List returns accounts whose username or email contains searchTerm
 func (a *Account) List(ctx context.Context, searchTerm string) ([]model.Account, error) {
-	return nil, nil
+	ctx, span := tracing.Start(ctx, "Account.List")
+	defer span.End()
+
+	return a.repo.List(ctx, searchTerm)
+}
+
+// ListDeleted returns accounts marked as deleted that have not been purged yet
+func (a *Account) ListDeleted(ctx context.Context) ([]model.Account, error) {
+	ctx, span := tracing.Start(ctx, "Account.ListDeleted")
+	defer span.End()
+
+	return a.repo.ListDeleted(ctx)
+}
+
+// Restore clears the deletion mark of an account
+func (a *Account) Restore(ctx context.Context, id int64) (model.Account, error) {
+	ctx, span := tracing.Start(ctx, "Account.Restore")
+	defer span.End()
+
+	var acc model.Account
+	err := a.auditor.Change(ctx, func(ctx context.Context) error {
+		var err error
+		if acc, err = a.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		return a.auditor.Record(ctx, model.AuditAccountRestore, model.AuditTargetAccount, id,
+			map[string]any{"deleted": true}, map[string]any{"deleted": false})
+	})
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	logger.FromContext(ctx).Infof("Account %d restored", id)
+	return acc, nil
 }
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+)
+
+// memoryAccounts keeps accounts in memory with the soft delete and versioning semantics
+// of repository.PostgresAccount
+type memoryAccounts struct {
+	AccountRepository
+
+	accounts map[int64]model.Account
+}
+
+func newMemoryAccounts(accounts ...model.Account) *memoryAccounts {
+	m := &memoryAccounts{accounts: make(map[int64]model.Account)}
+	for _, acc := range accounts {
+		m.accounts[acc.ID] = acc
+	}
+	return m
+}
+
+func (m *memoryAccounts) GetByID(_ context.Context, id int64) (model.Account, error) {
+	acc, ok := m.accounts[id]
+	if !ok || acc.DeletedAt != nil {
+		return model.Account{}, repository.ErrUserNotFound
+	}
+	return acc, nil
+}
+
+func (m *memoryAccounts) Delete(_ context.Context, id int64, version int64) error {
+	acc, ok := m.accounts[id]
+	if !ok || acc.DeletedAt != nil {
+		return repository.ErrUserNotFound
+	}
+	if acc.Version != version {
+		return repository.ErrVersionConflict
+	}
+
+	now := time.Now()
+	acc.DeletedAt = &now
+	acc.Version++
+	m.accounts[id] = acc
+	return nil
+}
+
+func (m *memoryAccounts) UpdateEmail(_ context.Context, id int64, email string, version int64) error {
+	acc, ok := m.accounts[id]
+	if !ok || acc.DeletedAt != nil {
+		return repository.ErrUserNotFound
+	}
+	if acc.Version != version {
+		return repository.ErrVersionConflict
+	}
+	for _, other := range m.accounts {
+		if other.ID != id && other.Email == email && other.Role == acc.Role {
+			return repository.ErrUserAlreadyExists
+		}
+	}
+
+	acc.Email = email
+	acc.EmailVerifiedAt = nil
+	acc.Version++
+	m.accounts[id] = acc
+	return nil
+}
+
+func (m *memoryAccounts) UpdateUsername(_ context.Context, id int64, username string, version int64) error {
+	acc, ok := m.accounts[id]
+	if !ok || acc.DeletedAt != nil {
+		return repository.ErrUserNotFound
+	}
+	if acc.Version != version {
+		return repository.ErrVersionConflict
+	}
+
+	acc.Username = username
+	acc.Version++
+	m.accounts[id] = acc
+	return nil
+}
+
+func (m *memoryAccounts) ListDeleted(context.Context) ([]model.Account, error) {
+	var deleted []model.Account
+	for _, acc := range m.accounts {
+		if acc.DeletedAt != nil {
+			deleted = append(deleted, acc)
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memoryAccounts) Restore(_ context.Context, id int64) (model.Account, error) {
+	acc, ok := m.accounts[id]
+	if !ok || acc.DeletedAt == nil {
+		return model.Account{}, repository.ErrUserNotFound
+	}
+
+	acc.DeletedAt = nil
+	acc.Version++
+	m.accounts[id] = acc
+	return acc, nil
+}
+
+func TestAccountDeleteListRestore(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryAccounts(model.Account{ID: 7, Email: "alice@example.com", Role: "investor", Version: 3})
+	auditor := &recordingAuditor{}
+	var revoked []int64
+	sessions := sessionRevokerFunc(func(_ context.Context, userID int64) error {
+		revoked = append(revoked, userID)
+		return nil
+	})
+	svc := NewAccount(repo, nil, sessions, auditor, nil)
+
+	if err := svc.Delete(ctx, "Alice@Example.com", 7, 3); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if !slices.Equal(revoked, []int64{7}) {
+		t.Fatalf("revoked sessions of %v, want [7]", revoked)
+	}
+	if _, err := svc.GetByID(ctx, 7); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Fatalf("GetByID after delete: error = %v, want ErrUserNotFound", err)
+	}
+
+	deleted, err := svc.ListDeleted(ctx)
+	if err != nil {
+		t.Fatalf("ListDeleted: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != 7 {
+		t.Fatalf("ListDeleted = %+v, want account 7", deleted)
+	}
+
+	restored, err := svc.Restore(ctx, 7)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Version != 5 {
+		t.Fatalf("restored account = %+v, want no deletion mark and version 5", restored)
+	}
+	if deleted, _ = svc.ListDeleted(ctx); len(deleted) != 0 {
+		t.Fatalf("ListDeleted after restore = %+v, want empty", deleted)
+	}
+	if _, err = svc.GetByID(ctx, 7); err != nil {
+		t.Fatalf("GetByID after restore: %v", err)
+	}
+
+	want := []string{model.AuditAccountDelete, model.AuditAccountRestore}
+	if got := auditor.actions(); !slices.Equal(got, want) {
+		t.Fatalf("audit actions = %v, want %v", got, want)
+	}
+}
+
+func TestAccountDeleteRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		version int64
+		wantErr error
+	}{
+		{name: "email of another account", email: "bob@example.com", version: 1, wantErr: ErrAccountAccessDenied},
+		{name: "stale version", email: "alice@example.com", version: 0, wantErr: repository.ErrVersionConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryAccounts(model.Account{ID: 7, Email: "alice@example.com", Version: 1})
+			auditor := &recordingAuditor{}
+			sessions := sessionRevokerFunc(func(context.Context, int64) error {
+				t.Fatal("sessions must not be revoked when the deletion is rejected")
+				return nil
+			})
+			svc := NewAccount(repo, nil, sessions, auditor, nil)
+
+			err := svc.Delete(context.Background(), tt.email, 7, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete: error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.accounts[7].DeletedAt != nil {
+				t.Fatal("account was marked deleted")
+			}
+			if len(auditor.actions()) != 0 {
+				t.Fatalf("audit actions = %v, want none", auditor.actions())
+			}
+		})
+	}
+}
+
+// emailVerifierFunc adapts a function to EmailVerifier
+type emailVerifierFunc func(ctx context.Context, userID int64) error
+
+func (f emailVerifierFunc) SendVerificationEmail(ctx context.Context, userID int64) error {
+	return f(ctx, userID)
+}
+
+func TestAccountSetEmail(t *testing.T) {
+	verifiedAt := time.Now()
+	walletEmail := "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23@" + walletEmailDomain
+
+	tests := []struct {
+		name        string
+		current     string
+		verifiedAt  *time.Time
+		email       string
+		version     int64
+		wantErr     error
+		wantVersion int64
+		wantSent    bool
+		wantAudit   []string
+	}{
+		{name: "wallet account sets a real email", current: walletEmail, email: " alice@example.com ", version: 1,
+			wantVersion: 2, wantSent: true, wantAudit: []string{model.AuditAccountEmailChange}},
+		{name: "verified email is replaced", current: "alice@example.com", verifiedAt: &verifiedAt, email: "alice@example.org", version: 1,
+			wantVersion: 2, wantSent: true, wantAudit: []string{model.AuditAccountEmailChange}},
+		{name: "same unverified email is sent again", current: "alice@example.com", email: "alice@example.com", version: 1,
+			wantVersion: 1, wantSent: true},
+		{name: "same verified email is kept", current: "alice@example.com", verifiedAt: &verifiedAt, email: "alice@example.com", version: 1,
+			wantVersion: 1},
+		{name: "placeholder domain", current: walletEmail, email: "bob@" + walletEmailDomain, version: 1, wantErr: ErrInvalidEmail},
+		{name: "not an email", current: walletEmail, email: "alice", version: 1, wantErr: ErrInvalidEmail},
+		{name: "email of another account", current: walletEmail, email: "bob@example.com", version: 1, wantErr: repository.ErrUserAlreadyExists},
+		{name: "stale version", current: walletEmail, email: "alice@example.com", version: 0, wantErr: repository.ErrVersionConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryAccounts(
+				model.Account{ID: 7, Email: tt.current, Role: "investor", EmailVerifiedAt: tt.verifiedAt, Version: 1},
+				model.Account{ID: 8, Email: "bob@example.com", Role: "investor", Version: 1},
+			)
+			auditor := &recordingAuditor{}
+			var sent []int64
+			verifier := emailVerifierFunc(func(_ context.Context, userID int64) error {
+				sent = append(sent, userID)
+				return nil
+			})
+			svc := NewAccount(repo, verifier, nil, auditor, nil)
+
+			version, err := svc.SetEmail(context.Background(), 7, tt.email, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetEmail: error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && version != tt.wantVersion {
+				t.Fatalf("version = %d, want %d", version, tt.wantVersion)
+			}
+			if (len(sent) > 0) != tt.wantSent {
+				t.Fatalf("verification sent to %v, want sent = %v", sent, tt.wantSent)
+			}
+			if got := auditor.actions(); !slices.Equal(got, tt.wantAudit) {
+				t.Fatalf("audit actions = %v, want %v", got, tt.wantAudit)
+			}
+
+			acc := repo.accounts[7]
+			if tt.wantAudit != nil && (acc.Email != strings.TrimSpace(tt.email) || acc.EmailVerifiedAt != nil) {
+				t.Fatalf("account after change = %+v, want unverified %s", acc, tt.email)
+			}
+			if tt.wantErr != nil && acc.Email != tt.current {
+				t.Fatalf("email changed to %s on rejected request", acc.Email)
+			}
+		})
+	}
+}
+
+// TestWalletAccountPassesEmailGate shows that a wallet account is no longer blocked
+// by the email verification gate once it sets and confirms an email
+func TestWalletAccountPassesEmailGate(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryAccounts(model.Account{ID: 7, Email: "0xabc@" + walletEmailDomain, Role: "investor", Version: 1})
+	svc := NewAccount(repo, emailVerifierFunc(func(context.Context, int64) error { return nil }), nil, &recordingAuditor{}, nil)
+
+	if err := ensureVerified(ctx, repo, 7); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("ensureVerified before: error = %v, want ErrEmailNotVerified", err)
+	}
+	if _, err := svc.SetEmail(ctx, 7, "alice@example.com", 1); err != nil {
+		t.Fatalf("SetEmail: %v", err)
+	}
+
+	// What Auth.VerifyEmail does with the token from the mailed link
+	acc := repo.accounts[7]
+	now := time.Now()
+	acc.EmailVerifiedAt = &now
+	repo.accounts[7] = acc
+
+	if err := ensureVerified(ctx, repo, 7); err != nil {
+		t.Fatalf("ensureVerified after: %v", err)
+	}
+}
+
+func TestAccountUpdate(t *testing.T) {
+	tests := []struct {
+		name         string
+		username     string
+		version      int64
+		wantErr      error
+		wantUsername string
+		wantVersion  int64
+		wantAudit    []string
+	}{
+		{name: "username changed", username: "  bob ", version: 3, wantUsername: "bob", wantVersion: 4, wantAudit: []string{model.AuditAccountUpdate}},
+		{name: "same username", username: "alice", version: 3, wantUsername: "alice", wantVersion: 3, wantAudit: []string{}},
+		{name: "empty username", username: " ", version: 3, wantErr: ErrInvalidUsername, wantUsername: "alice", wantAudit: []string{}},
+		{name: "stale version", username: "bob", version: 2, wantErr: repository.ErrVersionConflict, wantUsername: "alice", wantAudit: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryAccounts(model.Account{ID: 7, Username: "alice", Email: "alice@example.com", Role: "investor", Version: 3})
+			auditor := &recordingAuditor{}
+			svc := NewAccount(repo, nil, nil, auditor, nil)
+
+			version, err := svc.Update(context.Background(), 7, tt.username, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update: error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && version != tt.wantVersion {
+				t.Fatalf("version = %d, want %d", version, tt.wantVersion)
+			}
+			if got := repo.accounts[7].Username; got != tt.wantUsername {
+				t.Fatalf("username = %q, want %q", got, tt.wantUsername)
+			}
+			if got := auditor.actions(); !slices.Equal(got, tt.wantAudit) {
+				t.Fatalf("audit actions = %v, want %v", got, tt.wantAudit)
+			}
+		})
+	}
+}
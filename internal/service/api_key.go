@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid, expired or revoked api key")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrInvalidAPIKeyName    = errors.New("api key name must be 1 to 100 characters long")
+	ErrInvalidAPIKeyScope   = errors.New("unknown api key scope")
+	ErrInvalidAPIKeyExpiry  = errors.New("api key expiry must be in the future")
+	ErrTooManyAPIKeys       = errors.New("too many api keys")
+	ErrInsufficientAPIScope = errors.New("api key does not grant access to this resource")
+)
+
+const (
+	// apiKeyPrefix marks API keys so that they are easy to recognize in configs and secret scanners
+	apiKeyPrefix = "cck_"
+	// apiKeyDisplayLength is the number of leading characters of a key shown in key listings
+	apiKeyDisplayLength = 12
+	maxAPIKeyNameLength = 100
+	maxAPIKeysPerUser   = 20
+)
+
+// APIKeyRepository defines the interface for API key repository operations
+type APIKeyRepository interface {
+	Create(ctx context.Context, key model.APIKey) (model.APIKey, error)
+	GetActiveByHash(ctx context.Context, keyHash string) (model.APIKey, error)
+	Touch(ctx context.Context, id int64, ip string) error
+	ListByUserID(ctx context.Context, userID int64) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id int64, userID int64) error
+}
+
+// APIKey service manages personal API keys used by integrations instead of a login session
+type APIKey struct {
+	repo APIKeyRepository
+}
+
+// NewAPIKey creates a new API key service
+func NewAPIKey(repo APIKeyRepository) *APIKey {
+	return &APIKey{
+		repo: repo,
+	}
+}
+
+// Create mints a new API key with the given scopes. The key itself is returned only once,
+// only its hash is stored
+func (a *APIKey) Create(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (string, model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKey.Create")
+	defer span.End()
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		logger.FromContext(ctx).Error("Invalid api key name")
+		return "", model.APIKey{}, fmt.Errorf("%w", ErrInvalidAPIKeyName)
+	}
+	if len(scopes) == 0 {
+		return "", model.APIKey{}, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyScope)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(model.APIKeyScopes, scope) {
+			logger.FromContext(ctx).Errorf("Invalid api key scope %q", scope)
+			return "", model.APIKey{}, fmt.Errorf("%w: %s", ErrInvalidAPIKeyScope, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", model.APIKey{}, fmt.Errorf("%w", ErrInvalidAPIKeyExpiry)
+	}
+
+	existing, err := a.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+	if len(existing) >= maxAPIKeysPerUser {
+		return "", model.APIKey{}, fmt.Errorf("%w: revoke unused keys first", ErrTooManyAPIKeys)
+	}
+
+	secret, _, err := generateToken()
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+	key := apiKeyPrefix + secret
+
+	slices.Sort(scopes)
+	created, err := a.repo.Create(ctx, model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   hashToken(key),
+		Scopes:    slices.Compact(scopes),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+
+	return key, created, nil
+}
+
+// List returns API keys of the user that have not been revoked
+func (a *APIKey) List(ctx context.Context, userID int64) ([]model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKey.List")
+	defer span.End()
+
+	return a.repo.ListByUserID(ctx, userID)
+}
+
+// Revoke disables one of the user's API keys
+func (a *APIKey) Revoke(ctx context.Context, userID int64, id int64) error {
+	ctx, span := tracing.Start(ctx, "APIKey.Revoke")
+	defer span.End()
+
+	err := a.repo.Revoke(ctx, id, userID)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return fmt.Errorf("%w", ErrAPIKeyNotFound)
+	}
+	return err
+}
+
+// Authenticate validates an API key and returns claims limited to its scopes
+func (a *APIKey) Authenticate(ctx context.Context, key string, ip string) (auth.Claims, error) {
+	ctx, span := tracing.Start(ctx, "APIKey.Authenticate")
+	defer span.End()
+
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return auth.Claims{}, fmt.Errorf("%w", ErrInvalidAPIKey)
+	}
+
+	apiKey, err := a.repo.GetActiveByHash(ctx, hashToken(key))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return auth.Claims{}, fmt.Errorf("%w", ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return auth.Claims{}, err
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > lastSeenResolution || apiKey.LastUsedIP != ip {
+		if err = a.repo.Touch(ctx, apiKey.ID, ip); err != nil {
+			logger.FromContext(ctx).Errorf("Failed to update last use of api key %d: %v", apiKey.ID, err)
+		}
+	}
+
+	claims := auth.Claims{
+		UserID:   apiKey.UserID,
+		APIKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+	}
+	if apiKey.ExpiresAt != nil {
+		claims.ExpiresAt = apiKey.ExpiresAt.Unix()
+	}
+	return claims, nil
+}
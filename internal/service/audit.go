@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/CryptoCrowd/internal/audit"
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/requestmeta"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrInvalidAuditFilter = errors.New("invalid audit filter")
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	auditVerifyBatchSize = 1000
+)
+
+// AuditRepository defines the interface for the append-only audit log
+type AuditRepository interface {
+	Append(ctx context.Context, event model.AuditEvent) (model.AuditEvent, error)
+	List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error)
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]model.AuditEvent, error)
+}
+
+// Auditor records security- and money-relevant changes
+type Auditor interface {
+	// Change runs fn in a database transaction. Repository calls and events recorded with the
+	// context passed to fn are committed together, so a change is never stored without its event
+	Change(ctx context.Context, fn func(ctx context.Context) error) error
+	// Record appends an event. Called inside Change, the event is written in the change's transaction
+	Record(ctx context.Context, action string, targetType string, targetID int64, before any, after any) error
+}
+
+// Transactor runs a function in a database transaction joined by the queries made with its context
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// AuditVerification reports the result of a hash chain check
+type AuditVerification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAtID is the first event whose hash does not match its content or predecessor
+	BrokenAtID int64 `json:"broken_at_id,omitempty"`
+}
+
+// Audit service writes and queries the hash-chained audit log
+type Audit struct {
+	repo AuditRepository
+	txs  Transactor
+}
+
+// NewAudit creates a new audit service. txs runs audited changes in transactions
+func NewAudit(repo AuditRepository, txs Transactor) *Audit {
+	return &Audit{
+		repo: repo,
+		txs:  txs,
+	}
+}
+
+// Change runs fn in a transaction that also holds the audit events recorded in fn
+func (a *Audit) Change(ctx context.Context, fn func(ctx context.Context) error) error {
+	return a.txs.InTx(ctx, fn)
+}
+
+// Record appends an event with the fields that differ between before and after.
+// The actor, IP and request ID are taken from the context. Inside Change a failure
+// rolls the change back; outside of it the caller must report the failure
+func (a *Audit) Record(ctx context.Context, action string, targetType string, targetID int64, before any, after any) error {
+	ctx, span := tracing.Start(ctx, "Audit.Record")
+	defer span.End()
+
+	changes, err := diff(before, after)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("Failed to build audit diff for %s %s %d: %v", action, targetType, targetID, err)
+		return fmt.Errorf("failed to build audit diff: %w", err)
+	}
+
+	meta := requestmeta.FromContext(ctx)
+	event := model.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+		IP:         meta.IP,
+		RequestID:  meta.RequestID,
+	}
+	if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		event.ActorID = &claims.UserID
+	}
+
+	if _, err = a.repo.Append(ctx, event); err != nil {
+		logger.FromContext(ctx).Errorf("Failed to record audit event %s %s %d: %v", action, targetType, targetID, err)
+		return err
+	}
+	return nil
+}
+
+// List returns audit events matching the filter, newest first
+func (a *Audit) List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	ctx, span := tracing.Start(ctx, "Audit.List")
+	defer span.End()
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit < 0 || filter.Limit > maxAuditPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidAuditFilter, maxAuditPageSize)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAuditFilter)
+	}
+
+	return a.repo.List(ctx, filter)
+}
+
+// Verify recomputes the hash chain from the first event and reports the first broken link
+func (a *Audit) Verify(ctx context.Context) (AuditVerification, error) {
+	ctx, span := tracing.Start(ctx, "Audit.Verify")
+	defer span.End()
+
+	var (
+		result   = AuditVerification{Valid: true}
+		prevHash string
+		afterID  int64
+	)
+
+	for {
+		events, err := a.repo.ListAfter(ctx, afterID, auditVerifyBatchSize)
+		if err != nil {
+			return AuditVerification{}, err
+		}
+
+		for _, event := range events {
+			hash, err := audit.Hash(event)
+			if err != nil {
+				return AuditVerification{}, err
+			}
+			if event.PrevHash != prevHash || event.Hash != hash {
+				logger.FromContext(ctx).Errorf("Audit log hash chain is broken at event %d", event.ID)
+				result.Valid = false
+				result.BrokenAtID = event.ID
+				return result, nil
+			}
+
+			prevHash = event.Hash
+			afterID = event.ID
+			result.Checked++
+		}
+
+		if len(events) < auditVerifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// diff returns the JSON fields whose values differ between before and after.
+// Either side may be nil for created or deleted targets
+func diff(before any, after any) (map[string]model.AuditChange, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]model.AuditChange)
+	for key, value := range beforeFields {
+		if other, ok := afterFields[key]; !ok || !reflect.DeepEqual(value, other) {
+			changes[key] = model.AuditChange{Before: value, After: afterFields[key]}
+		}
+	}
+	for key, value := range afterFields {
+		if _, ok := beforeFields[key]; !ok {
+			changes[key] = model.AuditChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields flattens a value into its top-level JSON fields, so that the diff
+// never contains fields hidden from JSON such as password hashes
+func jsonFields(value any) (map[string]any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/audit"
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// memoryAuditLog appends audit events to a slice and chains their hashes
+// the way repository.PostgresAudit does
+type memoryAuditLog struct {
+	AuditRepository
+
+	events []model.AuditEvent
+	err    error
+}
+
+func (m *memoryAuditLog) Append(_ context.Context, event model.AuditEvent) (model.AuditEvent, error) {
+	if m.err != nil {
+		return model.AuditEvent{}, m.err
+	}
+
+	event.ID = int64(len(m.events) + 1)
+	event.PrevHash = ""
+	if len(m.events) > 0 {
+		event.PrevHash = m.events[len(m.events)-1].Hash
+	}
+	event.CreatedAt = time.Now().UTC()
+
+	var err error
+	if event.Hash, err = audit.Hash(event); err != nil {
+		return model.AuditEvent{}, err
+	}
+	m.events = append(m.events, event)
+	return event, nil
+}
+
+func (m *memoryAuditLog) ListAfter(_ context.Context, afterID int64, limit int) ([]model.AuditEvent, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var events []model.AuditEvent
+	for _, event := range m.events {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+type txMarker struct{}
+
+// markingTransactor marks the context passed to fn, so tests can tell that fn ran in a transaction
+type markingTransactor struct{}
+
+func (markingTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txMarker{}, true))
+}
+
+func TestAuditRecord(t *testing.T) {
+	errAppend := errors.New("append failed")
+
+	tests := []struct {
+		name        string
+		before      any
+		after       any
+		appendErr   error
+		wantErr     bool
+		wantChanges map[string]model.AuditChange
+	}{
+		{
+			name:        "changed fields only",
+			before:      map[string]any{"status": "pending", "name": "a"},
+			after:       map[string]any{"status": "approved", "name": "a"},
+			wantChanges: map[string]model.AuditChange{"status": {Before: "pending", After: "approved"}},
+		},
+		{
+			name:        "created target",
+			after:       map[string]any{"country": "DE"},
+			wantChanges: map[string]model.AuditChange{"country": {After: "DE"}},
+		},
+		{
+			name:        "deleted target",
+			before:      map[string]any{"country": "DE"},
+			wantChanges: map[string]model.AuditChange{"country": {Before: "DE"}},
+		},
+		{name: "append fails", after: map[string]any{"a": 1}, appendErr: errAppend, wantErr: true},
+		{name: "value cannot be encoded", after: map[string]any{"a": make(chan int)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &memoryAuditLog{err: tt.appendErr}
+			svc := NewAudit(log, markingTransactor{})
+
+			err := svc.Record(context.Background(), model.AuditProjectUpdate, model.AuditTargetProject, 3, tt.before, tt.after)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Record: error = %v, want error = %v", err, tt.wantErr)
+			}
+			if tt.appendErr != nil && !errors.Is(err, tt.appendErr) {
+				t.Fatalf("Record: error = %v, want %v", err, tt.appendErr)
+			}
+			if tt.wantErr {
+				if len(log.events) != 0 {
+					t.Fatalf("appended %d events, want none", len(log.events))
+				}
+				return
+			}
+
+			if len(log.events) != 1 {
+				t.Fatalf("appended %d events, want 1", len(log.events))
+			}
+			event := log.events[0]
+			if event.Action != model.AuditProjectUpdate || event.TargetType != model.AuditTargetProject || event.TargetID != 3 {
+				t.Fatalf("event = %+v", event)
+			}
+			if len(event.Changes) != len(tt.wantChanges) {
+				t.Fatalf("changes = %v, want %v", event.Changes, tt.wantChanges)
+			}
+			for key, want := range tt.wantChanges {
+				if got := event.Changes[key]; got != want {
+					t.Fatalf("change of %s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditChange(t *testing.T) {
+	svc := NewAudit(&memoryAuditLog{}, markingTransactor{})
+	errChange := errors.New("change failed")
+
+	var inTx bool
+	err := svc.Change(context.Background(), func(ctx context.Context) error {
+		inTx = ctx.Value(txMarker{}) != nil
+		return errChange
+	})
+	if !errors.Is(err, errChange) {
+		t.Fatalf("Change: error = %v, want %v", err, errChange)
+	}
+	if !inTx {
+		t.Fatal("Change did not run fn in a transaction")
+	}
+}
+
+func TestAuditVerify(t *testing.T) {
+	errList := errors.New("list failed")
+
+	// rehash recomputes the hash of a tampered event, as an attacker with write access would
+	rehash := func(t *testing.T, log *memoryAuditLog, idx int) {
+		t.Helper()
+
+		hash, err := audit.Hash(log.events[idx])
+		if err != nil {
+			t.Fatalf("Hash: %v", err)
+		}
+		log.events[idx].Hash = hash
+	}
+
+	tests := []struct {
+		name    string
+		events  int
+		tamper  func(t *testing.T, log *memoryAuditLog)
+		listErr error
+		want    AuditVerification
+		wantErr error
+	}{
+		{name: "empty log", want: AuditVerification{Valid: true}},
+		{name: "intact chain", events: 5, want: AuditVerification{Valid: true, Checked: 5}},
+		{name: "chain spanning several batches", events: auditVerifyBatchSize + 1, want: AuditVerification{Valid: true, Checked: auditVerifyBatchSize + 1}},
+		{
+			name:   "changed event",
+			events: 5,
+			tamper: func(_ *testing.T, log *memoryAuditLog) {
+				log.events[2].Changes = map[string]model.AuditChange{"status": {Before: "pending", After: "rejected"}}
+			},
+			want: AuditVerification{Checked: 2, BrokenAtID: 3},
+		},
+		{
+			name:   "changed event with a recomputed hash",
+			events: 5,
+			tamper: func(t *testing.T, log *memoryAuditLog) {
+				log.events[2].TargetID = 99
+				rehash(t, log, 2)
+			},
+			want: AuditVerification{Checked: 3, BrokenAtID: 4},
+		},
+		{
+			name:   "removed event",
+			events: 5,
+			tamper: func(_ *testing.T, log *memoryAuditLog) {
+				log.events = append(log.events[:1], log.events[2:]...)
+			},
+			want: AuditVerification{Checked: 1, BrokenAtID: 3},
+		},
+		{
+			name:   "first event rewritten to start a new chain",
+			events: 3,
+			tamper: func(t *testing.T, log *memoryAuditLog) {
+				log.events[0].PrevHash = "forged"
+				rehash(t, log, 0)
+			},
+			want: AuditVerification{BrokenAtID: 1},
+		},
+		{name: "log cannot be read", events: 1, listErr: errList, wantErr: errList},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			log := &memoryAuditLog{}
+			svc := NewAudit(log, markingTransactor{})
+			for idx := range tt.events {
+				if err := svc.Record(ctx, model.AuditProjectUpdate, model.AuditTargetProject, int64(idx),
+					map[string]any{"status": "pending"}, map[string]any{"status": "approved"}); err != nil {
+					t.Fatalf("Record: %v", err)
+				}
+			}
+			if tt.tamper != nil {
+				tt.tamper(t, log)
+			}
+			log.err = tt.listErr
+
+			got, err := svc.Verify(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify: error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Verify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
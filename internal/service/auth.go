@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/config"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrEmailNotVerified   = errors.New("email is not verified")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrWeakPassword       = errors.New("password must be at least 8 characters long")
+)
+
+const minPasswordLength = 8
+
+// TokenRepository defines the interface for single-use account token operations
+type TokenRepository interface {
+	Replace(ctx context.Context, token model.AccountToken) error
+	Consume(ctx context.Context, tokenHash string, purpose string) (int64, error)
+}
+
+// AuthAccountRepository defines the account operations needed by authentication flows
+type AuthAccountRepository interface {
+	GetByID(ctx context.Context, id int64) (model.Account, error)
+	GetByEmailAndRole(ctx context.Context, email string, role string) (model.Account, error)
+	MarkEmailVerified(ctx context.Context, id int64) error
+	UpdatePassword(ctx context.Context, id int64, newPassword string) error
+	Authenticate(ctx context.Context, email string, role string, password string) (model.Account, error)
+}
+
+// SecondFactor verifies two-factor codes of accounts that enabled them
+type SecondFactor interface {
+	IsEnabled(ctx context.Context, userID int64) (bool, error)
+	Verify(ctx context.Context, userID int64, code string, recoveryCode string) error
+}
+
+// EmailSender sends transactional emails to account owners
+type EmailSender interface {
+	SendEmail(ctx context.Context, userID int64, kind string, data map[string]any) error
+}
+
+// SessionManager starts, renews and revokes login sessions
+type SessionManager interface {
+	Start(ctx context.Context, userID int64, client model.ClientInfo, mfaAt time.Time) (string, auth.Claims, error)
+	Reissue(ctx context.Context, claims auth.Claims, mfaAt time.Time) (string, auth.Claims, error)
+	RevokeAll(ctx context.Context, userID int64) error
+}
+
+// Auth service implements login, email verification and password reset flows
+type Auth struct {
+	accounts      AuthAccountRepository
+	tokens        TokenRepository
+	emails        EmailSender
+	secondFactor  SecondFactor
+	sessions      SessionManager
+	frontendURL   string
+	emailTokenTTL time.Duration
+	resetTokenTTL time.Duration
+}
+
+// NewAuth creates a new authentication service
+func NewAuth(
+	accounts AuthAccountRepository,
+	tokens TokenRepository,
+	emails EmailSender,
+	secondFactor SecondFactor,
+	sessions SessionManager,
+	cfg config.AuthConfig,
+) *Auth {
+	return &Auth{
+		accounts:      accounts,
+		tokens:        tokens,
+		emails:        emails,
+		secondFactor:  secondFactor,
+		sessions:      sessions,
+		frontendURL:   cfg.FrontendURL,
+		emailTokenTTL: time.Duration(cfg.EmailTokenTTL) * time.Hour,
+		resetTokenTTL: time.Duration(cfg.PasswordResetTokenTTL) * time.Minute,
+	}
+}
+
+// Login checks the password and, if the account enabled it, the second factor,
+// and starts a new session for the client
+func (a *Auth) Login(
+	ctx context.Context,
+	email string,
+	role string,
+	password string,
+	code string,
+	recoveryCode string,
+	client model.ClientInfo,
+) (string, auth.Claims, error) {
+	ctx, span := tracing.Start(ctx, "Auth.Login")
+	defer span.End()
+
+	acc, err := a.accounts.Authenticate(ctx, email, role, password)
+	if errors.Is(err, repository.ErrInvalidCredentials) {
+		logger.FromContext(ctx).Errorf("Failed login attempt for %s (%s)", email, role)
+		return "", auth.Claims{}, fmt.Errorf("%w", ErrInvalidCredentials)
+	}
+	if err != nil {
+		return "", auth.Claims{}, err
+	}
+
+	enabled, err := a.secondFactor.IsEnabled(ctx, acc.ID)
+	if err != nil {
+		return "", auth.Claims{}, err
+	}
+
+	var mfaAt time.Time
+	if enabled {
+		if err = a.secondFactor.Verify(ctx, acc.ID, code, recoveryCode); err != nil {
+			return "", auth.Claims{}, err
+		}
+		mfaAt = time.Now()
+	}
+
+	return a.sessions.Start(ctx, acc.ID, client, mfaAt)
+}
+
+// StepUp re-confirms the second factor of an authenticated user and issues a token
+// for the same session that allows sensitive actions for a limited time
+func (a *Auth) StepUp(ctx context.Context, claims auth.Claims, code string, recoveryCode string) (string, auth.Claims, error) {
+	ctx, span := tracing.Start(ctx, "Auth.StepUp")
+	defer span.End()
+
+	if err := a.secondFactor.Verify(ctx, claims.UserID, code, recoveryCode); err != nil {
+		return "", auth.Claims{}, err
+	}
+
+	return a.sessions.Reissue(ctx, claims, time.Now())
+}
+
+// SendVerificationEmail issues a new email verification token and mails the link to the user.
+// Previously issued verification links stop working
+func (a *Auth) SendVerificationEmail(ctx context.Context, userID int64) error {
+	ctx, span := tracing.Start(ctx, "Auth.SendVerificationEmail")
+	defer span.End()
+
+	return a.issueToken(ctx, userID, model.TokenPurposeVerifyEmail, model.EmailVerification, "/verify-email", a.emailTokenTTL)
+}
+
+// VerifyEmail consumes a verification token and marks the owner's email as verified
+func (a *Auth) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "Auth.VerifyEmail")
+	defer span.End()
+
+	userID, err := a.consumeToken(ctx, token, model.TokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	return a.accounts.MarkEmailVerified(ctx, userID)
+}
+
+// ForgotPassword mails a password reset link if the account exists. The result does not
+// reveal whether the account exists
+func (a *Auth) ForgotPassword(ctx context.Context, email string, role string) error {
+	ctx, span := tracing.Start(ctx, "Auth.ForgotPassword")
+	defer span.End()
+
+	acc, err := a.accounts.GetByEmailAndRole(ctx, email, role)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		logger.FromContext(ctx).Debugf("Password reset requested for unknown account %s (%s)", email, role)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return a.issueToken(ctx, acc.ID, model.TokenPurposeResetPassword, model.EmailPasswordReset, "/reset-password", a.resetTokenTTL)
+}
+
+// ResetPassword consumes a reset token and sets a new password for its owner
+func (a *Auth) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "Auth.ResetPassword")
+	defer span.End()
+
+	if err := validatePassword(ctx, newPassword); err != nil {
+		return err
+	}
+
+	userID, err := a.consumeToken(ctx, token, model.TokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	if err = a.accounts.UpdatePassword(ctx, userID, newPassword); err != nil {
+		return err
+	}
+
+	// Whoever knew the old password must not stay logged in
+	return a.sessions.RevokeAll(ctx, userID)
+}
+
+func (a *Auth) issueToken(ctx context.Context, userID int64, purpose string, emailKind string, path string, ttl time.Duration) error {
+	token, hash, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	err = a.tokens.Replace(ctx, model.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := a.frontendURL + path + "?token=" + url.QueryEscape(token)
+	return a.emails.SendEmail(ctx, userID, emailKind, map[string]any{
+		"Link":      link,
+		"ExpiresAt": expiresAt.UTC().Format("02.01.2006 15:04 MST"),
+	})
+}
+
+func (a *Auth) consumeToken(ctx context.Context, token string, purpose string) (int64, error) {
+	if token == "" {
+		return 0, fmt.Errorf("%w", ErrInvalidToken)
+	}
+
+	userID, err := a.tokens.Consume(ctx, hashToken(token), purpose)
+	if errors.Is(err, repository.ErrTokenInvalid) {
+		logger.FromContext(ctx).Error("Invalid or expired account token")
+		return 0, fmt.Errorf("%w", ErrInvalidToken)
+	}
+	return userID, err
+}
+
+func validatePassword(ctx context.Context, password string) error {
+	if password == "" {
+		logger.FromContext(ctx).Error("Empty password")
+		return fmt.Errorf("%w", ErrEmptyPass)
+	}
+	if len(password) < minPasswordLength {
+		logger.FromContext(ctx).Error("Password is too short")
+		return fmt.Errorf("%w", ErrWeakPassword)
+	}
+	return nil
+}
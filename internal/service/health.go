@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/worker"
+)
+
+// healthCheckTimeout bounds each dependency check, so that a hanging database fails the probe
+// instead of outliving it
+const healthCheckTimeout = 3 * time.Second
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// Pinger checks that the database accepts queries
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// MigrationChecker returns the number of migrations that are not applied to the database yet
+type MigrationChecker func(ctx context.Context) (int, error)
+
+// HeartbeatSource returns the state of the running background jobs
+type HeartbeatSource func() []worker.Heartbeat
+
+// HealthCheck is the result of checking one dependency
+type HealthCheck struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// Readiness reports whether the instance can serve traffic, with a breakdown per dependency
+type Readiness struct {
+	Ready  bool                   `json:"ready"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// WorkerHealth describes a background job in the readiness breakdown
+type WorkerHealth struct {
+	Status    string    `json:"status"`
+	LastBeat  time.Time `json:"last_beat"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Health service answers liveness and readiness probes of the orchestrator
+type Health struct {
+	db                Pinger
+	pendingMigrations MigrationChecker
+	heartbeats        HeartbeatSource
+	draining          atomic.Bool
+}
+
+// NewHealth creates a new health service
+func NewHealth(db Pinger, pendingMigrations MigrationChecker, heartbeats HeartbeatSource) *Health {
+	return &Health{
+		db:                db,
+		pendingMigrations: pendingMigrations,
+		heartbeats:        heartbeats,
+	}
+}
+
+// Drain marks the instance as shutting down. From then on readiness fails, so that the load
+// balancer stops sending new requests before the server stops accepting them
+func (s *Health) Drain() {
+	s.draining.Store(true)
+}
+
+// Readiness checks the database, the schema version and the background jobs.
+// Failed job runs are reported but do not fail readiness, only jobs that stopped beating do
+func (s *Health) Readiness(ctx context.Context) Readiness {
+	result := Readiness{
+		Ready: true,
+		Checks: map[string]HealthCheck{
+			"database":   s.checkDatabase(ctx),
+			"migrations": s.checkMigrations(ctx),
+			"workers":    s.checkWorkers(),
+		},
+	}
+
+	if s.draining.Load() {
+		result.Checks["shutdown"] = HealthCheck{Status: HealthStatusDown, Error: "shutting down"}
+	}
+
+	for name, check := range result.Checks {
+		if check.Status != HealthStatusUp {
+			result.Ready = false
+			logger.FromContext(ctx).Warnf("Readiness check %s failed: %s", name, check.Error)
+		}
+	}
+	return result
+}
+
+func (s *Health) checkDatabase(ctx context.Context) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	if err := s.db.Ping(ctx); err != nil {
+		return HealthCheck{Status: HealthStatusDown, Error: err.Error()}
+	}
+	return HealthCheck{Status: HealthStatusUp}
+}
+
+func (s *Health) checkMigrations(ctx context.Context) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	pending, err := s.pendingMigrations(ctx)
+	if err != nil {
+		return HealthCheck{Status: HealthStatusDown, Error: err.Error()}
+	}
+
+	details := map[string]int{"pending": pending}
+	if pending > 0 {
+		return HealthCheck{Status: HealthStatusDown, Error: "database schema is behind the application", Details: details}
+	}
+	return HealthCheck{Status: HealthStatusUp, Details: details}
+}
+
+func (s *Health) checkWorkers() HealthCheck {
+	now := time.Now()
+	check := HealthCheck{Status: HealthStatusUp}
+	workers := make(map[string]WorkerHealth)
+
+	for _, hb := range s.heartbeats() {
+		status := HealthStatusUp
+		if hb.Stale(now) {
+			status = HealthStatusDown
+			check.Status = HealthStatusDown
+			check.Error = "background job " + hb.Name + " stopped"
+		}
+		workers[hb.Name] = WorkerHealth{Status: status, LastBeat: hb.LastBeat, LastError: hb.LastError}
+	}
+
+	check.Details = workers
+	return check
+}
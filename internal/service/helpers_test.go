@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// auditEntry is an event captured by recordingAuditor
+type auditEntry struct {
+	action   string
+	target   string
+	targetID int64
+	before   any
+	after    any
+}
+
+// recordingAuditor keeps audit events in memory. Events recorded in a Change that fails
+// are dropped, as the transaction holding them would be rolled back
+type recordingAuditor struct {
+	mu     sync.Mutex
+	events []auditEntry
+	// err is returned by Record instead of recording the event
+	err error
+}
+
+func (a *recordingAuditor) Change(ctx context.Context, fn func(ctx context.Context) error) error {
+	a.mu.Lock()
+	recorded := len(a.events)
+	a.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		a.mu.Lock()
+		a.events = a.events[:recorded]
+		a.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (a *recordingAuditor) Record(_ context.Context, action string, targetType string, targetID int64, before any, after any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.err != nil {
+		return a.err
+	}
+	a.events = append(a.events, auditEntry{action: action, target: targetType, targetID: targetID, before: before, after: after})
+	return nil
+}
+
+func (a *recordingAuditor) actions() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	actions := make([]string, len(a.events))
+	for idx, e := range a.events {
+		actions[idx] = e.action
+	}
+	return actions
+}
+
+// sessionRevokerFunc adapts a function to SessionRevoker
+type sessionRevokerFunc func(ctx context.Context, userID int64) error
+
+func (f sessionRevokerFunc) RevokeAll(ctx context.Context, userID int64) error {
+	return f(ctx, userID)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrInvalidIdempotencyKey    = errors.New("idempotency key must be 1 to 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still in progress")
+)
+
+// maxIdempotencyKeyLength is the length of the idempotency_keys.key column
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyRepository defines the interface for idempotency key storage
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, key model.IdempotencyKey) (model.IdempotencyKey, error)
+	Get(ctx context.Context, userID int64, key string) (model.IdempotencyKey, error)
+	Complete(ctx context.Context, id int64, status int, contentType string, body []byte) error
+	Release(ctx context.Context, id int64) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Idempotency service makes retried requests safe: the first request with a key is executed
+// and its response stored, later requests with the same key get the stored response
+type Idempotency struct {
+	repo        IdempotencyRepository
+	ttl         time.Duration
+	lockTimeout time.Duration
+}
+
+// NewIdempotency creates a new idempotency service. Keys can be reused after ttl. A request
+// that has not completed within lockTimeout, e.g. because the process crashed or its response
+// could not be stored, no longer holds the key and a retry is executed again
+func NewIdempotency(repo IdempotencyRepository, ttl time.Duration, lockTimeout time.Duration) *Idempotency {
+	return &Idempotency{
+		repo:        repo,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+	}
+}
+
+// Begin reserves the key for a request with the given fingerprint. If the key was already
+// used for the same request, the stored record holding the response is returned with
+// replay set, and the request must not be executed again
+func (s *Idempotency) Begin(ctx context.Context, userID int64, key string, fingerprint string) (model.IdempotencyKey, bool, error) {
+	ctx, span := tracing.Start(ctx, "Idempotency.Begin")
+	defer span.End()
+
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return model.IdempotencyKey{}, false, fmt.Errorf("%w", ErrInvalidIdempotencyKey)
+	}
+
+	now := time.Now()
+	reserved, err := s.repo.Reserve(ctx, model.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+		LockedUntil: now.Add(s.lockTimeout),
+	})
+	if err == nil {
+		return reserved, false, nil
+	}
+	if !errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		return model.IdempotencyKey{}, false, err
+	}
+
+	existing, err := s.repo.Get(ctx, userID, key)
+	if errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+		// Released by the first request between the two queries, the client can retry
+		return model.IdempotencyKey{}, false, fmt.Errorf("%w", ErrIdempotencyKeyInProgress)
+	}
+	if err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+
+	if existing.Fingerprint != fingerprint {
+		logger.FromContext(ctx).Errorf("User %d reused idempotency key for a different request", userID)
+		return model.IdempotencyKey{}, false, fmt.Errorf("%w", ErrIdempotencyKeyReused)
+	}
+	if existing.ResponseStatus == nil {
+		return model.IdempotencyKey{}, false, fmt.Errorf("%w", ErrIdempotencyKeyInProgress)
+	}
+	return existing, true, nil
+}
+
+// Complete stores the response of the request that reserved the key
+func (s *Idempotency) Complete(ctx context.Context, id int64, status int, contentType string, body []byte) error {
+	ctx, span := tracing.Start(ctx, "Idempotency.Complete")
+	defer span.End()
+
+	return s.repo.Complete(ctx, id, status, contentType, body)
+}
+
+// Release frees the key of a request that did not succeed so that the client can retry it
+func (s *Idempotency) Release(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "Idempotency.Release")
+	defer span.End()
+
+	return s.repo.Release(ctx, id)
+}
+
+// DeleteExpired removes keys whose retention window has ended
+func (s *Idempotency) DeleteExpired(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "Idempotency.DeleteExpired")
+	defer span.End()
+
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		logger.FromContext(ctx).Debugf("Deleted %d expired idempotency keys", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+)
+
+type idempotencyKeyID struct {
+	userID int64
+	key    string
+}
+
+// memoryIdempotencyKeys keeps idempotency keys in memory. Like repository.PostgresIdempotency,
+// Reserve takes over an expired key or the key of a request that did not complete in time,
+// and fails on a live one
+type memoryIdempotencyKeys struct {
+	IdempotencyRepository
+
+	keys   map[idempotencyKeyID]model.IdempotencyKey
+	nextID int64
+}
+
+func newMemoryIdempotencyKeys() *memoryIdempotencyKeys {
+	return &memoryIdempotencyKeys{keys: make(map[idempotencyKeyID]model.IdempotencyKey)}
+}
+
+func (m *memoryIdempotencyKeys) Reserve(_ context.Context, key model.IdempotencyKey) (model.IdempotencyKey, error) {
+	id := idempotencyKeyID{userID: key.UserID, key: key.Key}
+	if existing, ok := m.keys[id]; ok && existing.ExpiresAt.After(key.CreatedAt) &&
+		(existing.ResponseStatus != nil || existing.LockedUntil.After(key.CreatedAt)) {
+		return model.IdempotencyKey{}, repository.ErrIdempotencyKeyExists
+	}
+
+	m.nextID++
+	key.ID = m.nextID
+	m.keys[id] = key
+	return key, nil
+}
+
+func (m *memoryIdempotencyKeys) Get(_ context.Context, userID int64, key string) (model.IdempotencyKey, error) {
+	found, ok := m.keys[idempotencyKeyID{userID: userID, key: key}]
+	if !ok {
+		return model.IdempotencyKey{}, repository.ErrIdempotencyKeyNotFound
+	}
+	return found, nil
+}
+
+func (m *memoryIdempotencyKeys) Complete(_ context.Context, id int64, status int, contentType string, body []byte) error {
+	for keyID, key := range m.keys {
+		if key.ID == id {
+			key.ResponseStatus = &status
+			key.ResponseContentType = contentType
+			key.ResponseBody = body
+			m.keys[keyID] = key
+		}
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyKeys) Release(_ context.Context, id int64) error {
+	for keyID, key := range m.keys {
+		if key.ID == id {
+			delete(m.keys, keyID)
+		}
+	}
+	return nil
+}
+
+func TestIdempotencyBegin(t *testing.T) {
+	const (
+		userID      = int64(7)
+		key         = "key-1"
+		fingerprint = "POST /investments {amount:100}"
+	)
+	status := 201
+
+	tests := []struct {
+		name        string
+		existing    *model.IdempotencyKey
+		userID      int64
+		key         string
+		fingerprint string
+		wantReplay  bool
+		wantErr     error
+	}{
+		{name: "new key", userID: userID, key: key, fingerprint: fingerprint},
+		{
+			name:        "completed request is replayed",
+			existing:    &model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint, ResponseStatus: &status, ExpiresAt: time.Now().Add(time.Hour)},
+			userID:      userID,
+			key:         key,
+			fingerprint: fingerprint,
+			wantReplay:  true,
+		},
+		{
+			name:        "request still in progress",
+			existing:    &model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint, ExpiresAt: time.Now().Add(time.Hour), LockedUntil: time.Now().Add(time.Minute)},
+			userID:      userID,
+			key:         key,
+			fingerprint: fingerprint,
+			wantErr:     ErrIdempotencyKeyInProgress,
+		},
+		{
+			name:        "request that did not complete in time is executed again",
+			existing:    &model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint, ExpiresAt: time.Now().Add(time.Hour), LockedUntil: time.Now().Add(-time.Second)},
+			userID:      userID,
+			key:         key,
+			fingerprint: fingerprint,
+		},
+		{
+			name:        "completed request is replayed after the reservation timed out",
+			existing:    &model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint, ResponseStatus: &status, ExpiresAt: time.Now().Add(time.Hour), LockedUntil: time.Now().Add(-time.Second)},
+			userID:      userID,
+			key:         key,
+			fingerprint: fingerprint,
+			wantReplay:  true,
+		},
+		{
+			name:        "key reused for another request",
+			existing:    &model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint, ResponseStatus: &status, ExpiresAt: time.Now().Add(time.Hour)},
+			userID:      userID,
+			key:         key,
+			fingerprint: "POST /investments {amount:200}",
+			wantErr:     ErrIdempotencyKeyReused,
+		},
+		{
+			name:        "expired key is reserved again",
+			existing:    &model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: "old request", ResponseStatus: &status, ExpiresAt: time.Now().Add(-time.Second)},
+			userID:      userID,
+			key:         key,
+			fingerprint: fingerprint,
+		},
+		{
+			name:        "same key of another user",
+			existing:    &model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint, ResponseStatus: &status, ExpiresAt: time.Now().Add(time.Hour)},
+			userID:      8,
+			key:         key,
+			fingerprint: fingerprint,
+		},
+		{name: "empty key", userID: userID, fingerprint: fingerprint, wantErr: ErrInvalidIdempotencyKey},
+		{name: "key too long", userID: userID, key: strings.Repeat("k", maxIdempotencyKeyLength+1), fingerprint: fingerprint, wantErr: ErrInvalidIdempotencyKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryIdempotencyKeys()
+			if tt.existing != nil {
+				repo.nextID++
+				existing := *tt.existing
+				existing.ID = repo.nextID
+				repo.keys[idempotencyKeyID{userID: existing.UserID, key: existing.Key}] = existing
+			}
+			svc := NewIdempotency(repo, time.Hour, time.Minute)
+
+			record, replay, err := svc.Begin(context.Background(), tt.userID, tt.key, tt.fingerprint)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Begin: error = %v, want %v", err, tt.wantErr)
+			}
+			if replay != tt.wantReplay {
+				t.Fatalf("Begin: replay = %v, want %v", replay, tt.wantReplay)
+			}
+			if err == nil && record.Fingerprint != tt.fingerprint {
+				t.Fatalf("Begin: record fingerprint = %q, want %q", record.Fingerprint, tt.fingerprint)
+			}
+		})
+	}
+}
+
+func TestIdempotencyLifecycle(t *testing.T) {
+	ctx := context.Background()
+	svc := NewIdempotency(newMemoryIdempotencyKeys(), time.Hour, time.Minute)
+
+	record, replay, err := svc.Begin(ctx, 7, "key-1", "request")
+	if err != nil || replay {
+		t.Fatalf("first Begin = (%v, %v), want a reservation", replay, err)
+	}
+
+	// A failed request releases the key, so the retry is executed
+	if err = svc.Release(ctx, record.ID); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if record, replay, err = svc.Begin(ctx, 7, "key-1", "request"); err != nil || replay {
+		t.Fatalf("Begin after release = (%v, %v), want a reservation", replay, err)
+	}
+
+	if err = svc.Complete(ctx, record.ID, 201, "application/json", []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	stored, replay, err := svc.Begin(ctx, 7, "key-1", "request")
+	if err != nil || !replay {
+		t.Fatalf("Begin after complete = (%v, %v), want a replay", replay, err)
+	}
+	if *stored.ResponseStatus != 201 || string(stored.ResponseBody) != `{"id":1}` || stored.ResponseContentType != "application/json" {
+		t.Fatalf("replayed response = %d %s %s", *stored.ResponseStatus, stored.ResponseContentType, stored.ResponseBody)
+	}
+}
+
+func TestIdempotencyTakesOverTimedOutReservation(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryIdempotencyKeys()
+	svc := NewIdempotency(repo, time.Hour, time.Minute)
+
+	stale, _, err := svc.Begin(ctx, 7, "key-1", "request")
+	if err != nil {
+		t.Fatalf("first Begin: %v", err)
+	}
+
+	// The process executing the first request crashed, so its reservation timed out
+	id := idempotencyKeyID{userID: 7, key: "key-1"}
+	record := repo.keys[id]
+	record.LockedUntil = time.Now().Add(-time.Second)
+	repo.keys[id] = record
+
+	retry, replay, err := svc.Begin(ctx, 7, "key-1", "request")
+	if err != nil || replay {
+		t.Fatalf("Begin after the timeout = (%v, %v), want a reservation", replay, err)
+	}
+	if retry.ID == stale.ID {
+		t.Fatalf("retry reserved the key with the ID %d of the timed out request", retry.ID)
+	}
+
+	// A late response of the first request must not complete the reservation of the retry
+	if err = svc.Complete(ctx, stale.ID, 201, "application/json", []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, _, err = svc.Begin(ctx, 7, "key-1", "request"); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+		t.Fatalf("Begin while the retry runs: error = %v, want %v", err, ErrIdempotencyKeyInProgress)
+	}
+}
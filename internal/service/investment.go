@@ -4,94 +4,591 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CryptoCrowd/internal/compliance"
 	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/metrics"
 	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+	"github.com/shopspring/decimal"
 )
 
 var (
 	ErrInvalidInvestmentUser    = errors.New("invalid investment user")
 	ErrInvalidInvestmentProject = errors.New("invalid investment project")
 	ErrInvalidInvestmentAmount  = errors.New("invalid investment amount")
+	ErrProjectNotOpen           = errors.New("project is not open for investments")
+	ErrInvestmentAccessDenied   = errors.New("investments of other users are not accessible")
+	ErrInvestmentRulesViolated  = errors.New("investment violates compliance rules")
+	ErrInvalidInvestmentRules   = errors.New("invalid investment rules")
 )
 
+// InvestmentRulesError lists the compliance rules an investment violates
+type InvestmentRulesError struct {
+	Violations []compliance.Violation
+}
+
+func (e *InvestmentRulesError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for idx, v := range e.Violations {
+		messages[idx] = v.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrInvestmentRulesViolated, strings.Join(messages, "; "))
+}
+
+func (e *InvestmentRulesError) Unwrap() error {
+	return ErrInvestmentRulesViolated
+}
+
+// ProjectInvestmentRules describes the rules set for a project and the rules in effect for it
+type ProjectInvestmentRules struct {
+	Project   model.InvestmentRules `json:"project"`
+	Effective model.InvestmentRules `json:"effective"`
+}
+
+// InvestmentRulesRepository defines the interface for per-project investment rules
+type InvestmentRulesRepository interface {
+	GetByProjectID(ctx context.Context, projectID int64) (model.InvestmentRules, error)
+	Upsert(ctx context.Context, projectID int64, rules model.InvestmentRules) error
+}
+
 // InvestmentRepository defines the interface for investment repository operations
 type InvestmentRepository interface {
-	Create(ctx context.Context, investment model.Investment) error
-	Update(ctx context.Context, investment model.Investment) error
-	Delete(ctx context.Context, id int64) error
+	Create(ctx context.Context, investment model.Investment, guard repository.InvestmentGuard) (int64, error)
+	Update(ctx context.Context, investment model.Investment, guard repository.InvestmentGuard) error
+	Delete(ctx context.Context, id int64, version int64) error
 	GetByID(ctx context.Context, id int64) (model.Investment, error)
 	GetByUserID(ctx context.Context, userID int64) ([]model.Investment, error)
 	GetByProjectID(ctx context.Context, projectID int64) ([]model.Investment, error)
+	Totals(ctx context.Context, userID int64, projectID int64, yearStart time.Time) (model.InvestmentTotals, error)
+	ListDeleted(ctx context.Context) ([]model.Investment, error)
+	GetDeletedByID(ctx context.Context, id int64) (model.Investment, error)
+	Restore(ctx context.Context, id int64, guard repository.InvestmentGuard) (model.Investment, error)
 }
 
 // Investment service implements business logic for investment operations
 type Investment struct {
-	repo    InvestmentRepository
-	project ProjectRepository
+	repo         InvestmentRepository
+	project      ProjectRepository
+	accounts     AccountReader
+	auditor      Auditor
+	largeAmount  decimal.Decimal
+	stepUpWindow time.Duration
+	kycLimit     decimal.Decimal
+	rules        InvestmentRulesRepository
+	globalRules  model.InvestmentRules
+	screener     SanctionsScreener
 }
 
-// NewInvestment creates a new investment service
-func NewInvestment(repo InvestmentRepository, project ProjectRepository) *Investment {
+// NewInvestment creates a new investment service. Investments of largeAmount or more
+// require a second factor confirmed within stepUpWindow. Investors without KYC can invest
+// at most kycLimit in total. globalRules apply to every project unless overridden for it
+func NewInvestment(
+	repo InvestmentRepository,
+	project ProjectRepository,
+	accounts AccountReader,
+	auditor Auditor,
+	largeAmount decimal.Decimal,
+	stepUpWindow time.Duration,
+	kycLimit decimal.Decimal,
+	rules InvestmentRulesRepository,
+	globalRules model.InvestmentRules,
+	screener SanctionsScreener,
+) *Investment {
 	return &Investment{
-		repo:    repo,
-		project: project,
+		repo:         repo,
+		project:      project,
+		accounts:     accounts,
+		auditor:      auditor,
+		largeAmount:  largeAmount,
+		stepUpWindow: stepUpWindow,
+		kycLimit:     kycLimit,
+		rules:        rules,
+		globalRules:  globalRules,
+		screener:     screener,
 	}
 }
 
 // validateInvestment validates investment data
-func (i *Investment) validateInvestment(investment model.Investment) error {
+func (i *Investment) validateInvestment(ctx context.Context, investment model.Investment) error {
 	// Validate investment user
 	if investment.UserID <= 0 {
-		logger.Error("Invalid investment user")
+		logger.FromContext(ctx).Error("Invalid investment user")
 		return fmt.Errorf("%w", ErrInvalidInvestmentUser)
 	}
 
 	// Validate investment project
 	if investment.ProjectID <= 0 {
-		logger.Error("Invalid investment project")
+		logger.FromContext(ctx).Error("Invalid investment project")
 		return fmt.Errorf("%w", ErrInvalidInvestmentProject)
 	}
 
 	// Validate investment amount
-	if investment.Amount <= 0 {
-		logger.Error("Invalid investment amount")
+	if investment.Amount.LessThanOrEqual(decimal.Zero) {
+		logger.FromContext(ctx).Error("Invalid investment amount")
 		return fmt.Errorf("%w", ErrInvalidInvestmentAmount)
 	}
 
 	return nil
 }
 
-// Create creates a new investment with validation and updates project's raised amount
+// Create creates a new investment with validation and updates project's raised amount.
+// The KYC limit and the investment rules are evaluated again inside the insert transaction
+// against the totals of the locked investor, so concurrent investments cannot exceed them together
 func (i *Investment) Create(ctx context.Context, investment model.Investment) error {
+	ctx, span := tracing.Start(ctx, "Investment.Create")
+	defer span.End()
+
+	if err := i.validateInvestment(ctx, investment); err != nil {
+		return err
+	}
+
+	if err := ensureVerified(ctx, i.accounts, investment.UserID); err != nil {
+		return err
+	}
+
+	chk, err := i.prepareCheck(ctx, investment)
+	if err != nil {
+		return err
+	}
+	if err = i.enforce(ctx, chk, investment); err != nil {
+		return err
+	}
+
+	if investment.Amount.GreaterThanOrEqual(i.largeAmount) {
+		if err := ensureStepUp(ctx, i.stepUpWindow); err != nil {
+			return err
+		}
+	}
+
+	guard := i.lockedGuard(ctx, chk, investment)
+	err = i.auditor.Change(ctx, func(ctx context.Context) error {
+		id, err := i.repo.Create(ctx, investment, guard)
+		if errors.Is(err, repository.ErrProjectClosed) {
+			logger.FromContext(ctx).Errorf("Project %d closed before the investment of user %d was saved", investment.ProjectID, investment.UserID)
+			return fmt.Errorf("%w", ErrProjectNotOpen)
+		}
+		if err != nil {
+			return err
+		}
+
+		investment.ID = id
+		return i.auditor.Record(ctx, model.AuditInvestmentCreate, model.AuditTargetInvestment, id, nil, investment)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.InvestmentCreated(investment.Amount)
+	return nil
+}
+
+// Check evaluates an investment the way Create does without persisting it,
+// so that the frontend can pre-check an amount. No violations means the investment is allowed
+func (i *Investment) Check(ctx context.Context, investment model.Investment) ([]compliance.Violation, error) {
+	ctx, span := tracing.Start(ctx, "Investment.Check")
+	defer span.End()
+
+	if err := i.validateInvestment(ctx, investment); err != nil {
+		return nil, err
+	}
+
+	chk, err := i.prepareCheck(ctx, investment)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := i.repo.Totals(ctx, investment.UserID, investment.ProjectID, chk.yearStart)
+	if err != nil {
+		return nil, err
+	}
+	return i.evaluate(ctx, chk, totals)
+}
+
+// investmentCheck holds what an investment is evaluated against apart from the investor's totals,
+// which change with concurrent investments and are read again under lock before the insert
+type investmentCheck struct {
+	account   model.Account
+	rules     []compliance.Rule
+	facts     compliance.Facts
+	yearStart time.Time
+	// replaced is the current state of an investment whose amount changes. Its amount is left
+	// out of the totals, since the new amount replaces it
+	replaced *model.Investment
+}
+
+// prepareCheck screens the investor against sanctions lists, verifies that the project is open
+// and loads the effective investment rules of the project
+func (i *Investment) prepareCheck(ctx context.Context, investment model.Investment) (investmentCheck, error) {
+	if err := i.screener.ScreenAccount(ctx, investment.UserID, model.ScreeningTriggerInvestment); err != nil {
+		return investmentCheck{}, err
+	}
+
+	acc, err := i.accounts.GetByID(ctx, investment.UserID)
+	if err != nil {
+		return investmentCheck{}, err
+	}
+
+	project, err := i.project.GetByID(ctx, investment.ProjectID)
+	if err != nil {
+		return investmentCheck{}, err
+	}
+
+	if !isOpenForInvestments(project) {
+		logger.FromContext(ctx).Errorf("Project %d is not open for investments", project.ID)
+		return investmentCheck{}, fmt.Errorf("%w", ErrProjectNotOpen)
+	}
+
+	projectRules, err := i.rules.GetByProjectID(ctx, project.ID)
+	if err != nil {
+		return investmentCheck{}, err
+	}
+
+	return investmentCheck{
+		account: acc,
+		rules:   compliance.Build(compliance.Merge(i.globalRules, projectRules)),
+		facts: compliance.Facts{
+			Amount:        investment.Amount,
+			Country:       acc.Country,
+			ProjectTarget: project.AmountRequested,
+		},
+		yearStart: time.Date(time.Now().UTC().Year(), time.January, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+// enforce evaluates the investment against the current totals of the investor and fails
+// before the step-up check and the insert when a limit is already exceeded
+func (i *Investment) enforce(ctx context.Context, chk investmentCheck, investment model.Investment) error {
+	totals, err := i.repo.Totals(ctx, investment.UserID, investment.ProjectID, chk.yearStart)
+	if err != nil {
+		return err
+	}
+
+	violations, err := i.evaluate(ctx, chk, totals)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		logger.FromContext(ctx).Errorf("Investment of user %d in project %d violates %d rules", investment.UserID, investment.ProjectID, len(violations))
+		return &InvestmentRulesError{Violations: violations}
+	}
 	return nil
 }
 
-// Update updates an existing investment with validation
-func (i *Investment) Update(ctx context.Context, investment model.Investment, userID int64) error {
+// lockedGuard evaluates the investment again against the totals the repository reads
+// while the investor and the project are locked
+func (i *Investment) lockedGuard(ctx context.Context, chk investmentCheck, investment model.Investment) repository.InvestmentGuard {
+	return repository.InvestmentGuard{
+		YearStart: chk.yearStart,
+		Check: func(totals model.InvestmentTotals) error {
+			violations, err := i.evaluate(ctx, chk, totals)
+			if err != nil {
+				return err
+			}
+			if len(violations) > 0 {
+				logger.FromContext(ctx).Errorf("Investment of user %d in project %d violates %d rules after locking", investment.UserID, investment.ProjectID, len(violations))
+				return &InvestmentRulesError{Violations: violations}
+			}
+			return nil
+		},
+	}
+}
+
+// evaluate verifies the KYC limit and evaluates the investment rules given the investor's totals
+func (i *Investment) evaluate(ctx context.Context, chk investmentCheck, totals model.InvestmentTotals) ([]compliance.Violation, error) {
+	if r := chk.replaced; r != nil {
+		totals.Total = totals.Total.Sub(r.Amount)
+		totals.InProject = totals.InProject.Sub(r.Amount)
+		if r.InvestedAt != nil && !r.InvestedAt.Before(chk.yearStart) {
+			totals.ThisYear = totals.ThisYear.Sub(r.Amount)
+		}
+	}
+
+	if err := i.ensureWithinKYCLimit(ctx, chk.account, totals.Total, chk.facts.Amount); err != nil {
+		return nil, err
+	}
+
+	facts := chk.facts
+	facts.InvestedThisYear = totals.ThisYear
+	facts.InvestedInProject = totals.InProject
+	return compliance.Evaluate(chk.rules, facts), nil
+}
+
+// isOpenForInvestments reports whether the project is an approved campaign that has not
+// reached its deadline. Only such projects accept and release investments
+func isOpenForInvestments(project model.Project) bool {
+	return project.Status == "approved" && (project.DeadlineAt == nil || !project.DeadlineAt.Before(time.Now()))
+}
+
+// ensureWithinKYCLimit returns ErrKYCRequired if an investor without verified identity
+// would exceed the total amount allowed without KYC
+func (i *Investment) ensureWithinKYCLimit(ctx context.Context, acc model.Account, invested decimal.Decimal, amount decimal.Decimal) error {
+	if acc.KYCStatus == model.KYCVerified {
+		return nil
+	}
+
+	if invested.Add(amount).GreaterThan(i.kycLimit) {
+		logger.FromContext(ctx).Errorf("User %d without KYC exceeds the investment limit", acc.ID)
+		return fmt.Errorf("%w: investments above %s require a verified identity", ErrKYCRequired, i.kycLimit)
+	}
 	return nil
 }
 
-// Delete deletes an investment
-func (i *Investment) Delete(ctx context.Context, id int64, userID int64) error {
+// GetProjectRules returns the investment rules set for a project and the effective
+// rules after merging them with the global ones
+func (i *Investment) GetProjectRules(ctx context.Context, projectID int64) (ProjectInvestmentRules, error) {
+	ctx, span := tracing.Start(ctx, "Investment.GetProjectRules")
+	defer span.End()
+
+	if _, err := i.project.GetByID(ctx, projectID); err != nil {
+		return ProjectInvestmentRules{}, err
+	}
+
+	rules, err := i.rules.GetByProjectID(ctx, projectID)
+	if err != nil {
+		return ProjectInvestmentRules{}, err
+	}
+	return newProjectInvestmentRules(i.globalRules, rules), nil
+}
+
+// SetProjectRules replaces the investment rules of a project. Limits left empty fall back to the global ones
+func (i *Investment) SetProjectRules(ctx context.Context, projectID int64, rules model.InvestmentRules) (ProjectInvestmentRules, error) {
+	ctx, span := tracing.Start(ctx, "Investment.SetProjectRules")
+	defer span.End()
+
+	for idx, country := range rules.BlockedCountries {
+		rules.BlockedCountries[idx] = strings.ToUpper(strings.TrimSpace(country))
+	}
+	if err := compliance.Validate(rules); err != nil {
+		logger.FromContext(ctx).Errorf("Invalid investment rules for project %d: %v", projectID, err)
+		return ProjectInvestmentRules{}, fmt.Errorf("%w: %w", ErrInvalidInvestmentRules, err)
+	}
+
+	before, err := i.GetProjectRules(ctx, projectID)
+	if err != nil {
+		return ProjectInvestmentRules{}, err
+	}
+
+	err = i.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := i.rules.Upsert(ctx, projectID, rules); err != nil {
+			return err
+		}
+		return i.auditor.Record(ctx, model.AuditProjectRulesChange, model.AuditTargetProject, projectID, before.Project, rules)
+	})
+	if err != nil {
+		return ProjectInvestmentRules{}, err
+	}
+
+	return newProjectInvestmentRules(i.globalRules, rules), nil
+}
+
+func newProjectInvestmentRules(global model.InvestmentRules, project model.InvestmentRules) ProjectInvestmentRules {
+	if project.BlockedCountries == nil {
+		project.BlockedCountries = []string{}
+	}
+	return ProjectInvestmentRules{
+		Project:   project,
+		Effective: compliance.Merge(global, project),
+	}
+}
+
+// Update changes the amount of an investment of the requesting user and returns its new version.
+// The new amount is checked like a new investment that replaces the current one,
+// and only while the project is still collecting funds
+func (i *Investment) Update(ctx context.Context, id int64, userID int64, amount decimal.Decimal, version int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "Investment.Update")
+	defer span.End()
+
+	current, err := i.GetByID(ctx, id, userID)
+	if err != nil {
+		return 0, err
+	}
+	if current.Version != version {
+		return 0, repository.ErrVersionConflict
+	}
+
+	updated := current
+	updated.Amount = amount
+	if err = i.validateInvestment(ctx, updated); err != nil {
+		return 0, err
+	}
+	if updated.Amount.Equal(current.Amount) {
+		return current.Version, nil
+	}
+
+	if err = ensureVerified(ctx, i.accounts, userID); err != nil {
+		return 0, err
+	}
+
+	chk, err := i.prepareCheck(ctx, updated)
+	if err != nil {
+		return 0, err
+	}
+	chk.replaced = &current
+	if err = i.enforce(ctx, chk, updated); err != nil {
+		return 0, err
+	}
+
+	if amount.GreaterThan(current.Amount) && amount.GreaterThanOrEqual(i.largeAmount) {
+		if err := ensureStepUp(ctx, i.stepUpWindow); err != nil {
+			return 0, err
+		}
+	}
+
+	guard := i.lockedGuard(ctx, chk, updated)
+	err = i.auditor.Change(ctx, func(ctx context.Context) error {
+		err := i.repo.Update(ctx, updated, guard)
+		if errors.Is(err, repository.ErrProjectClosed) {
+			logger.FromContext(ctx).Errorf("User %d tried to change investment %d in closed project %d", userID, id, current.ProjectID)
+			return fmt.Errorf("%w", ErrProjectNotOpen)
+		}
+		if err != nil {
+			return err
+		}
+		return i.auditor.Record(ctx, model.AuditInvestmentUpdate, model.AuditTargetInvestment, id,
+			map[string]any{"amount": current.Amount}, map[string]any{"amount": amount})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logger.FromContext(ctx).Infof("Investment %d changed", id)
+	return version + 1, nil
+}
+
+// Delete cancels an investment of the requesting user and subtracts its amount from the project.
+// Investments can be cancelled only while the project is still collecting funds
+func (i *Investment) Delete(ctx context.Context, id int64, userID int64, version int64) error {
+	ctx, span := tracing.Start(ctx, "Investment.Delete")
+	defer span.End()
+
+	investment, err := i.GetByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	// The repository checks the project under its row lock, so it cannot close in between
+	err = i.auditor.Change(ctx, func(ctx context.Context) error {
+		err := i.repo.Delete(ctx, id, version)
+		if errors.Is(err, repository.ErrProjectClosed) {
+			logger.FromContext(ctx).Errorf("User %d tried to cancel investment %d in closed project %d", userID, id, investment.ProjectID)
+			return fmt.Errorf("%w", ErrProjectNotOpen)
+		}
+		if err != nil {
+			return err
+		}
+		return i.auditor.Record(ctx, model.AuditInvestmentDelete, model.AuditTargetInvestment, id, investment, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Infof("Investment %d cancelled", id)
 	return nil
 }
 
-// GetByID retrieves an investment by ID
+// GetByID retrieves an investment of the requesting user by ID
 func (i *Investment) GetByID(ctx context.Context, id int64, userID int64) (model.Investment, error) {
-	return model.Investment{}, nil
+	ctx, span := tracing.Start(ctx, "Investment.GetByID")
+	defer span.End()
+
+	investment, err := i.repo.GetByID(ctx, id)
+	if err != nil {
+		return model.Investment{}, err
+	}
+	if investment.UserID != userID {
+		logger.FromContext(ctx).Errorf("User %d requested investment %d of user %d", userID, id, investment.UserID)
+		return model.Investment{}, fmt.Errorf("%w", ErrInvestmentAccessDenied)
+	}
+	return investment, nil
 }
 
 // GetByUserID lists investments by user ID
 func (i *Investment) GetByUserID(ctx context.Context, userID int64, requestingUserID int64) ([]model.Investment, error) {
-	return nil, nil
+	ctx, span := tracing.Start(ctx, "Investment.GetByUserID")
+	defer span.End()
+
+	if userID != requestingUserID {
+		logger.FromContext(ctx).Errorf("User %d requested investments of user %d", requestingUserID, userID)
+		return nil, fmt.Errorf("%w", ErrInvestmentAccessDenied)
+	}
+
+	return i.repo.GetByUserID(ctx, userID)
 }
 
-// GetByProjectID lists investments by project ID
-func (i *Investment) GetByProjectID(ctx context.Context, projectID int64) ([]model.Investment, error) {
-	return nil, nil
+// GetByProjectID lists investments in a project. They identify the investors,
+// so only the owner of the project and administrators can see them
+func (i *Investment) GetByProjectID(ctx context.Context, projectID int64, requestingUserID int64) ([]model.Investment, error) {
+	ctx, span := tracing.Start(ctx, "Investment.GetByProjectID")
+	defer span.End()
+
+	project, err := i.project.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project.OwnerID != requestingUserID {
+		acc, err := i.accounts.GetByID(ctx, requestingUserID)
+		if err != nil {
+			return nil, err
+		}
+		if acc.Role != "admin" {
+			logger.FromContext(ctx).Errorf("User %d requested investments in project %d of user %d", requestingUserID, projectID, project.OwnerID)
+			return nil, fmt.Errorf("%w", ErrInvestmentAccessDenied)
+		}
+	}
+
+	return i.repo.GetByProjectID(ctx, projectID)
 }
 
-// UpdateRaisedAmount updates the amount raised for a project
-func (i *Investment) UpdateRaisedAmount(ctx context.Context, projectID int, amount float64) error {
-	return nil
+// ListDeleted returns investments marked as deleted that have not been purged yet
+func (i *Investment) ListDeleted(ctx context.Context) ([]model.Investment, error) {
+	ctx, span := tracing.Start(ctx, "Investment.ListDeleted")
+	defer span.End()
+
+	return i.repo.ListDeleted(ctx)
+}
+
+// Restore clears the deletion mark of an investment and adds its amount back to the project.
+// The investment is checked like a new one: the investor is screened, the project must be open
+// and the KYC limit and the investment rules are evaluated again under lock
+func (i *Investment) Restore(ctx context.Context, id int64) (model.Investment, error) {
+	ctx, span := tracing.Start(ctx, "Investment.Restore")
+	defer span.End()
+
+	investment, err := i.repo.GetDeletedByID(ctx, id)
+	if err != nil {
+		return model.Investment{}, err
+	}
+
+	chk, err := i.prepareCheck(ctx, investment)
+	if err != nil {
+		return model.Investment{}, err
+	}
+	if err = i.enforce(ctx, chk, investment); err != nil {
+		return model.Investment{}, err
+	}
+
+	guard := i.lockedGuard(ctx, chk, investment)
+	var restored model.Investment
+	err = i.auditor.Change(ctx, func(ctx context.Context) error {
+		var err error
+		restored, err = i.repo.Restore(ctx, id, guard)
+		if errors.Is(err, repository.ErrProjectClosed) {
+			logger.FromContext(ctx).Errorf("Investment %d cannot be restored in closed project %d", id, investment.ProjectID)
+			return fmt.Errorf("%w", ErrProjectNotOpen)
+		}
+		if err != nil {
+			return err
+		}
+		return i.auditor.Record(ctx, model.AuditInvestmentRestore, model.AuditTargetInvestment, id,
+			map[string]any{"deleted": true}, map[string]any{"deleted": false})
+	})
+	if err != nil {
+		return model.Investment{}, err
+	}
+
+	logger.FromContext(ctx).Infof("Investment %d restored", id)
+	return restored, nil
 }
@@ -0,0 +1,547 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// memoryInvestments keeps investments in memory and maintains amount_raised of memoryProjects
+// the way repository.PostgresInvestment does in its transactions
+type memoryInvestments struct {
+	InvestmentRepository
+
+	investments map[int64]model.Investment
+	projects    *memoryProjects
+	// beforeCreate and beforeRestore run at the start of Create and Restore, before the project
+	// and the guard are checked, to simulate a concurrent request
+	beforeCreate  func()
+	beforeRestore func()
+}
+
+// lockOpenProject mirrors the check of the locked project row in repository.PostgresInvestment
+func (m *memoryInvestments) lockOpenProject(projectID int64) error {
+	project, ok := m.projects.projects[projectID]
+	if !ok {
+		return repository.ErrProjectNotFound
+	}
+	if !isOpenForInvestments(project) {
+		return repository.ErrProjectClosed
+	}
+	return nil
+}
+
+func (m *memoryInvestments) Create(ctx context.Context, investment model.Investment, guard repository.InvestmentGuard) (int64, error) {
+	if m.beforeCreate != nil {
+		m.beforeCreate()
+	}
+	if err := m.lockOpenProject(investment.ProjectID); err != nil {
+		return 0, err
+	}
+
+	totals, err := m.Totals(ctx, investment.UserID, investment.ProjectID, guard.YearStart)
+	if err != nil {
+		return 0, err
+	}
+	if err = guard.Check(totals); err != nil {
+		return 0, err
+	}
+	return m.insert(investment), nil
+}
+
+func (m *memoryInvestments) insert(investment model.Investment) int64 {
+	now := time.Now()
+	investment.ID = int64(len(m.investments) + 1)
+	investment.InvestedAt = &now
+	investment.Version = 1
+	m.investments[investment.ID] = investment
+	m.projects.addRaised(investment.ProjectID, investment.Amount)
+	return investment.ID
+}
+
+func (m *memoryInvestments) Update(ctx context.Context, investment model.Investment, guard repository.InvestmentGuard) error {
+	current, ok := m.investments[investment.ID]
+	if !ok || current.DeletedAt != nil || current.UserID != investment.UserID {
+		return repository.ErrInvestmentNotFound
+	}
+	if current.Version != investment.Version {
+		return repository.ErrVersionConflict
+	}
+	if err := m.lockOpenProject(current.ProjectID); err != nil {
+		return err
+	}
+	totals, err := m.Totals(ctx, current.UserID, current.ProjectID, guard.YearStart)
+	if err != nil {
+		return err
+	}
+	if err = guard.Check(totals); err != nil {
+		return err
+	}
+
+	m.projects.addRaised(current.ProjectID, investment.Amount.Sub(current.Amount))
+	current.Amount = investment.Amount
+	current.Version++
+	m.investments[current.ID] = current
+	return nil
+}
+
+func (m *memoryInvestments) Totals(_ context.Context, userID int64, projectID int64, yearStart time.Time) (model.InvestmentTotals, error) {
+	var totals model.InvestmentTotals
+	for _, inv := range m.investments {
+		if inv.UserID != userID || inv.DeletedAt != nil {
+			continue
+		}
+		totals.Total = totals.Total.Add(inv.Amount)
+		if inv.InvestedAt != nil && !inv.InvestedAt.Before(yearStart) {
+			totals.ThisYear = totals.ThisYear.Add(inv.Amount)
+		}
+		if inv.ProjectID == projectID {
+			totals.InProject = totals.InProject.Add(inv.Amount)
+		}
+	}
+	return totals, nil
+}
+
+func (m *memoryInvestments) GetByID(_ context.Context, id int64) (model.Investment, error) {
+	inv, ok := m.investments[id]
+	if !ok || inv.DeletedAt != nil {
+		return model.Investment{}, repository.ErrInvestmentNotFound
+	}
+	return inv, nil
+}
+
+func (m *memoryInvestments) GetByProjectID(_ context.Context, projectID int64) ([]model.Investment, error) {
+	var investments []model.Investment
+	for _, inv := range m.investments {
+		if inv.ProjectID == projectID && inv.DeletedAt == nil {
+			investments = append(investments, inv)
+		}
+	}
+	return investments, nil
+}
+
+func (m *memoryInvestments) Delete(_ context.Context, id int64, version int64) error {
+	inv, ok := m.investments[id]
+	if !ok || inv.DeletedAt != nil {
+		return repository.ErrInvestmentNotFound
+	}
+	if inv.Version != version {
+		return repository.ErrVersionConflict
+	}
+	if err := m.lockOpenProject(inv.ProjectID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	inv.DeletedAt = &now
+	inv.Version++
+	m.investments[id] = inv
+	m.projects.addRaised(inv.ProjectID, inv.Amount.Neg())
+	return nil
+}
+
+func (m *memoryInvestments) ListDeleted(context.Context) ([]model.Investment, error) {
+	var deleted []model.Investment
+	for _, inv := range m.investments {
+		if inv.DeletedAt != nil {
+			deleted = append(deleted, inv)
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memoryInvestments) GetDeletedByID(_ context.Context, id int64) (model.Investment, error) {
+	inv, ok := m.investments[id]
+	if !ok || inv.DeletedAt == nil {
+		return model.Investment{}, repository.ErrInvestmentNotFound
+	}
+	return inv, nil
+}
+
+func (m *memoryInvestments) Restore(ctx context.Context, id int64, guard repository.InvestmentGuard) (model.Investment, error) {
+	if m.beforeRestore != nil {
+		m.beforeRestore()
+	}
+
+	inv, err := m.GetDeletedByID(ctx, id)
+	if err != nil {
+		return model.Investment{}, err
+	}
+	if err = m.lockOpenProject(inv.ProjectID); err != nil {
+		return model.Investment{}, err
+	}
+	totals, err := m.Totals(ctx, inv.UserID, inv.ProjectID, guard.YearStart)
+	if err != nil {
+		return model.Investment{}, err
+	}
+	if err = guard.Check(totals); err != nil {
+		return model.Investment{}, err
+	}
+
+	inv.DeletedAt = nil
+	inv.Version++
+	m.investments[id] = inv
+	m.projects.addRaised(inv.ProjectID, inv.Amount)
+	return inv, nil
+}
+
+// memoryProjects serves projects for the investment service
+type memoryProjects struct {
+	ProjectRepository
+
+	projects map[int64]model.Project
+}
+
+func (m *memoryProjects) GetByID(_ context.Context, id int64) (model.Project, error) {
+	project, ok := m.projects[id]
+	if !ok {
+		return model.Project{}, repository.ErrProjectNotFound
+	}
+	return project, nil
+}
+
+func (m *memoryProjects) addRaised(id int64, delta decimal.Decimal) {
+	project := m.projects[id]
+	project.AmountRaised = project.AmountRaised.Add(delta)
+	m.projects[id] = project
+}
+
+// noRules serves projects without their own investment rules
+type noRules struct {
+	InvestmentRulesRepository
+}
+
+func (noRules) GetByProjectID(context.Context, int64) (model.InvestmentRules, error) {
+	return model.InvestmentRules{}, nil
+}
+
+// passScreening clears every account
+type passScreening struct{}
+
+func (passScreening) ScreenAccount(context.Context, int64, string) error { return nil }
+
+// holdScreening keeps every account on hold pending a review of its hits
+type holdScreening struct{}
+
+func (holdScreening) ScreenAccount(context.Context, int64, string) error { return ErrSanctionsReview }
+
+func newInvestmentFixture(projectStatus string) (*memoryInvestments, *memoryProjects) {
+	deadline := time.Now().Add(24 * time.Hour)
+	projects := &memoryProjects{projects: map[int64]model.Project{
+		10: {ID: 10, Status: projectStatus, DeadlineAt: &deadline, AmountRequested: decimal.NewFromInt(10000), AmountRaised: decimal.NewFromInt(500)},
+	}}
+	investedAt := time.Now()
+	investments := &memoryInvestments{
+		investments: map[int64]model.Investment{
+			1: {ID: 1, UserID: 7, ProjectID: 10, Amount: decimal.NewFromInt(200), InvestedAt: &investedAt, Version: 1},
+		},
+		projects: projects,
+	}
+	return investments, projects
+}
+
+func TestInvestmentDeleteListRestore(t *testing.T) {
+	ctx := context.Background()
+	investments, projects := newInvestmentFixture("approved")
+	auditor := &recordingAuditor{}
+	accounts := newMemoryAccounts(model.Account{ID: 7, KYCStatus: model.KYCVerified})
+	svc := NewInvestment(investments, projects, accounts, auditor, decimal.NewFromInt(1000), time.Minute,
+		decimal.NewFromInt(1000), noRules{}, model.InvestmentRules{}, passScreening{})
+
+	if err := svc.Delete(ctx, 1, 7, 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if raised := projects.projects[10].AmountRaised; !raised.Equal(decimal.NewFromInt(300)) {
+		t.Fatalf("amount raised after delete = %s, want 300", raised)
+	}
+	if _, err := svc.GetByID(ctx, 1, 7); !errors.Is(err, repository.ErrInvestmentNotFound) {
+		t.Fatalf("GetByID after delete: error = %v, want ErrInvestmentNotFound", err)
+	}
+
+	deleted, err := svc.ListDeleted(ctx)
+	if err != nil {
+		t.Fatalf("ListDeleted: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != 1 {
+		t.Fatalf("ListDeleted = %+v, want investment 1", deleted)
+	}
+
+	restored, err := svc.Restore(ctx, 1)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Version != 3 {
+		t.Fatalf("restored investment = %+v, want no deletion mark and version 3", restored)
+	}
+	if raised := projects.projects[10].AmountRaised; !raised.Equal(decimal.NewFromInt(500)) {
+		t.Fatalf("amount raised after restore = %s, want 500", raised)
+	}
+	if deleted, _ = svc.ListDeleted(ctx); len(deleted) != 0 {
+		t.Fatalf("ListDeleted after restore = %+v, want empty", deleted)
+	}
+
+	want := []string{model.AuditInvestmentDelete, model.AuditInvestmentRestore}
+	if got := auditor.actions(); !slices.Equal(got, want) {
+		t.Fatalf("audit actions = %v, want %v", got, want)
+	}
+}
+
+func TestInvestmentDeleteRejected(t *testing.T) {
+	tests := []struct {
+		name          string
+		projectStatus string
+		userID        int64
+		version       int64
+		wantErr       error
+	}{
+		{name: "investment of another user", projectStatus: "approved", userID: 8, version: 1, wantErr: ErrInvestmentAccessDenied},
+		{name: "project no longer collecting", projectStatus: "funded", userID: 7, version: 1, wantErr: ErrProjectNotOpen},
+		{name: "stale version", projectStatus: "approved", userID: 7, version: 0, wantErr: repository.ErrVersionConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			investments, projects := newInvestmentFixture(tt.projectStatus)
+			auditor := &recordingAuditor{}
+			svc := &Investment{repo: investments, project: projects, auditor: auditor}
+
+			err := svc.Delete(context.Background(), 1, tt.userID, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete: error = %v, want %v", err, tt.wantErr)
+			}
+			if investments.investments[1].DeletedAt != nil {
+				t.Fatal("investment was marked deleted")
+			}
+			if raised := projects.projects[10].AmountRaised; !raised.Equal(decimal.NewFromInt(500)) {
+				t.Fatalf("amount raised = %s, want 500", raised)
+			}
+			if len(auditor.actions()) != 0 {
+				t.Fatalf("audit actions = %v, want none", auditor.actions())
+			}
+		})
+	}
+}
+
+func TestInvestmentCreateRechecksLimitsUnderLock(t *testing.T) {
+	annualCap := decimal.NewFromInt(400)
+	verifiedAt := time.Now()
+	errAudit := errors.New("audit log unavailable")
+
+	tests := []struct {
+		name       string
+		kycStatus  string
+		kycLimit   int64
+		global     model.InvestmentRules
+		concurrent bool
+		closed     bool
+		auditErr   error
+		wantErr    error
+	}{
+		{name: "within limits", kycStatus: model.KYCVerified, global: model.InvestmentRules{AnnualCap: &annualCap}},
+		{name: "annual cap exceeded by a concurrent investment", kycStatus: model.KYCVerified, global: model.InvestmentRules{AnnualCap: &annualCap}, concurrent: true, wantErr: ErrInvestmentRulesViolated},
+		{name: "KYC limit exceeded by a concurrent investment", kycLimit: 400, concurrent: true, wantErr: ErrKYCRequired},
+		{name: "project closed by a concurrent request", kycStatus: model.KYCVerified, closed: true, wantErr: ErrProjectNotOpen},
+		{name: "audit event cannot be recorded", kycStatus: model.KYCVerified, auditErr: errAudit, wantErr: errAudit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			investments, projects := newInvestmentFixture("approved")
+			accounts := newMemoryAccounts(model.Account{ID: 7, KYCStatus: tt.kycStatus, EmailVerifiedAt: &verifiedAt})
+			auditor := &recordingAuditor{err: tt.auditErr}
+			svc := NewInvestment(investments, projects, accounts, auditor, decimal.NewFromInt(1000), time.Minute,
+				decimal.NewFromInt(tt.kycLimit), noRules{}, tt.global, passScreening{})
+
+			// The request passes the checks before the insert, then another request of the same
+			// investor commits first: the totals read under lock must reject this one
+			if tt.concurrent {
+				investments.beforeCreate = func() {
+					investments.insert(model.Investment{UserID: 7, ProjectID: 10, Amount: decimal.NewFromInt(150)})
+				}
+			}
+			// The project passes the open check, then reaches its goal before the insert
+			if tt.closed {
+				investments.beforeCreate = func() {
+					project := projects.projects[10]
+					project.Status = "funded"
+					projects.projects[10] = project
+				}
+			}
+
+			err := svc.Create(context.Background(), model.Investment{UserID: 7, ProjectID: 10, Amount: decimal.NewFromInt(150)})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create: error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantAudit := []string{model.AuditInvestmentCreate}
+			if tt.wantErr != nil {
+				wantAudit = []string{}
+			}
+			if got := auditor.actions(); !slices.Equal(got, wantAudit) {
+				t.Fatalf("audit actions = %v, want %v", got, wantAudit)
+			}
+		})
+	}
+}
+
+func TestInvestmentRestoreRejected(t *testing.T) {
+	annualCap := decimal.NewFromInt(300)
+
+	tests := []struct {
+		name      string
+		screener  SanctionsScreener
+		kycStatus string
+		global    model.InvestmentRules
+		closed    bool
+		wantErr   error
+	}{
+		{name: "investor on sanctions hold", screener: holdScreening{}, kycStatus: model.KYCVerified, wantErr: ErrSanctionsReview},
+		{name: "KYC limit exceeded", screener: passScreening{}, wantErr: ErrKYCRequired},
+		{name: "annual cap exceeded", screener: passScreening{}, kycStatus: model.KYCVerified, global: model.InvestmentRules{AnnualCap: &annualCap}, wantErr: ErrInvestmentRulesViolated},
+		{name: "project closed before the restore", screener: passScreening{}, kycStatus: model.KYCVerified, closed: true, wantErr: ErrProjectNotOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			investments, projects := newInvestmentFixture("approved")
+			deletedAt := time.Now()
+			deleted := investments.investments[1]
+			deleted.DeletedAt = &deletedAt
+			investments.investments[1] = deleted
+			// Another investment of the investor made while the first one was deleted
+			investments.insert(model.Investment{UserID: 7, ProjectID: 10, Amount: decimal.NewFromInt(150)})
+
+			accounts := newMemoryAccounts(model.Account{ID: 7, KYCStatus: tt.kycStatus})
+			auditor := &recordingAuditor{}
+			svc := NewInvestment(investments, projects, accounts, auditor, decimal.NewFromInt(1000), time.Minute,
+				decimal.NewFromInt(300), noRules{}, tt.global, tt.screener)
+			// The project passes the open check, then reaches its goal before the restore
+			if tt.closed {
+				investments.beforeRestore = func() {
+					project := projects.projects[10]
+					project.Status = "funded"
+					projects.projects[10] = project
+				}
+			}
+
+			if _, err := svc.Restore(context.Background(), 1); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Restore: error = %v, want %v", err, tt.wantErr)
+			}
+			if investments.investments[1].DeletedAt == nil {
+				t.Fatal("investment was restored")
+			}
+			if len(auditor.actions()) != 0 {
+				t.Fatalf("audit actions = %v, want none", auditor.actions())
+			}
+		})
+	}
+}
+
+func TestInvestmentUpdate(t *testing.T) {
+	annualCap := decimal.NewFromInt(300)
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name        string
+		userID      int64
+		amount      int64
+		version     int64
+		status      string
+		kycStatus   string
+		global      model.InvestmentRules
+		wantErr     error
+		wantRaised  int64
+		wantVersion int64
+	}{
+		{name: "amount increased", userID: 7, amount: 250, version: 1, status: "approved", kycStatus: model.KYCVerified, wantRaised: 550, wantVersion: 2},
+		{name: "amount decreased", userID: 7, amount: 50, version: 1, status: "approved", kycStatus: model.KYCVerified, wantRaised: 350, wantVersion: 2},
+		// The current 200 is replaced, so only the new 300 counts towards the annual cap
+		{name: "new amount within the annual cap", userID: 7, amount: 300, version: 1, status: "approved", kycStatus: model.KYCVerified, global: model.InvestmentRules{AnnualCap: &annualCap}, wantRaised: 600, wantVersion: 2},
+		{name: "new amount above the annual cap", userID: 7, amount: 350, version: 1, status: "approved", kycStatus: model.KYCVerified, global: model.InvestmentRules{AnnualCap: &annualCap}, wantErr: ErrInvestmentRulesViolated},
+		{name: "KYC limit exceeded", userID: 7, amount: 350, version: 1, status: "approved", wantErr: ErrKYCRequired},
+		{name: "investment of another user", userID: 8, amount: 250, version: 1, status: "approved", kycStatus: model.KYCVerified, wantErr: ErrInvestmentAccessDenied},
+		{name: "stale version", userID: 7, amount: 250, version: 0, status: "approved", kycStatus: model.KYCVerified, wantErr: repository.ErrVersionConflict},
+		{name: "project no longer collecting", userID: 7, amount: 250, version: 1, status: "funded", kycStatus: model.KYCVerified, wantErr: ErrProjectNotOpen},
+		{name: "invalid amount", userID: 7, amount: 0, version: 1, status: "approved", kycStatus: model.KYCVerified, wantErr: ErrInvalidInvestmentAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			investments, projects := newInvestmentFixture(tt.status)
+			accounts := newMemoryAccounts(
+				model.Account{ID: 7, KYCStatus: tt.kycStatus, EmailVerifiedAt: &verifiedAt},
+				model.Account{ID: 8, KYCStatus: tt.kycStatus, EmailVerifiedAt: &verifiedAt},
+			)
+			auditor := &recordingAuditor{}
+			svc := NewInvestment(investments, projects, accounts, auditor, decimal.NewFromInt(1000), time.Minute,
+				decimal.NewFromInt(300), noRules{}, tt.global, passScreening{})
+
+			version, err := svc.Update(context.Background(), 1, tt.userID, decimal.NewFromInt(tt.amount), tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update: error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if amount := investments.investments[1].Amount; !amount.Equal(decimal.NewFromInt(200)) {
+					t.Fatalf("amount = %s, want the unchanged 200", amount)
+				}
+				if len(auditor.actions()) != 0 {
+					t.Fatalf("audit actions = %v, want none", auditor.actions())
+				}
+				return
+			}
+
+			if version != tt.wantVersion {
+				t.Fatalf("version = %d, want %d", version, tt.wantVersion)
+			}
+			if raised := projects.projects[10].AmountRaised; !raised.Equal(decimal.NewFromInt(tt.wantRaised)) {
+				t.Fatalf("amount raised = %s, want %d", raised, tt.wantRaised)
+			}
+			if got, want := auditor.actions(), []string{model.AuditInvestmentUpdate}; !slices.Equal(got, want) {
+				t.Fatalf("audit actions = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestInvestmentGetByProjectID(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    int64
+		projectID int64
+		wantErr   error
+	}{
+		{name: "project owner", userID: 3, projectID: 10},
+		{name: "administrator", userID: 4, projectID: 10},
+		{name: "investor of the project", userID: 7, projectID: 10, wantErr: ErrInvestmentAccessDenied},
+		{name: "missing project", userID: 3, projectID: 11, wantErr: repository.ErrProjectNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			investments, projects := newInvestmentFixture("approved")
+			project := projects.projects[10]
+			project.OwnerID = 3
+			projects.projects[10] = project
+			accounts := newMemoryAccounts(
+				model.Account{ID: 3, Role: "startup"},
+				model.Account{ID: 4, Role: "admin"},
+				model.Account{ID: 7, Role: "investor"},
+			)
+			svc := &Investment{repo: investments, project: projects, accounts: accounts}
+
+			got, err := svc.GetByProjectID(context.Background(), tt.projectID, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetByProjectID: error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (len(got) != 1 || got[0].ID != 1) {
+				t.Fatalf("GetByProjectID = %+v, want investment 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/CryptoCrowd/internal/kyc"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrKYCRequired              = errors.New("identity verification (KYC) is required")
+	ErrInvalidDocumentKind      = errors.New("invalid document kind")
+	ErrUnsupportedDocumentType  = errors.New("document must be a JPEG, PNG or PDF file")
+	ErrDocumentTooLarge         = errors.New("document is too large")
+	ErrKYCAlreadySubmitted      = errors.New("kyc application is already submitted or approved")
+	ErrKYCNoDocuments           = errors.New("upload at least one document before submitting")
+	ErrKYCApplicationNotFound   = errors.New("kyc application not found")
+	ErrKYCApplicationNotPending = errors.New("kyc application has already been reviewed")
+	ErrKYCDocumentNotFound      = errors.New("kyc document not found")
+	ErrInvalidKYCDecision       = errors.New("decision must be verified or rejected")
+)
+
+// documentContentTypes lists content types accepted for KYC documents, detected from file content
+var documentContentTypes = []string{"image/jpeg", "image/png", "application/pdf"}
+
+// KYCRepository defines the interface for KYC repository operations
+type KYCRepository interface {
+	CreateDocument(ctx context.Context, doc model.KYCDocument) (model.KYCDocument, error)
+	GetDocument(ctx context.Context, id int64) (model.KYCDocument, error)
+	ListUnsubmittedDocuments(ctx context.Context, userID int64) ([]model.KYCDocument, error)
+	ListApplicationDocuments(ctx context.Context, applicationID int64) ([]model.KYCDocument, error)
+	CreateApplication(ctx context.Context, userID int64, provider string) (model.KYCApplication, error)
+	SetProviderRef(ctx context.Context, applicationID int64, ref string) error
+	ApplyDecision(ctx context.Context, app model.KYCApplication) (model.KYCApplication, error)
+	GetApplication(ctx context.Context, id int64) (model.KYCApplication, error)
+	GetLatestApplication(ctx context.Context, userID int64) (model.KYCApplication, error)
+	ListApplications(ctx context.Context, status string) ([]model.KYCApplication, error)
+}
+
+// KYCOverview describes the verification state of an account
+type KYCOverview struct {
+	Status      string                `json:"status"`
+	Application *model.KYCApplication `json:"application,omitempty"`
+	// Documents are uploaded but not yet submitted for verification
+	Documents []model.KYCDocument `json:"documents"`
+}
+
+// KYC service implements identity verification of investors and founders
+type KYC struct {
+	repo            KYCRepository
+	accounts        AccountReader
+	provider        kyc.Provider
+	store           kyc.DocumentStore
+	auditor         Auditor
+	maxDocumentSize int64
+}
+
+// NewKYC creates a new KYC service. maxDocumentSize is in bytes
+func NewKYC(
+	repo KYCRepository,
+	accounts AccountReader,
+	provider kyc.Provider,
+	store kyc.DocumentStore,
+	auditor Auditor,
+	maxDocumentSize int64,
+) *KYC {
+	return &KYC{
+		repo:            repo,
+		accounts:        accounts,
+		provider:        provider,
+		store:           store,
+		auditor:         auditor,
+		maxDocumentSize: maxDocumentSize,
+	}
+}
+
+// Overview returns the KYC status of the user with the latest application and pending uploads
+func (k *KYC) Overview(ctx context.Context, userID int64) (KYCOverview, error) {
+	ctx, span := tracing.Start(ctx, "KYC.Overview")
+	defer span.End()
+
+	acc, err := k.accounts.GetByID(ctx, userID)
+	if err != nil {
+		return KYCOverview{}, err
+	}
+
+	overview := KYCOverview{Status: acc.KYCStatus}
+
+	app, err := k.repo.GetLatestApplication(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrKYCApplicationNotFound) {
+		return KYCOverview{}, err
+	}
+	if err == nil {
+		if app.Documents, err = k.repo.ListApplicationDocuments(ctx, app.ID); err != nil {
+			return KYCOverview{}, err
+		}
+		overview.Application = &app
+	}
+
+	if overview.Documents, err = k.repo.ListUnsubmittedDocuments(ctx, userID); err != nil {
+		return KYCOverview{}, err
+	}
+	return overview, nil
+}
+
+// UploadDocument stores a document for the next application. The content type is detected
+// from the file itself, the one declared by the client is ignored
+func (k *KYC) UploadDocument(ctx context.Context, userID int64, kind string, fileName string, r io.Reader) (model.KYCDocument, error) {
+	ctx, span := tracing.Start(ctx, "KYC.UploadDocument")
+	defer span.End()
+
+	if !slices.Contains(model.KYCDocumentKinds, kind) {
+		logger.FromContext(ctx).Errorf("Invalid KYC document kind %q", kind)
+		return model.KYCDocument{}, fmt.Errorf("%w", ErrInvalidDocumentKind)
+	}
+
+	acc, err := k.accounts.GetByID(ctx, userID)
+	if err != nil {
+		return model.KYCDocument{}, err
+	}
+	if acc.KYCStatus == model.KYCPending || acc.KYCStatus == model.KYCVerified {
+		return model.KYCDocument{}, fmt.Errorf("%w", ErrKYCAlreadySubmitted)
+	}
+
+	// Read one byte more than allowed to detect oversized files without trusting their declared size
+	data, err := io.ReadAll(io.LimitReader(r, k.maxDocumentSize+1))
+	if err != nil {
+		return model.KYCDocument{}, fmt.Errorf("failed to read document: %w", err)
+	}
+	if int64(len(data)) > k.maxDocumentSize {
+		return model.KYCDocument{}, fmt.Errorf("%w: the limit is %d bytes", ErrDocumentTooLarge, k.maxDocumentSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(documentContentTypes, contentType) {
+		logger.FromContext(ctx).Errorf("Rejected KYC document of type %s from user %d", contentType, userID)
+		return model.KYCDocument{}, fmt.Errorf("%w", ErrUnsupportedDocumentType)
+	}
+
+	key, err := documentKey(userID)
+	if err != nil {
+		return model.KYCDocument{}, err
+	}
+	if err = k.store.Save(ctx, key, bytes.NewReader(data)); err != nil {
+		return model.KYCDocument{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	return k.repo.CreateDocument(ctx, model.KYCDocument{
+		UserID:      userID,
+		Kind:        kind,
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		StorageKey:  key,
+	})
+}
+
+// Submit sends the uploaded documents to the KYC provider. Providers that decide at once
+// update the status immediately, otherwise the application waits for review
+func (k *KYC) Submit(ctx context.Context, userID int64) (model.KYCApplication, error) {
+	ctx, span := tracing.Start(ctx, "KYC.Submit")
+	defer span.End()
+
+	app, err := k.repo.CreateApplication(ctx, userID, k.provider.Name())
+	switch {
+	case errors.Is(err, repository.ErrKYCAlreadySubmitted):
+		return model.KYCApplication{}, fmt.Errorf("%w", ErrKYCAlreadySubmitted)
+	case errors.Is(err, repository.ErrKYCNoDocuments):
+		return model.KYCApplication{}, fmt.Errorf("%w", ErrKYCNoDocuments)
+	case err != nil:
+		return model.KYCApplication{}, err
+	}
+
+	acc, err := k.accounts.GetByID(ctx, userID)
+	if err != nil {
+		return model.KYCApplication{}, err
+	}
+	if app.Documents, err = k.repo.ListApplicationDocuments(ctx, app.ID); err != nil {
+		return model.KYCApplication{}, err
+	}
+
+	decision, err := k.provider.Submit(ctx, kyc.Submission{Application: app, Account: acc, Documents: app.Documents})
+	if err != nil {
+		// The application stays pending and can still be reviewed manually
+		logger.FromContext(ctx).Errorf("KYC provider %s failed to accept application %d: %v", k.provider.Name(), app.ID, err)
+		return app, nil
+	}
+
+	if decision.Status == model.KYCPending {
+		if decision.Reference != "" {
+			if err = k.repo.SetProviderRef(ctx, app.ID, decision.Reference); err != nil {
+				return model.KYCApplication{}, err
+			}
+			app.ProviderRef = decision.Reference
+		}
+		return app, nil
+	}
+
+	documents := app.Documents
+	app.Status = decision.Status
+	app.ProviderRef = decision.Reference
+	app.Reason = decision.Reason
+	if app, err = k.applyDecision(ctx, app); err != nil {
+		return model.KYCApplication{}, err
+	}
+	app.Documents = documents
+	return app, nil
+}
+
+// ListApplications returns applications with the given status for review
+func (k *KYC) ListApplications(ctx context.Context, status string) ([]model.KYCApplication, error) {
+	ctx, span := tracing.Start(ctx, "KYC.ListApplications")
+	defer span.End()
+
+	if status == "" {
+		status = model.KYCPending
+	}
+	return k.repo.ListApplications(ctx, status)
+}
+
+// GetApplication returns an application with its documents
+func (k *KYC) GetApplication(ctx context.Context, id int64) (model.KYCApplication, error) {
+	ctx, span := tracing.Start(ctx, "KYC.GetApplication")
+	defer span.End()
+
+	app, err := k.repo.GetApplication(ctx, id)
+	if errors.Is(err, repository.ErrKYCApplicationNotFound) {
+		return model.KYCApplication{}, fmt.Errorf("%w", ErrKYCApplicationNotFound)
+	}
+	if err != nil {
+		return model.KYCApplication{}, err
+	}
+
+	if app.Documents, err = k.repo.ListApplicationDocuments(ctx, app.ID); err != nil {
+		return model.KYCApplication{}, err
+	}
+	return app, nil
+}
+
+// OpenDocument returns a document with its file for review. The caller must close the file
+func (k *KYC) OpenDocument(ctx context.Context, id int64) (model.KYCDocument, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "KYC.OpenDocument")
+	defer span.End()
+
+	doc, err := k.repo.GetDocument(ctx, id)
+	if errors.Is(err, repository.ErrKYCDocumentNotFound) {
+		return model.KYCDocument{}, nil, fmt.Errorf("%w", ErrKYCDocumentNotFound)
+	}
+	if err != nil {
+		return model.KYCDocument{}, nil, err
+	}
+
+	file, err := k.store.Open(ctx, doc.StorageKey)
+	if errors.Is(err, kyc.ErrDocumentNotFound) {
+		logger.FromContext(ctx).Errorf("File of KYC document %d is missing", doc.ID)
+		return model.KYCDocument{}, nil, fmt.Errorf("%w", ErrKYCDocumentNotFound)
+	}
+	if err != nil {
+		return model.KYCDocument{}, nil, err
+	}
+	return doc, file, nil
+}
+
+// Review records an administrator's decision on a pending application
+func (k *KYC) Review(ctx context.Context, reviewerID int64, applicationID int64, status string, reason string) (model.KYCApplication, error) {
+	ctx, span := tracing.Start(ctx, "KYC.Review")
+	defer span.End()
+
+	if status != model.KYCVerified && status != model.KYCRejected {
+		logger.FromContext(ctx).Error("Invalid KYC decision")
+		return model.KYCApplication{}, fmt.Errorf("%w", ErrInvalidKYCDecision)
+	}
+
+	return k.applyDecision(ctx, model.KYCApplication{
+		ID:         applicationID,
+		Status:     status,
+		Reason:     reason,
+		ReviewerID: &reviewerID,
+	})
+}
+
+func (k *KYC) applyDecision(ctx context.Context, app model.KYCApplication) (model.KYCApplication, error) {
+	var updated model.KYCApplication
+	err := k.auditor.Change(ctx, func(ctx context.Context) error {
+		var err error
+		if updated, err = k.repo.ApplyDecision(ctx, app); err != nil {
+			return err
+		}
+		return k.auditor.Record(ctx, model.AuditKYCDecision, model.AuditTargetAccount, updated.UserID,
+			map[string]any{"kyc_status": model.KYCPending},
+			map[string]any{"kyc_status": updated.Status, "kyc_application_id": updated.ID, "reason": updated.Reason})
+	})
+	switch {
+	case errors.Is(err, repository.ErrKYCApplicationNotFound):
+		return model.KYCApplication{}, fmt.Errorf("%w", ErrKYCApplicationNotFound)
+	case errors.Is(err, repository.ErrKYCApplicationNotPending):
+		return model.KYCApplication{}, fmt.Errorf("%w", ErrKYCApplicationNotPending)
+	case err != nil:
+		return model.KYCApplication{}, err
+	}
+	return updated, nil
+}
+
+// documentKey returns a random storage key that does not reveal the original file name
+func documentKey(userID int64) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate document key: %w", err)
+	}
+	return filepath.Join(strconv.FormatInt(userID, 10), hex.EncodeToString(buf)), nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/mailer"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/notification"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrInvalidNotificationLanguage = errors.New("invalid notification language")
+)
+
+// NotificationRepository defines the interface for notification repository operations
+type NotificationRepository interface {
+	Create(ctx context.Context, notification model.Notification) error
+	ListByUserID(ctx context.Context, userID int64, unreadOnly bool) ([]model.Notification, error)
+	MarkRead(ctx context.Context, userID int64, ids []int64) error
+	GetPreferences(ctx context.Context, userID int64) (model.NotificationPreferences, error)
+	UpsertPreferences(ctx context.Context, prefs model.NotificationPreferences) error
+}
+
+// AccountReader provides read access to accounts for services that need recipient details
+type AccountReader interface {
+	GetByID(ctx context.Context, id int64) (model.Account, error)
+}
+
+// Notification service stores in-app notifications and delivers them by email
+type Notification struct {
+	repo      NotificationRepository
+	accounts  AccountReader
+	mailer    mailer.Mailer
+	templates *notification.Templates
+}
+
+// NewNotification creates a new notification service
+func NewNotification(repo NotificationRepository, accounts AccountReader, m mailer.Mailer, templates *notification.Templates) *Notification {
+	return &Notification{
+		repo:      repo,
+		accounts:  accounts,
+		mailer:    m,
+		templates: templates,
+	}
+}
+
+// Notify renders a notification in the user's language, stores it and sends it by email
+// unless the user muted this kind of notification or disabled email delivery
+func (n *Notification) Notify(ctx context.Context, userID int64, kind string, data map[string]any) error {
+	ctx, span := tracing.Start(ctx, "Notification.Notify")
+	defer span.End()
+
+	acc, err := n.accounts.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load notification recipient: %w", err)
+	}
+
+	prefs, err := n.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(prefs.MutedTypes, kind) {
+		return nil
+	}
+
+	payload := map[string]any{"Username": acc.Username}
+	for k, v := range data {
+		payload[k] = v
+	}
+
+	title, body, err := n.templates.Render(kind, prefs.Language, payload)
+	if err != nil {
+		return err
+	}
+
+	err = n.repo.Create(ctx, model.Notification{
+		UserID: userID,
+		Type:   kind,
+		Title:  title,
+		Body:   body,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !prefs.EmailEnabled {
+		return nil
+	}
+
+	// The in-app notification is already stored, so a mail failure is only logged
+	err = n.mailer.Send(ctx, mailer.Message{To: acc.Email, Subject: title, Body: body})
+	if err != nil {
+		logger.FromContext(ctx).Errorf("Failed to send %s notification email to user %d: %v", kind, userID, err)
+	}
+
+	return nil
+}
+
+// SendEmail renders a transactional email in the user's language and sends it right away.
+// Unlike Notify it ignores mute and email preferences and does not store an in-app notification
+func (n *Notification) SendEmail(ctx context.Context, userID int64, kind string, data map[string]any) error {
+	ctx, span := tracing.Start(ctx, "Notification.SendEmail")
+	defer span.End()
+
+	acc, err := n.accounts.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load email recipient: %w", err)
+	}
+
+	prefs, err := n.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{"Username": acc.Username}
+	for k, v := range data {
+		payload[k] = v
+	}
+
+	subject, body, err := n.templates.Render(kind, prefs.Language, payload)
+	if err != nil {
+		return err
+	}
+
+	return n.mailer.Send(ctx, mailer.Message{To: acc.Email, Subject: subject, Body: body})
+}
+
+// List returns notifications of the user
+func (n *Notification) List(ctx context.Context, userID int64, unreadOnly bool) ([]model.Notification, error) {
+	ctx, span := tracing.Start(ctx, "Notification.List")
+	defer span.End()
+
+	return n.repo.ListByUserID(ctx, userID, unreadOnly)
+}
+
+// MarkRead marks the given notifications as read, or all of them when ids is empty
+func (n *Notification) MarkRead(ctx context.Context, userID int64, ids []int64) error {
+	ctx, span := tracing.Start(ctx, "Notification.MarkRead")
+	defer span.End()
+
+	return n.repo.MarkRead(ctx, userID, ids)
+}
+
+// GetPreferences returns notification preferences of the user, falling back to defaults
+func (n *Notification) GetPreferences(ctx context.Context, userID int64) (model.NotificationPreferences, error) {
+	ctx, span := tracing.Start(ctx, "Notification.GetPreferences")
+	defer span.End()
+
+	prefs, err := n.repo.GetPreferences(ctx, userID)
+	if errors.Is(err, repository.ErrPreferencesNotFound) {
+		return model.NotificationPreferences{
+			UserID:       userID,
+			Language:     notification.DefaultLanguage,
+			EmailEnabled: true,
+			MutedTypes:   []string{},
+		}, nil
+	}
+	return prefs, err
+}
+
+// UpdatePreferences validates and saves notification preferences of the user
+func (n *Notification) UpdatePreferences(ctx context.Context, prefs model.NotificationPreferences) error {
+	ctx, span := tracing.Start(ctx, "Notification.UpdatePreferences")
+	defer span.End()
+
+	if !n.templates.SupportsLanguage(prefs.Language) {
+		logger.FromContext(ctx).Error("Invalid notification language")
+		return fmt.Errorf("%w", ErrInvalidNotificationLanguage)
+	}
+
+	return n.repo.UpsertPreferences(ctx, prefs)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CryptoCrowd/internal/mailer"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/notification"
+	"github.com/CryptoCrowd/internal/repository"
+)
+
+// memoryNotifications keeps stored notifications and preferences in memory
+type memoryNotifications struct {
+	NotificationRepository
+
+	created []model.Notification
+	prefs   map[int64]model.NotificationPreferences
+}
+
+func (m *memoryNotifications) Create(_ context.Context, n model.Notification) error {
+	m.created = append(m.created, n)
+	return nil
+}
+
+func (m *memoryNotifications) GetPreferences(_ context.Context, userID int64) (model.NotificationPreferences, error) {
+	prefs, ok := m.prefs[userID]
+	if !ok {
+		return model.NotificationPreferences{}, repository.ErrPreferencesNotFound
+	}
+	return prefs, nil
+}
+
+// recordingMailer keeps the messages it was asked to send
+type recordingMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return m.err
+}
+
+func TestNotificationNotify(t *testing.T) {
+	templates, err := notification.LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		prefs     *model.NotificationPreferences
+		mailErr   error
+		wantTitle string
+		wantMail  bool
+	}{
+		{
+			name:      "no preferences: russian and email",
+			wantTitle: "Проект «Solar Farm» одобрен",
+			wantMail:  true,
+		},
+		{
+			name:      "english",
+			prefs:     &model.NotificationPreferences{Language: notification.LanguageEN, EmailEnabled: true},
+			wantTitle: `Project "Solar Farm" approved`,
+			wantMail:  true,
+		},
+		{
+			name:      "email disabled",
+			prefs:     &model.NotificationPreferences{Language: notification.LanguageEN},
+			wantTitle: `Project "Solar Farm" approved`,
+		},
+		{
+			name:  "muted",
+			prefs: &model.NotificationPreferences{Language: notification.LanguageEN, EmailEnabled: true, MutedTypes: []string{model.NotificationProjectApproved}},
+		},
+		{
+			name:      "mail failure keeps the in-app notification",
+			mailErr:   errors.New("smtp unavailable"),
+			wantTitle: "Проект «Solar Farm» одобрен",
+			wantMail:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryNotifications{prefs: make(map[int64]model.NotificationPreferences)}
+			if tt.prefs != nil {
+				repo.prefs[1] = *tt.prefs
+			}
+			accounts := newMemoryAccounts(model.Account{ID: 1, Username: "alice", Email: "alice@example.com"})
+			m := &recordingMailer{err: tt.mailErr}
+			svc := NewNotification(repo, accounts, m, templates)
+
+			err := svc.Notify(context.Background(), 1, model.NotificationProjectApproved, map[string]any{"ProjectName": "Solar Farm"})
+			if err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+
+			if tt.wantTitle == "" {
+				if len(repo.created) != 0 || len(m.sent) != 0 {
+					t.Fatalf("muted notification was stored %d times and mailed %d times", len(repo.created), len(m.sent))
+				}
+				return
+			}
+			if len(repo.created) != 1 || repo.created[0].Title != tt.wantTitle {
+				t.Fatalf("stored %+v, want one notification titled %q", repo.created, tt.wantTitle)
+			}
+			if tt.wantMail != (len(m.sent) == 1) {
+				t.Fatalf("mailed %d messages, want mail %v", len(m.sent), tt.wantMail)
+			}
+			if tt.wantMail && (m.sent[0].To != "alice@example.com" || m.sent[0].Subject != tt.wantTitle) {
+				t.Fatalf("mailed %+v, want %q to alice@example.com", m.sent[0], tt.wantTitle)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/CryptoCrowd/internal/kyc"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrErasureAlreadyRequested = errors.New("account erasure is already scheduled")
+	ErrErasureRequestNotFound  = errors.New("no pending account erasure request")
+)
+
+// PrivacyRepository defines the interface for personal data export and erasure
+type PrivacyRepository interface {
+	Export(ctx context.Context, userID int64) (map[string]json.RawMessage, error)
+	ListDocuments(ctx context.Context, userID int64) ([]model.KYCDocument, error)
+	CreateErasureRequest(ctx context.Context, userID int64, scheduledAt time.Time) (model.ErasureRequest, error)
+	GetPendingErasure(ctx context.Context, userID int64) (model.ErasureRequest, error)
+	CancelErasure(ctx context.Context, userID int64) (model.ErasureRequest, error)
+	ListDueErasures(ctx context.Context, now time.Time) ([]model.ErasureRequest, error)
+	Erase(ctx context.Context, requestID int64) ([]string, error)
+}
+
+// DataExport holds everything stored about a user, one JSON array per section
+type DataExport struct {
+	UserID      int64                      `json:"user_id"`
+	GeneratedAt time.Time                  `json:"generated_at"`
+	Sections    map[string]json.RawMessage `json:"sections"`
+}
+
+// Privacy service implements the data subject rights of access and erasure
+type Privacy struct {
+	repo        PrivacyRepository
+	documents   kyc.DocumentStore
+	auditor     Auditor
+	gracePeriod time.Duration
+}
+
+// NewPrivacy creates a new privacy service. Erasure takes effect gracePeriod after the request
+func NewPrivacy(repo PrivacyRepository, documents kyc.DocumentStore, auditor Auditor, gracePeriod time.Duration) *Privacy {
+	return &Privacy{
+		repo:        repo,
+		documents:   documents,
+		auditor:     auditor,
+		gracePeriod: gracePeriod,
+	}
+}
+
+// Export returns all data stored about the user as JSON sections
+func (p *Privacy) Export(ctx context.Context, userID int64) (DataExport, error) {
+	ctx, span := tracing.Start(ctx, "Privacy.Export")
+	defer span.End()
+
+	sections, err := p.repo.Export(ctx, userID)
+	if err != nil {
+		return DataExport{}, err
+	}
+
+	// The data is handed out only once the export is on record
+	if err = p.auditor.Record(ctx, model.AuditAccountDataExport, model.AuditTargetAccount, userID, nil, nil); err != nil {
+		return DataExport{}, err
+	}
+	return DataExport{
+		UserID:      userID,
+		GeneratedAt: time.Now().UTC(),
+		Sections:    sections,
+	}, nil
+}
+
+// WriteExportZIP writes the export as a ZIP archive with one JSON file per section
+// and the uploaded KYC document files
+func (p *Privacy) WriteExportZIP(ctx context.Context, userID int64, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "Privacy.WriteExportZIP")
+	defer span.End()
+
+	export, err := p.Export(ctx, userID)
+	if err != nil {
+		return err
+	}
+	docs, err := p.repo.ListDocuments(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+
+	names := make([]string, 0, len(export.Sections))
+	for name := range export.Sections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if err = writeZIPFile(archive, name+".json", export.GeneratedAt, export.Sections[name]); err != nil {
+			return err
+		}
+	}
+
+	for _, doc := range docs {
+		if err = p.writeDocument(ctx, archive, doc, export.GeneratedAt); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func (p *Privacy) writeDocument(ctx context.Context, archive *zip.Writer, doc model.KYCDocument, modified time.Time) error {
+	file, err := p.documents.Open(ctx, doc.StorageKey)
+	if errors.Is(err, kyc.ErrDocumentNotFound) {
+		logger.FromContext(ctx).Errorf("File of KYC document %d is missing from the data export", doc.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	name := path.Join("kyc-documents", strconv.FormatInt(doc.ID, 10)+"-"+path.Base(doc.FileName))
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("failed to write data export: %w", err)
+	}
+	if _, err = io.Copy(entry, file); err != nil {
+		return fmt.Errorf("failed to write data export: %w", err)
+	}
+	return nil
+}
+
+func writeZIPFile(archive *zip.Writer, name string, modified time.Time, data []byte) error {
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("failed to write data export: %w", err)
+	}
+	if _, err = entry.Write(data); err != nil {
+		return fmt.Errorf("failed to write data export: %w", err)
+	}
+	return nil
+}
+
+// RequestErasure schedules erasure of the account after the grace period.
+// The user can cancel the request until then
+func (p *Privacy) RequestErasure(ctx context.Context, userID int64) (model.ErasureRequest, error) {
+	ctx, span := tracing.Start(ctx, "Privacy.RequestErasure")
+	defer span.End()
+
+	var req model.ErasureRequest
+	err := p.auditor.Change(ctx, func(ctx context.Context) error {
+		var err error
+		if req, err = p.repo.CreateErasureRequest(ctx, userID, time.Now().Add(p.gracePeriod)); err != nil {
+			return err
+		}
+		return p.auditor.Record(ctx, model.AuditAccountErasureRequest, model.AuditTargetAccount, userID, nil,
+			map[string]any{"erasure_request_id": req.ID, "scheduled_at": req.ScheduledAt})
+	})
+	if errors.Is(err, repository.ErrErasureAlreadyRequested) {
+		return model.ErasureRequest{}, fmt.Errorf("%w", ErrErasureAlreadyRequested)
+	}
+	if err != nil {
+		return model.ErasureRequest{}, err
+	}
+
+	logger.FromContext(ctx).Infof("User %d requested account erasure scheduled at %s", userID, req.ScheduledAt)
+	return req, nil
+}
+
+// GetErasure returns the pending erasure request of the user
+func (p *Privacy) GetErasure(ctx context.Context, userID int64) (model.ErasureRequest, error) {
+	ctx, span := tracing.Start(ctx, "Privacy.GetErasure")
+	defer span.End()
+
+	req, err := p.repo.GetPendingErasure(ctx, userID)
+	if errors.Is(err, repository.ErrErasureRequestNotFound) {
+		return model.ErasureRequest{}, fmt.Errorf("%w", ErrErasureRequestNotFound)
+	}
+	return req, err
+}
+
+// CancelErasure cancels the pending erasure request of the user
+func (p *Privacy) CancelErasure(ctx context.Context, userID int64) (model.ErasureRequest, error) {
+	ctx, span := tracing.Start(ctx, "Privacy.CancelErasure")
+	defer span.End()
+
+	var req model.ErasureRequest
+	err := p.auditor.Change(ctx, func(ctx context.Context) error {
+		var err error
+		if req, err = p.repo.CancelErasure(ctx, userID); err != nil {
+			return err
+		}
+		return p.auditor.Record(ctx, model.AuditAccountErasureCancel, model.AuditTargetAccount, userID,
+			map[string]any{"erasure_request_id": req.ID, "status": model.ErasurePending},
+			map[string]any{"erasure_request_id": req.ID, "status": req.Status})
+	})
+	if errors.Is(err, repository.ErrErasureRequestNotFound) {
+		return model.ErasureRequest{}, fmt.Errorf("%w", ErrErasureRequestNotFound)
+	}
+	if err != nil {
+		return model.ErasureRequest{}, err
+	}
+	return req, nil
+}
+
+// EraseDue pseudonymizes accounts whose grace period has ended. Financial records are kept
+func (p *Privacy) EraseDue(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "Privacy.EraseDue")
+	defer span.End()
+
+	reqs, err := p.repo.ListDueErasures(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		var keys []string
+		err := p.auditor.Change(ctx, func(ctx context.Context) error {
+			var err error
+			if keys, err = p.repo.Erase(ctx, req.ID); err != nil {
+				return err
+			}
+			return p.auditor.Record(ctx, model.AuditAccountErase, model.AuditTargetAccount, req.UserID, nil,
+				map[string]any{"erasure_request_id": req.ID})
+		})
+		if errors.Is(err, repository.ErrErasureRequestNotFound) {
+			// Cancelled after it was listed
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err = p.documents.Delete(ctx, key); err != nil {
+				logger.FromContext(ctx).Errorf("Failed to delete KYC document file of erased user %d: %v", req.UserID, err)
+			}
+		}
+
+		logger.FromContext(ctx).Infof("Erased personal data of user %d", req.UserID)
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/CryptoCrowd/internal/logger"
 	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
 )
 
 var (
@@ -18,60 +20,85 @@ var (
 	ErrInvalidProjectStatus      = errors.New("invalid project status")
 	ErrInvalidProjectAmount      = errors.New("invalid project amount")
 	ErrInvalidProjectDeadline    = errors.New("invalid project deadline")
+	ErrProjectAccessDenied       = errors.New("projects of other users cannot be changed")
+	ErrProjectLocked             = errors.New("project cannot be changed after approval")
 )
 
+// moderationStatuses lists the statuses an administrator can assign during moderation
+var moderationStatuses = map[string]string{
+	"approved": model.NotificationProjectApproved,
+	"rejected": model.NotificationProjectRejected,
+}
+
 // ProjectRepository defines the interface for project repository operations
 type ProjectRepository interface {
-	Create(ctx context.Context, project model.Project) error
+	Create(ctx context.Context, project model.Project) (int64, error)
 	Update(ctx context.Context, project model.Project) error
-	Delete(ctx context.Context, id int) error
-	GetByID(ctx context.Context, id int) (model.Project, error)
+	Delete(ctx context.Context, id int64, version int64) error
+	GetByID(ctx context.Context, id int64) (model.Project, error)
 	List(ctx context.Context, searchTerm string) ([]model.Project, error)
 	ListByOwnerID(ctx context.Context, id int64, searchTerm string) ([]model.Project, error)
 	GetPhotosByProjectID(ctx context.Context, projectID int) ([]model.ProjectImage, error)
+	UpdateStatus(ctx context.Context, id int64, status string, version int64) error
+	FailExpired(ctx context.Context, now time.Time) ([]model.Project, error)
+	ListBackerIDs(ctx context.Context, projectID int64) ([]int64, error)
+	GetProgress(ctx context.Context, id int64) (model.ProjectProgress, error)
+	ListDeleted(ctx context.Context) ([]model.Project, error)
+	Restore(ctx context.Context, id int64) (model.Project, error)
+}
+
+// Notifier delivers user notifications about project lifecycle events
+type Notifier interface {
+	Notify(ctx context.Context, userID int64, kind string, data map[string]any) error
 }
 
 // Project service implements business logic for project operations
 type Project struct {
-	repo ProjectRepository
+	repo     ProjectRepository
+	accounts AccountReader
+	notifier Notifier
+	auditor  Auditor
 }
 
 // NewProject creates a new project service
-func NewProject(repo ProjectRepository) *Project {
+func NewProject(repo ProjectRepository, accounts AccountReader, notifier Notifier, auditor Auditor) *Project {
 	return &Project{
-		repo: repo,
+		repo:     repo,
+		accounts: accounts,
+		notifier: notifier,
+		auditor:  auditor,
 	}
 }
 
 // validateProject validates project data
-func (p *Project) validateProject(project model.Project) error {
+func (p *Project) validateProject(ctx context.Context, project model.Project) error {
 	// Validate project name
 	if project.Name == "" {
-		logger.Error("Invalid project name")
+		logger.FromContext(ctx).Error("Invalid project name")
 		return fmt.Errorf("%w", ErrInvalidProjectName)
 	}
 
 	// Validate project description
 	if project.Description == "" {
-		logger.Error("Invalid project description")
+		logger.FromContext(ctx).Error("Invalid project description")
 		return fmt.Errorf("%w", ErrInvalidProjectDescription)
 	}
 
 	// Validate project owner
 	if project.OwnerID <= 0 {
-		logger.Error("Invalid project owner")
+		logger.FromContext(ctx).Error("Invalid project owner")
 		return fmt.Errorf("%w", ErrInvalidProjectOwner)
 	}
 
 	// Validate project status
 	if project.Status == "" {
-		logger.Error("Invalid project status")
+		logger.FromContext(ctx).Error("Invalid project status")
 		return fmt.Errorf("%w", ErrInvalidProjectStatus)
 	}
 
 	// Validate project amount
 	if project.AmountRequested.LessThanOrEqual(decimal.NewFromInt(0)) {
-		logger.Error("Invalid project amount requested")
+		logger.FromContext(ctx).Error("Invalid project amount requested")
 		return fmt.Errorf("%w", ErrInvalidProjectAmount)
 	}
 
@@ -79,7 +106,7 @@ func (p *Project) validateProject(project model.Project) error {
 	if project.DeadlineAt != nil {
 		now := time.Now()
 		if project.DeadlineAt.Before(now) {
-			logger.Error("Invalid project deadline: deadline is in the past")
+			logger.FromContext(ctx).Error("Invalid project deadline: deadline is in the past")
 			return fmt.Errorf("%w: deadline is in the past", ErrInvalidProjectDeadline)
 		}
 	}
@@ -87,37 +114,295 @@ func (p *Project) validateProject(project model.Project) error {
 	return nil
 }
 
-// Create создает новый проект (заглушка)
+// Create создает новый проект, который ожидает модерации
 func (p *Project) Create(ctx context.Context, project model.Project) error {
-	return nil
+	ctx, span := tracing.Start(ctx, "Project.Create")
+	defer span.End()
+
+	project.Status = "pending"
+	project.AmountRaised = decimal.Zero
+
+	if err := p.validateProject(ctx, project); err != nil {
+		return err
+	}
+
+	if err := ensureVerified(ctx, p.accounts, project.OwnerID); err != nil {
+		return err
+	}
+
+	// Only founders with a verified identity can publish campaigns
+	if err := ensureKYCVerified(ctx, p.accounts, project.OwnerID); err != nil {
+		return err
+	}
+
+	return p.auditor.Change(ctx, func(ctx context.Context) error {
+		id, err := p.repo.Create(ctx, project)
+		if err != nil {
+			return err
+		}
+
+		project.ID = id
+		return p.auditor.Record(ctx, model.AuditProjectCreate, model.AuditTargetProject, id, nil, project)
+	})
 }
 
-// Update обновляет существующий проект (заглушка)
-func (p *Project) Update(ctx context.Context, project model.Project, userID int) error {
-	return nil
+// Update changes the name, description, requested amount and deadline of a project awaiting
+// moderation. The change is rejected with repository.ErrVersionConflict if the project was
+// changed since the caller read update.Version
+func (p *Project) Update(ctx context.Context, update model.Project, userID int64) (model.Project, error) {
+	ctx, span := tracing.Start(ctx, "Project.Update")
+	defer span.End()
+
+	project, err := p.ownedProject(ctx, update.ID, userID)
+	if err != nil {
+		return model.Project{}, err
+	}
+
+	updated := project
+	updated.Name = update.Name
+	updated.Description = update.Description
+	updated.AmountRequested = update.AmountRequested
+	updated.DeadlineAt = update.DeadlineAt
+	updated.Version = update.Version
+	if err = p.validateProject(ctx, updated); err != nil {
+		return model.Project{}, err
+	}
+
+	err = p.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := p.repo.Update(ctx, updated); err != nil {
+			return err
+		}
+
+		updated.Version++
+		return p.auditor.Record(ctx, model.AuditProjectUpdate, model.AuditTargetProject, project.ID, project, updated)
+	})
+	if err != nil {
+		return model.Project{}, err
+	}
+	return updated, nil
 }
 
-// Delete удаляет проект (заглушка)
-func (p *Project) Delete(ctx context.Context, id int, userID int) error {
-	return nil
+// Delete marks a project awaiting moderation or rejected as deleted
+func (p *Project) Delete(ctx context.Context, id int64, userID int64, version int64) error {
+	ctx, span := tracing.Start(ctx, "Project.Delete")
+	defer span.End()
+
+	project, err := p.ownedProject(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return p.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := p.repo.Delete(ctx, id, version); err != nil {
+			return err
+		}
+		return p.auditor.Record(ctx, model.AuditProjectDelete, model.AuditTargetProject, id, project, nil)
+	})
 }
 
-// GetByID возвращает проект по ID (заглушка)
-func (p *Project) GetByID(ctx context.Context, id int) (model.Project, error) {
-	return model.Project{}, nil
+// ownedProject returns a project the user may change: their own one not yet approved
+func (p *Project) ownedProject(ctx context.Context, id int64, userID int64) (model.Project, error) {
+	project, err := p.repo.GetByID(ctx, id)
+	if err != nil {
+		return model.Project{}, err
+	}
+	if project.OwnerID != userID {
+		logger.FromContext(ctx).Errorf("User %d tried to change project %d of user %d", userID, id, project.OwnerID)
+		return model.Project{}, fmt.Errorf("%w", ErrProjectAccessDenied)
+	}
+	if project.Status != "pending" && project.Status != "rejected" {
+		logger.FromContext(ctx).Errorf("User %d tried to change project %d in status %s", userID, id, project.Status)
+		return model.Project{}, fmt.Errorf("%w", ErrProjectLocked)
+	}
+	return project, nil
 }
 
-// List возвращает список проектов (заглушка)
+// GetByID returns a project. Projects that are not approved are visible only to their owner
+func (p *Project) GetByID(ctx context.Context, id int64, requestingUserID int64) (model.Project, error) {
+	ctx, span := tracing.Start(ctx, "Project.GetByID")
+	defer span.End()
+
+	project, err := p.repo.GetByID(ctx, id)
+	if err != nil {
+		return model.Project{}, err
+	}
+	if project.Status != "approved" && project.OwnerID != requestingUserID {
+		return model.Project{}, repository.ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// List возвращает список проектов, в названии или описании которых есть searchTerm
 func (p *Project) List(ctx context.Context, searchTerm string) ([]model.Project, error) {
-	return nil, nil
+	ctx, span := tracing.Start(ctx, "Project.List")
+	defer span.End()
+
+	return p.repo.List(ctx, searchTerm)
 }
 
-// ListByOwnerID возвращает список проектов по ownerID (заглушка)
-func (p *Project) ListByOwnerID(ctx context.Context, ownerID int64, searchTerm string) ([]model.Project, error) {
-	return nil, nil
+// ListByOwnerID возвращает список проектов по ownerID. Владелец видит все свои проекты,
+// остальные пользователи - только одобренные
+func (p *Project) ListByOwnerID(ctx context.Context, ownerID int64, requestingUserID int64, searchTerm string) ([]model.Project, error) {
+	ctx, span := tracing.Start(ctx, "Project.ListByOwnerID")
+	defer span.End()
+
+	projects, err := p.repo.ListByOwnerID(ctx, ownerID, searchTerm)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID == requestingUserID {
+		return projects, nil
+	}
+
+	visible := make([]model.Project, 0, len(projects))
+	for _, project := range projects {
+		if project.Status == "approved" {
+			visible = append(visible, project)
+		}
+	}
+	return visible, nil
 }
 
-// GetPhotosByProjectID возвращает фото проекта (заглушка)
+// GetPhotosByProjectID возвращает фото проекта в порядке загрузки
 func (p *Project) GetPhotosByProjectID(ctx context.Context, projectID int) ([]model.ProjectImage, error) {
-	return nil, nil
+	ctx, span := tracing.Start(ctx, "Project.GetPhotosByProjectID")
+	defer span.End()
+
+	return p.repo.GetPhotosByProjectID(ctx, projectID)
+}
+
+// GetProgress returns the current funding progress of a project
+func (p *Project) GetProgress(ctx context.Context, id int64) (model.ProjectProgress, error) {
+	ctx, span := tracing.Start(ctx, "Project.GetProgress")
+	defer span.End()
+
+	return p.repo.GetProgress(ctx, id)
+}
+
+// UpdateStatus applies a moderation decision to a project and notifies its owner.
+// The decision is rejected if the project was changed since the moderator read version
+func (p *Project) UpdateStatus(ctx context.Context, id int64, status string, version int64) error {
+	ctx, span := tracing.Start(ctx, "Project.UpdateStatus")
+	defer span.End()
+
+	kind, ok := moderationStatuses[status]
+	if !ok {
+		logger.FromContext(ctx).Error("Invalid project status")
+		return fmt.Errorf("%w", ErrInvalidProjectStatus)
+	}
+
+	project, err := p.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = p.auditor.Change(ctx, func(ctx context.Context) error {
+		if err := p.repo.UpdateStatus(ctx, id, status, version); err != nil {
+			return err
+		}
+
+		updated := project
+		updated.Status = status
+		updated.Version = version + 1
+		return p.auditor.Record(ctx, model.AuditProjectStatusChange, model.AuditTargetProject, id, project, updated)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = p.notifier.Notify(ctx, project.OwnerID, kind, projectNotificationData(project)); err != nil {
+		logger.FromContext(ctx).Errorf("Failed to notify owner of project %d: %v", id, err)
+	}
+
+	return nil
+}
+
+// ListDeleted returns projects marked as deleted that have not been purged yet
+func (p *Project) ListDeleted(ctx context.Context) ([]model.Project, error) {
+	ctx, span := tracing.Start(ctx, "Project.ListDeleted")
+	defer span.End()
+
+	return p.repo.ListDeleted(ctx)
+}
+
+// Restore clears the deletion mark of a project
+func (p *Project) Restore(ctx context.Context, id int64) (model.Project, error) {
+	ctx, span := tracing.Start(ctx, "Project.Restore")
+	defer span.End()
+
+	var project model.Project
+	err := p.auditor.Change(ctx, func(ctx context.Context) error {
+		var err error
+		if project, err = p.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		return p.auditor.Record(ctx, model.AuditProjectRestore, model.AuditTargetProject, id,
+			map[string]any{"deleted": true}, map[string]any{"deleted": false})
+	})
+	if err != nil {
+		return model.Project{}, err
+	}
+
+	logger.FromContext(ctx).Infof("Project %d restored", id)
+	return project, nil
+}
+
+// FailExpired closes approved campaigns whose deadline passed without reaching
+// the requested amount and notifies their backers
+func (p *Project) FailExpired(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "Project.FailExpired")
+	defer span.End()
+
+	var projects []model.Project
+	err := p.auditor.Change(ctx, func(ctx context.Context) error {
+		var err error
+		if projects, err = p.repo.FailExpired(ctx, time.Now()); err != nil {
+			return err
+		}
+
+		for _, project := range projects {
+			// Only approved campaigns are failed, see PostgresProject.FailExpired
+			before := project
+			before.Status = "approved"
+			if err = p.auditor.Record(ctx, model.AuditProjectStatusChange, model.AuditTargetProject, project.ID, before, project); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		// The campaign is already failed, so the remaining projects still have to notify their backers
+		backers, err := p.repo.ListBackerIDs(ctx, project.ID)
+		if err != nil {
+			logger.FromContext(ctx).Errorf("Failed to list backers of failed project %d: %v", project.ID, err)
+			continue
+		}
+
+		logger.FromContext(ctx).Infof("Campaign of project %d failed, notifying %d backers", project.ID, len(backers))
+		for _, userID := range backers {
+			err = p.notifier.Notify(ctx, userID, model.NotificationCampaignFailed, projectNotificationData(project))
+			if err != nil {
+				logger.FromContext(ctx).Errorf("Failed to notify user %d about failed project %d: %v", userID, project.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func projectNotificationData(project model.Project) map[string]any {
+	data := map[string]any{
+		"ProjectName":     project.Name,
+		"AmountRequested": project.AmountRequested.String(),
+		"AmountRaised":    project.AmountRaised.String(),
+		"Deadline":        "",
+	}
+	if project.DeadlineAt != nil {
+		data["Deadline"] = project.DeadlineAt.Format("02.01.2006")
+	}
+	return data
 }
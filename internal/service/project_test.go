@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// expiringProjects fails a fixed set of campaigns and serves their backers
+type expiringProjects struct {
+	ProjectRepository
+
+	expired []model.Project
+	backers map[int64][]int64
+	// backersErr fails listing the backers of the given projects
+	backersErr map[int64]error
+}
+
+func (e *expiringProjects) FailExpired(context.Context, time.Time) ([]model.Project, error) {
+	return e.expired, nil
+}
+
+func (e *expiringProjects) ListBackerIDs(_ context.Context, projectID int64) ([]int64, error) {
+	if err := e.backersErr[projectID]; err != nil {
+		return nil, err
+	}
+	return e.backers[projectID], nil
+}
+
+// recordingNotifier keeps the users it notified and the notifications they got
+type recordingNotifier struct {
+	notified []int64
+	sent     []string
+	fail     map[int64]error
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, userID int64, kind string, data map[string]any) error {
+	n.notified = append(n.notified, userID)
+	if err := n.fail[userID]; err != nil {
+		return err
+	}
+	n.sent = append(n.sent, fmt.Sprintf("%d:%s:%v", userID, kind, data["ProjectName"]))
+	return nil
+}
+
+func TestProjectFailExpired(t *testing.T) {
+	errBackers := errors.New("backers unavailable")
+	errMail := errors.New("mail unavailable")
+
+	tests := []struct {
+		name         string
+		backersErr   map[int64]error
+		notifyErr    map[int64]error
+		wantNotified []int64
+		wantSent     []string
+	}{
+		{
+			name:         "all backers notified",
+			wantNotified: []int64{101, 102, 201},
+			wantSent:     []string{"101:campaign_failed:first", "102:campaign_failed:first", "201:campaign_failed:second"},
+		},
+		{
+			name:         "backers of one project unavailable",
+			backersErr:   map[int64]error{1: errBackers},
+			wantNotified: []int64{201},
+			wantSent:     []string{"201:campaign_failed:second"},
+		},
+		{
+			name:         "failed notification does not stop the others",
+			notifyErr:    map[int64]error{101: errMail},
+			wantNotified: []int64{101, 102, 201},
+			wantSent:     []string{"102:campaign_failed:first", "201:campaign_failed:second"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &expiringProjects{
+				expired: []model.Project{
+					{ID: 1, Name: "first", Status: "failed"},
+					{ID: 2, Name: "second", Status: "failed"},
+				},
+				backers:    map[int64][]int64{1: {101, 102}, 2: {201}},
+				backersErr: tt.backersErr,
+			}
+			notifier := &recordingNotifier{fail: tt.notifyErr}
+			auditor := &recordingAuditor{}
+			svc := NewProject(repo, nil, notifier, auditor)
+
+			if err := svc.FailExpired(context.Background()); err != nil {
+				t.Fatalf("FailExpired: %v", err)
+			}
+			if !slices.Equal(notifier.notified, tt.wantNotified) {
+				t.Fatalf("notified %v, want %v", notifier.notified, tt.wantNotified)
+			}
+			if !slices.Equal(notifier.sent, tt.wantSent) {
+				t.Fatalf("sent %v, want %v", notifier.sent, tt.wantSent)
+			}
+
+			want := []string{model.AuditProjectStatusChange, model.AuditProjectStatusChange}
+			if got := auditor.actions(); !slices.Equal(got, want) {
+				t.Fatalf("audit actions = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+// DeletedPurger permanently deletes records marked as deleted before the given time
+type DeletedPurger interface {
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Retention service permanently deletes soft-deleted records once their retention period ends
+type Retention struct {
+	accounts            DeletedPurger
+	projects            DeletedPurger
+	investments         DeletedPurger
+	retention           time.Duration
+	investmentRetention time.Duration
+}
+
+// NewRetention creates a new retention service. Accounts and projects are kept for retention
+// after deletion, investments for investmentRetention as financial records
+func NewRetention(
+	accounts DeletedPurger,
+	projects DeletedPurger,
+	investments DeletedPurger,
+	retention time.Duration,
+	investmentRetention time.Duration,
+) *Retention {
+	return &Retention{
+		accounts:            accounts,
+		projects:            projects,
+		investments:         investments,
+		retention:           retention,
+		investmentRetention: investmentRetention,
+	}
+}
+
+// PurgeDeleted permanently deletes records whose retention period has ended. Investments go
+// first so that projects and accounts they referenced become eligible in the same run.
+// Records that must be kept for reporting are skipped by the repositories
+func (r *Retention) PurgeDeleted(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "Retention.PurgeDeleted")
+	defer span.End()
+
+	now := time.Now()
+
+	investments, err := r.investments.PurgeDeleted(ctx, now.Add(-r.investmentRetention))
+	if err != nil {
+		return err
+	}
+	projects, err := r.projects.PurgeDeleted(ctx, now.Add(-r.retention))
+	if err != nil {
+		return err
+	}
+	accounts, err := r.accounts.PurgeDeleted(ctx, now.Add(-r.retention))
+	if err != nil {
+		return err
+	}
+
+	if investments+projects+accounts > 0 {
+		logger.FromContext(ctx).Infof("Purged deleted records: %d investments, %d projects, %d accounts",
+			investments, projects, accounts)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrInvalidLogLevel = errors.New("level must be debug, info, warn, error or fatal")
+	ErrInvalidConfig   = errors.New("failed to reload configuration")
+)
+
+// ConfigReloader re-reads the configuration and applies the settings that can change at runtime.
+// It returns the sections whose changes only take effect after a restart
+type ConfigReloader interface {
+	Reload() ([]string, error)
+}
+
+// Runtime service changes operational settings of the running process
+type Runtime struct {
+	reloader ConfigReloader
+	auditor  Auditor
+}
+
+// NewRuntime creates a new runtime service
+func NewRuntime(reloader ConfigReloader, auditor Auditor) *Runtime {
+	return &Runtime{
+		reloader: reloader,
+		auditor:  auditor,
+	}
+}
+
+// LogLevel returns the current level of the global logger
+func (s *Runtime) LogLevel() string {
+	return logger.Level()
+}
+
+// SetLogLevel changes the level of the global logger until the next restart or configuration reload
+func (s *Runtime) SetLogLevel(ctx context.Context, level string) error {
+	ctx, span := tracing.Start(ctx, "Runtime.SetLogLevel")
+	defer span.End()
+
+	before := logger.Level()
+	if err := logger.SetLevel(level); err != nil {
+		logger.FromContext(ctx).Error("Invalid log level: ", level)
+		return fmt.Errorf("%w", ErrInvalidLogLevel)
+	}
+
+	logger.FromContext(ctx).Warnf("Log level changed from %s to %s", before, logger.Level())
+	// The level lives in memory, so there is no transaction to undo: a failed record is reported instead
+	return s.auditor.Record(ctx, model.AuditConfigLogLevel, model.AuditTargetConfig, 0,
+		map[string]string{"level": before}, map[string]string{"level": logger.Level()})
+}
+
+// ReloadConfig re-reads the configuration file and environment. An invalid configuration is
+// rejected and the current one stays in effect
+func (s *Runtime) ReloadConfig(ctx context.Context) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "Runtime.ReloadConfig")
+	defer span.End()
+
+	restartRequired, err := s.reloader.Reload()
+	if err != nil {
+		logger.FromContext(ctx).Errorf("Failed to reload configuration: %v", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	logger.FromContext(ctx).Info("Configuration reloaded")
+	if len(restartRequired) > 0 {
+		logger.FromContext(ctx).Warnf("Changes of %v take effect after a restart", restartRequired)
+	}
+	err = s.auditor.Record(ctx, model.AuditConfigReload, model.AuditTargetConfig, 0,
+		nil, map[string][]string{"restart_required": restartRequired})
+	if err != nil {
+		return nil, err
+	}
+	return restartRequired, nil
+}
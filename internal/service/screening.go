@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/screening"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrSanctionsReview          = errors.New("account is on hold pending sanctions screening review")
+	ErrScreeningHitNotFound     = errors.New("screening hit not found")
+	ErrScreeningHitNotPending   = errors.New("screening hit has already been reviewed")
+	ErrInvalidScreeningDecision = errors.New("decision must be confirmed or dismissed")
+)
+
+// rescreenBatchSize is the number of accounts loaded at once during a re-screening run
+const rescreenBatchSize = 500
+
+// ScreeningRepository defines the interface for screening hits and runs
+type ScreeningRepository interface {
+	RecordHits(ctx context.Context, hits []model.ScreeningHit) ([]model.ScreeningHit, error)
+	HasOpenHits(ctx context.Context, userID int64) (bool, error)
+	ListHits(ctx context.Context, status string) ([]model.ScreeningHit, error)
+	Review(ctx context.Context, id int64, status string, reviewerID int64) (model.ScreeningHit, error)
+	GetSubject(ctx context.Context, userID int64) (model.ScreeningSubject, error)
+	ListSubjects(ctx context.Context, afterID int64, limit int) ([]model.ScreeningSubject, error)
+	LastRunVersion(ctx context.Context) (string, error)
+	CreateRun(ctx context.Context, run model.ScreeningRun) error
+}
+
+// SanctionsLists matches names and wallet addresses against sanctions lists
+type SanctionsLists interface {
+	MatchName(name string) []screening.Match
+	MatchAddress(address string) []screening.Match
+	Version() string
+	Reload() (bool, error)
+}
+
+// SanctionsScreener screens accounts against sanctions lists
+type SanctionsScreener interface {
+	ScreenAccount(ctx context.Context, userID int64, trigger string) error
+}
+
+// Screening service screens accounts and their wallets against sanctions lists
+// and keeps hits for review by administrators
+type Screening struct {
+	repo    ScreeningRepository
+	lists   SanctionsLists
+	auditor Auditor
+}
+
+// NewScreening creates a new screening service
+func NewScreening(repo ScreeningRepository, lists SanctionsLists, auditor Auditor) *Screening {
+	return &Screening{
+		repo:    repo,
+		lists:   lists,
+		auditor: auditor,
+	}
+}
+
+// ScreenAccount matches the account name and linked wallet addresses and records new hits.
+// It returns ErrSanctionsReview while the account has hits that are pending or confirmed,
+// including hits found earlier
+func (s *Screening) ScreenAccount(ctx context.Context, userID int64, trigger string) error {
+	ctx, span := tracing.Start(ctx, "Screening.ScreenAccount")
+	defer span.End()
+
+	subject, err := s.repo.GetSubject(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.screen(ctx, subject, trigger); err != nil {
+		return err
+	}
+
+	held, err := s.repo.HasOpenHits(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if held {
+		return fmt.Errorf("%w", ErrSanctionsReview)
+	}
+	return nil
+}
+
+// Rescreen reloads the lists and screens all accounts if the lists changed since the last run
+func (s *Screening) Rescreen(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "Screening.Rescreen")
+	defer span.End()
+
+	if _, err := s.lists.Reload(); err != nil {
+		return err
+	}
+
+	version := s.lists.Version()
+	last, err := s.repo.LastRunVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version == last {
+		return nil
+	}
+
+	started := time.Now()
+	run := model.ScreeningRun{ListVersion: version, StartedAt: &started}
+	logger.FromContext(ctx).Infof("Sanctions lists changed, re-screening all accounts")
+
+	var afterID int64
+	for {
+		subjects, err := s.repo.ListSubjects(ctx, afterID, rescreenBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, subject := range subjects {
+			hits, err := s.screen(ctx, subject, model.ScreeningTriggerRescreen)
+			if err != nil {
+				return err
+			}
+			run.Screened++
+			run.Hits += int64(len(hits))
+			afterID = subject.UserID
+		}
+
+		if len(subjects) < rescreenBatchSize {
+			break
+		}
+	}
+
+	logger.FromContext(ctx).Infof("Re-screened %d accounts, %d new hits", run.Screened, run.Hits)
+	return s.repo.CreateRun(ctx, run)
+}
+
+// ListHits returns hits with the given status, pending by default
+func (s *Screening) ListHits(ctx context.Context, status string) ([]model.ScreeningHit, error) {
+	ctx, span := tracing.Start(ctx, "Screening.ListHits")
+	defer span.End()
+
+	if status == "" {
+		status = model.ScreeningPending
+	}
+	return s.repo.ListHits(ctx, status)
+}
+
+// Review records an administrator's decision on a pending hit. A dismissed hit releases
+// the account unless it has other open hits, a confirmed one keeps it on hold
+func (s *Screening) Review(ctx context.Context, reviewerID int64, id int64, status string) (model.ScreeningHit, error) {
+	ctx, span := tracing.Start(ctx, "Screening.Review")
+	defer span.End()
+
+	if status != model.ScreeningConfirmed && status != model.ScreeningDismissed {
+		logger.FromContext(ctx).Error("Invalid screening decision")
+		return model.ScreeningHit{}, fmt.Errorf("%w", ErrInvalidScreeningDecision)
+	}
+
+	var hit model.ScreeningHit
+	err := s.auditor.Change(ctx, func(ctx context.Context) error {
+		var err error
+		if hit, err = s.repo.Review(ctx, id, status, reviewerID); err != nil {
+			return err
+		}
+		return s.auditor.Record(ctx, model.AuditScreeningReview, model.AuditTargetAccount, hit.UserID,
+			map[string]any{"screening_hit_id": hit.ID, "status": model.ScreeningPending},
+			map[string]any{"screening_hit_id": hit.ID, "status": hit.Status})
+	})
+	switch {
+	case errors.Is(err, repository.ErrScreeningHitNotFound):
+		return model.ScreeningHit{}, fmt.Errorf("%w", ErrScreeningHitNotFound)
+	case errors.Is(err, repository.ErrScreeningHitNotPending):
+		return model.ScreeningHit{}, fmt.Errorf("%w", ErrScreeningHitNotPending)
+	case err != nil:
+		return model.ScreeningHit{}, err
+	}
+	return hit, nil
+}
+
+// screen matches a subject against the lists and returns newly recorded hits
+func (s *Screening) screen(ctx context.Context, subject model.ScreeningSubject, trigger string) ([]model.ScreeningHit, error) {
+	matches := s.lists.MatchName(subject.Username)
+	for _, address := range subject.Addresses {
+		matches = append(matches, s.lists.MatchAddress(address)...)
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	hits := make([]model.ScreeningHit, len(matches))
+	for idx, match := range matches {
+		hits[idx] = model.ScreeningHit{
+			UserID:     subject.UserID,
+			Kind:       match.Kind,
+			Value:      match.Value,
+			ListSource: match.Source,
+			EntryID:    match.EntryID,
+			EntryName:  match.EntryName,
+			Score:      match.Score,
+			Trigger:    trigger,
+		}
+	}
+
+	recorded, err := s.repo.RecordHits(ctx, hits)
+	if err != nil {
+		return nil, err
+	}
+	for _, hit := range recorded {
+		logger.FromContext(ctx).Warnf("Sanctions screening hit %d for user %d: %s %q matches %s entry %s",
+			hit.ID, hit.UserID, hit.Kind, hit.Value, hit.ListSource, hit.EntryID)
+	}
+	return recorded, nil
+}
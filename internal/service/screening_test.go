@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/screening"
+)
+
+// memoryScreening keeps subjects, hits and runs in memory. Like the database it records
+// a hit only once per user, kind, value and list entry
+type memoryScreening struct {
+	subjects []model.ScreeningSubject
+	hits     []model.ScreeningHit
+	runs     []model.ScreeningRun
+	// listCalls counts ListSubjects calls to check batching
+	listCalls int
+}
+
+func (m *memoryScreening) RecordHits(_ context.Context, hits []model.ScreeningHit) ([]model.ScreeningHit, error) {
+	var recorded []model.ScreeningHit
+	for _, hit := range hits {
+		known := slices.ContainsFunc(m.hits, func(h model.ScreeningHit) bool {
+			return h.UserID == hit.UserID && h.Kind == hit.Kind && h.Value == hit.Value && h.EntryID == hit.EntryID
+		})
+		if known {
+			continue
+		}
+		hit.ID = int64(len(m.hits) + 1)
+		hit.Status = model.ScreeningPending
+		m.hits = append(m.hits, hit)
+		recorded = append(recorded, hit)
+	}
+	return recorded, nil
+}
+
+func (m *memoryScreening) HasOpenHits(_ context.Context, userID int64) (bool, error) {
+	return slices.ContainsFunc(m.hits, func(h model.ScreeningHit) bool {
+		return h.UserID == userID && h.Status != model.ScreeningDismissed
+	}), nil
+}
+
+func (m *memoryScreening) ListHits(_ context.Context, status string) ([]model.ScreeningHit, error) {
+	var hits []model.ScreeningHit
+	for _, hit := range m.hits {
+		if hit.Status == status {
+			hits = append(hits, hit)
+		}
+	}
+	return hits, nil
+}
+
+func (m *memoryScreening) Review(_ context.Context, id int64, status string, reviewerID int64) (model.ScreeningHit, error) {
+	idx := slices.IndexFunc(m.hits, func(h model.ScreeningHit) bool { return h.ID == id })
+	if idx < 0 {
+		return model.ScreeningHit{}, repository.ErrScreeningHitNotFound
+	}
+	if m.hits[idx].Status != model.ScreeningPending {
+		return model.ScreeningHit{}, repository.ErrScreeningHitNotPending
+	}
+	m.hits[idx].Status = status
+	m.hits[idx].ReviewerID = &reviewerID
+	return m.hits[idx], nil
+}
+
+func (m *memoryScreening) GetSubject(_ context.Context, userID int64) (model.ScreeningSubject, error) {
+	idx := slices.IndexFunc(m.subjects, func(s model.ScreeningSubject) bool { return s.UserID == userID })
+	if idx < 0 {
+		return model.ScreeningSubject{}, repository.ErrUserNotFound
+	}
+	return m.subjects[idx], nil
+}
+
+func (m *memoryScreening) ListSubjects(_ context.Context, afterID int64, limit int) ([]model.ScreeningSubject, error) {
+	m.listCalls++
+	var subjects []model.ScreeningSubject
+	for _, subject := range m.subjects {
+		if subject.UserID > afterID && len(subjects) < limit {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects, nil
+}
+
+func (m *memoryScreening) LastRunVersion(_ context.Context) (string, error) {
+	if len(m.runs) == 0 {
+		return "", nil
+	}
+	return m.runs[len(m.runs)-1].ListVersion, nil
+}
+
+func (m *memoryScreening) CreateRun(_ context.Context, run model.ScreeningRun) error {
+	m.runs = append(m.runs, run)
+	return nil
+}
+
+// fixedLists matches exact names and addresses against a fixed set of entries
+type fixedLists struct {
+	names     map[string]string
+	addresses map[string]string
+	version   string
+	reloadErr error
+}
+
+func (l *fixedLists) MatchName(name string) []screening.Match {
+	if entryID, ok := l.names[name]; ok {
+		return []screening.Match{{Kind: model.ScreeningKindName, Value: name, Source: "test.csv", EntryID: entryID, Score: 1}}
+	}
+	return nil
+}
+
+func (l *fixedLists) MatchAddress(address string) []screening.Match {
+	if entryID, ok := l.addresses[address]; ok {
+		return []screening.Match{{Kind: model.ScreeningKindAddress, Value: address, Source: "test.csv", EntryID: entryID, Score: 1}}
+	}
+	return nil
+}
+
+func (l *fixedLists) Version() string {
+	return l.version
+}
+
+func (l *fixedLists) Reload() (bool, error) {
+	return false, l.reloadErr
+}
+
+func newFixedLists() *fixedLists {
+	return &fixedLists{
+		names:     map[string]string{"Ivan Ivanov": "E1"},
+		addresses: map[string]string{"0xbad": "E2"},
+		version:   "v1",
+	}
+}
+
+func TestScreeningScreenAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		subject  model.ScreeningSubject
+		previous []model.ScreeningHit
+		wantHits []string
+		wantErr  error
+	}{
+		{
+			name:    "clean account",
+			subject: model.ScreeningSubject{UserID: 1, Username: "alice", Addresses: []string{"0xgood"}},
+		},
+		{
+			name:     "sanctioned name",
+			subject:  model.ScreeningSubject{UserID: 1, Username: "Ivan Ivanov"},
+			wantHits: []string{"name:E1"},
+			wantErr:  ErrSanctionsReview,
+		},
+		{
+			name:     "sanctioned wallet",
+			subject:  model.ScreeningSubject{UserID: 1, Username: "alice", Addresses: []string{"0xgood", "0xbad"}},
+			wantHits: []string{"address:E2"},
+			wantErr:  ErrSanctionsReview,
+		},
+		{
+			name:     "earlier hit still pending",
+			subject:  model.ScreeningSubject{UserID: 1, Username: "alice"},
+			previous: []model.ScreeningHit{{ID: 1, UserID: 1, Kind: model.ScreeningKindAddress, Value: "0xold", EntryID: "E3", Status: model.ScreeningPending}},
+			wantHits: []string{"address:E3"},
+			wantErr:  ErrSanctionsReview,
+		},
+		{
+			name:     "earlier hit dismissed",
+			subject:  model.ScreeningSubject{UserID: 1, Username: "alice"},
+			previous: []model.ScreeningHit{{ID: 1, UserID: 1, Kind: model.ScreeningKindAddress, Value: "0xold", EntryID: "E3", Status: model.ScreeningDismissed}},
+			wantHits: []string{"address:E3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryScreening{subjects: []model.ScreeningSubject{tt.subject}, hits: tt.previous}
+			svc := NewScreening(repo, newFixedLists(), &recordingAuditor{})
+
+			err := svc.ScreenAccount(context.Background(), tt.subject.UserID, model.ScreeningTriggerSignup)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScreenAccount: error = %v, want %v", err, tt.wantErr)
+			}
+			if got := hitKeys(repo.hits); !slices.Equal(got, tt.wantHits) {
+				t.Fatalf("hits %v, want %v", got, tt.wantHits)
+			}
+
+			// Screening again records nothing new but keeps the account on hold
+			err = svc.ScreenAccount(context.Background(), tt.subject.UserID, model.ScreeningTriggerInvestment)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("second ScreenAccount: error = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.hits) != len(tt.wantHits) {
+				t.Fatalf("second ScreenAccount recorded %d hits, want %d", len(repo.hits), len(tt.wantHits))
+			}
+		})
+	}
+}
+
+func hitKeys(hits []model.ScreeningHit) []string {
+	var keys []string
+	for _, hit := range hits {
+		keys = append(keys, hit.Kind+":"+hit.EntryID)
+	}
+	return keys
+}
+
+func TestScreeningRescreen(t *testing.T) {
+	// One more account than a batch so that the run has to page
+	repo := &memoryScreening{}
+	for id := int64(1); id <= rescreenBatchSize+1; id++ {
+		repo.subjects = append(repo.subjects, model.ScreeningSubject{UserID: id, Username: fmt.Sprintf("user%d", id)})
+	}
+	repo.subjects[rescreenBatchSize].Username = "Ivan Ivanov"
+
+	lists := newFixedLists()
+	svc := NewScreening(repo, lists, &recordingAuditor{})
+
+	if err := svc.Rescreen(context.Background()); err != nil {
+		t.Fatalf("Rescreen: %v", err)
+	}
+	if len(repo.runs) != 1 {
+		t.Fatalf("%d runs recorded, want 1", len(repo.runs))
+	}
+	run := repo.runs[0]
+	if run.ListVersion != "v1" || run.Screened != rescreenBatchSize+1 || run.Hits != 1 {
+		t.Fatalf("run = %+v, want version v1, %d screened, 1 hit", run, rescreenBatchSize+1)
+	}
+	if repo.listCalls != 2 {
+		t.Fatalf("ListSubjects called %d times, want 2", repo.listCalls)
+	}
+	if hit := repo.hits[0]; hit.UserID != rescreenBatchSize+1 || hit.Trigger != model.ScreeningTriggerRescreen {
+		t.Fatalf("hit = %+v, want a rescreen hit of user %d", hit, rescreenBatchSize+1)
+	}
+
+	// Unchanged lists are not screened again
+	if err := svc.Rescreen(context.Background()); err != nil {
+		t.Fatalf("second Rescreen: %v", err)
+	}
+	if len(repo.runs) != 1 || repo.listCalls != 2 {
+		t.Fatalf("unchanged lists re-screened: %d runs, %d ListSubjects calls", len(repo.runs), repo.listCalls)
+	}
+
+	lists.reloadErr = errors.New("list unavailable")
+	lists.version = "v2"
+	if err := svc.Rescreen(context.Background()); !errors.Is(err, lists.reloadErr) {
+		t.Fatalf("Rescreen with a failing reload: error = %v, want %v", err, lists.reloadErr)
+	}
+	if len(repo.runs) != 1 {
+		t.Fatalf("failed reload recorded a run")
+	}
+}
+
+func TestScreeningReview(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int64
+		status     string
+		wantErr    error
+		wantHeld   bool
+		wantAudits []string
+	}{
+		{name: "dismiss releases the account", id: 1, status: model.ScreeningDismissed, wantAudits: []string{model.AuditScreeningReview}},
+		{name: "confirm keeps it on hold", id: 1, status: model.ScreeningConfirmed, wantHeld: true, wantAudits: []string{model.AuditScreeningReview}},
+		{name: "invalid decision", id: 1, status: model.ScreeningPending, wantErr: ErrInvalidScreeningDecision, wantHeld: true},
+		{name: "unknown hit", id: 9, status: model.ScreeningDismissed, wantErr: ErrScreeningHitNotFound, wantHeld: true},
+		{name: "already reviewed", id: 2, status: model.ScreeningDismissed, wantErr: ErrScreeningHitNotPending, wantHeld: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryScreening{hits: []model.ScreeningHit{
+				{ID: 1, UserID: 1, Kind: model.ScreeningKindName, EntryID: "E1", Status: model.ScreeningPending},
+				{ID: 2, UserID: 2, Kind: model.ScreeningKindName, EntryID: "E1", Status: model.ScreeningConfirmed},
+			}}
+			auditor := &recordingAuditor{}
+			svc := NewScreening(repo, newFixedLists(), auditor)
+
+			hit, err := svc.Review(context.Background(), 100, tt.id, tt.status)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Review: error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (hit.Status != tt.status || hit.ReviewerID == nil || *hit.ReviewerID != 100) {
+				t.Fatalf("Review = %+v, want status %s by reviewer 100", hit, tt.status)
+			}
+			if held, _ := repo.HasOpenHits(context.Background(), 1); held != tt.wantHeld {
+				t.Fatalf("account held = %v, want %v", held, tt.wantHeld)
+			}
+			if got := auditor.actions(); !slices.Equal(got, tt.wantAudits) {
+				t.Fatalf("audit actions = %v, want %v", got, tt.wantAudits)
+			}
+		})
+	}
+}
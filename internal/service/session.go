@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked or has expired")
+)
+
+// lastSeenResolution limits how often last activity of a session is written
+const lastSeenResolution = time.Minute
+
+const maxDeviceLength = 255
+
+// SessionRepository defines the interface for session repository operations
+type SessionRepository interface {
+	Create(ctx context.Context, session model.Session) (int64, error)
+	GetActive(ctx context.Context, id int64, userID int64) (model.Session, error)
+	Touch(ctx context.Context, id int64, ip string) error
+	Extend(ctx context.Context, id int64, userID int64, expiresAt time.Time) error
+	ListActive(ctx context.Context, userID int64) ([]model.Session, error)
+	Revoke(ctx context.Context, id int64, userID int64) error
+	RevokeAllExcept(ctx context.Context, userID int64, exceptID int64) error
+}
+
+// Session service ties access tokens to server-side session records
+type Session struct {
+	repo   SessionRepository
+	issuer *auth.TokenIssuer
+}
+
+// NewSession creates a new session service
+func NewSession(repo SessionRepository, issuer *auth.TokenIssuer) *Session {
+	return &Session{
+		repo:   repo,
+		issuer: issuer,
+	}
+}
+
+// Start records a new session for the client and issues its access token
+func (s *Session) Start(ctx context.Context, userID int64, client model.ClientInfo, mfaAt time.Time) (string, auth.Claims, error) {
+	ctx, span := tracing.Start(ctx, "Session.Start")
+	defer span.End()
+
+	device := client.Device
+	if device == "" {
+		device = client.UserAgent
+	}
+	if len(device) > maxDeviceLength {
+		device = device[:maxDeviceLength]
+	}
+
+	expiresAt := time.Now().Add(s.issuer.TTL())
+	id, err := s.repo.Create(ctx, model.Session{
+		UserID:    userID,
+		Device:    device,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return "", auth.Claims{}, err
+	}
+
+	return s.issuer.Issue(userID, id, mfaAt)
+}
+
+// Reissue issues a new token for an existing session and extends the session to its expiry
+func (s *Session) Reissue(ctx context.Context, claims auth.Claims, mfaAt time.Time) (string, auth.Claims, error) {
+	ctx, span := tracing.Start(ctx, "Session.Reissue")
+	defer span.End()
+
+	expiresAt := time.Now().Add(s.issuer.TTL())
+	err := s.repo.Extend(ctx, claims.SessionID, claims.UserID, expiresAt)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return "", auth.Claims{}, fmt.Errorf("%w", ErrSessionRevoked)
+	}
+	if err != nil {
+		return "", auth.Claims{}, err
+	}
+
+	return s.issuer.Issue(claims.UserID, claims.SessionID, mfaAt)
+}
+
+// Authenticate validates an access token and checks that its session is still active
+func (s *Session) Authenticate(ctx context.Context, token string, ip string) (auth.Claims, error) {
+	ctx, span := tracing.Start(ctx, "Session.Authenticate")
+	defer span.End()
+
+	claims, err := s.issuer.Parse(token)
+	if err != nil {
+		return auth.Claims{}, err
+	}
+
+	session, err := s.repo.GetActive(ctx, claims.SessionID, claims.UserID)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return auth.Claims{}, fmt.Errorf("%w", ErrSessionRevoked)
+	}
+	if err != nil {
+		return auth.Claims{}, err
+	}
+
+	if session.LastSeenAt == nil || time.Since(*session.LastSeenAt) > lastSeenResolution || session.IP != ip {
+		if err = s.repo.Touch(ctx, session.ID, ip); err != nil {
+			logger.FromContext(ctx).Errorf("Failed to update last activity of session %d: %v", session.ID, err)
+		}
+	}
+
+	return claims, nil
+}
+
+// List returns active sessions of the user, marking the current one
+func (s *Session) List(ctx context.Context, userID int64, currentID int64) ([]model.Session, error) {
+	ctx, span := tracing.Start(ctx, "Session.List")
+	defer span.End()
+
+	sessions, err := s.repo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// Revoke ends one of the user's sessions
+func (s *Session) Revoke(ctx context.Context, userID int64, id int64) error {
+	ctx, span := tracing.Start(ctx, "Session.Revoke")
+	defer span.End()
+
+	err := s.repo.Revoke(ctx, id, userID)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return fmt.Errorf("%w", ErrSessionNotFound)
+	}
+	return err
+}
+
+// RevokeOthers ends all sessions of the user except the current one
+func (s *Session) RevokeOthers(ctx context.Context, userID int64, currentID int64) error {
+	ctx, span := tracing.Start(ctx, "Session.RevokeOthers")
+	defer span.End()
+
+	return s.repo.RevokeAllExcept(ctx, userID, currentID)
+}
+
+// RevokeAll ends every session of the user, including the current one
+func (s *Session) RevokeAll(ctx context.Context, userID int64) error {
+	ctx, span := tracing.Start(ctx, "Session.RevokeAll")
+	defer span.End()
+
+	return s.repo.RevokeAllExcept(ctx, userID, 0)
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrTwoFactorRequired       = errors.New("two-factor code required")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorMandatory      = errors.New("two-factor authentication is mandatory for this role")
+	ErrStepUpRequired          = errors.New("recent two-factor confirmation required")
+)
+
+const recoveryCodeCount = 10
+
+// TwoFactorRepository defines the interface for TOTP and recovery code storage
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID int64) (model.TwoFactor, error)
+	SavePending(ctx context.Context, userID int64, secret string) error
+	Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error
+	Disable(ctx context.Context, userID int64) error
+	UseStep(ctx context.Context, userID int64, step int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+}
+
+// TwoFactor service implements TOTP enrolment and verification
+type TwoFactor struct {
+	repo         TwoFactorRepository
+	accounts     AccountReader
+	issuer       string
+	stepUpWindow time.Duration
+}
+
+// NewTwoFactor creates a new two-factor authentication service
+func NewTwoFactor(repo TwoFactorRepository, accounts AccountReader, issuer string, stepUpWindow time.Duration) *TwoFactor {
+	return &TwoFactor{
+		repo:         repo,
+		accounts:     accounts,
+		issuer:       issuer,
+		stepUpWindow: stepUpWindow,
+	}
+}
+
+// Enroll generates a new TOTP secret that becomes active after Confirm.
+// Returns the secret and an otpauth URI to render as a QR code
+func (t *TwoFactor) Enroll(ctx context.Context, userID int64) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactor.Enroll")
+	defer span.End()
+
+	acc, err := t.accounts.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = t.repo.SavePending(ctx, userID, secret)
+	if errors.Is(err, repository.ErrTwoFactorAlreadyEnabled) {
+		return "", "", fmt.Errorf("%w", ErrTwoFactorAlreadyEnabled)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, auth.TOTPURI(t.issuer, acc.Email, secret), nil
+}
+
+// Confirm enables two-factor authentication once the user proves possession of the secret.
+// Returns one-time recovery codes, which are shown only once
+func (t *TwoFactor) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactor.Confirm")
+	defer span.End()
+
+	tf, err := t.repo.Get(ctx, userID)
+	if errors.Is(err, repository.ErrTwoFactorNotFound) {
+		return nil, fmt.Errorf("%w", ErrTwoFactorNotEnabled)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tf.EnabledAt != nil {
+		return nil, fmt.Errorf("%w", ErrTwoFactorAlreadyEnabled)
+	}
+
+	step, ok := auth.ValidateTOTP(tf.Secret, code, time.Now(), tf.LastUsedStep)
+	if !ok {
+		logger.FromContext(ctx).Error("Invalid two-factor code")
+		return nil, fmt.Errorf("%w", ErrInvalidTwoFactorCode)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.repo.Enable(ctx, userID, step, hashes)
+	if errors.Is(err, repository.ErrTOTPStepUsed) {
+		return nil, fmt.Errorf("%w", ErrInvalidTwoFactorCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off. Requires a recent step-up and is not
+// allowed for roles where it is mandatory
+func (t *TwoFactor) Disable(ctx context.Context, userID int64) error {
+	ctx, span := tracing.Start(ctx, "TwoFactor.Disable")
+	defer span.End()
+
+	acc, err := t.accounts.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if acc.Role == "admin" {
+		logger.FromContext(ctx).Error("Attempt to disable mandatory two-factor authentication")
+		return fmt.Errorf("%w", ErrTwoFactorMandatory)
+	}
+
+	if err = ensureStepUp(ctx, t.stepUpWindow); err != nil {
+		return err
+	}
+
+	return t.repo.Disable(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user. Requires a recent step-up
+func (t *TwoFactor) RegenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactor.RegenerateRecoveryCodes")
+	defer span.End()
+
+	if err := ensureStepUp(ctx, t.stepUpWindow); err != nil {
+		return nil, err
+	}
+
+	enabled, err := t.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, fmt.Errorf("%w", ErrTwoFactorNotEnabled)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = t.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// IsEnabled reports whether the user has confirmed two-factor authentication
+func (t *TwoFactor) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactor.IsEnabled")
+	defer span.End()
+
+	tf, err := t.repo.Get(ctx, userID)
+	if errors.Is(err, repository.ErrTwoFactorNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.EnabledAt != nil, nil
+}
+
+// Verify checks a TOTP code or, if it is empty, a recovery code. Each code is accepted once
+func (t *TwoFactor) Verify(ctx context.Context, userID int64, code string, recoveryCode string) error {
+	ctx, span := tracing.Start(ctx, "TwoFactor.Verify")
+	defer span.End()
+
+	if code == "" && recoveryCode == "" {
+		return fmt.Errorf("%w", ErrTwoFactorRequired)
+	}
+
+	tf, err := t.repo.Get(ctx, userID)
+	if errors.Is(err, repository.ErrTwoFactorNotFound) || (err == nil && tf.EnabledAt == nil) {
+		return fmt.Errorf("%w", ErrTwoFactorNotEnabled)
+	}
+	if err != nil {
+		return err
+	}
+
+	if code != "" {
+		step, ok := auth.ValidateTOTP(tf.Secret, code, time.Now(), tf.LastUsedStep)
+		if !ok {
+			logger.FromContext(ctx).Errorf("Invalid two-factor code for user %d", userID)
+			return fmt.Errorf("%w", ErrInvalidTwoFactorCode)
+		}
+		err = t.repo.UseStep(ctx, userID, step)
+	} else {
+		err = t.repo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	if errors.Is(err, repository.ErrTOTPStepUsed) || errors.Is(err, repository.ErrRecoveryCodeInvalid) {
+		logger.FromContext(ctx).Errorf("Invalid or reused two-factor code for user %d", userID)
+		return fmt.Errorf("%w", ErrInvalidTwoFactorCode)
+	}
+	return err
+}
+
+// generateRecoveryCodes returns recovery codes formatted for display and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := strings.ToLower(encoding.EncodeToString(buf))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+)
+
+// memoryTwoFactor keeps TOTP state in memory and accepts only steps after the last used one,
+// like the conditional updates of repository.PostgresTwoFactor
+type memoryTwoFactor struct {
+	TwoFactorRepository
+
+	tf            model.TwoFactor
+	recoveryCodes map[string]bool
+}
+
+func (m *memoryTwoFactor) Get(context.Context, int64) (model.TwoFactor, error) {
+	if m.tf.Secret == "" {
+		return model.TwoFactor{}, repository.ErrTwoFactorNotFound
+	}
+	return m.tf, nil
+}
+
+func (m *memoryTwoFactor) Enable(_ context.Context, _ int64, step int64, recoveryCodeHashes []string) error {
+	if m.tf.EnabledAt != nil || step <= m.tf.LastUsedStep {
+		return repository.ErrTOTPStepUsed
+	}
+
+	now := time.Now()
+	m.tf.EnabledAt = &now
+	m.tf.LastUsedStep = step
+	m.recoveryCodes = make(map[string]bool)
+	for _, hash := range recoveryCodeHashes {
+		m.recoveryCodes[hash] = false
+	}
+	return nil
+}
+
+func (m *memoryTwoFactor) UseStep(_ context.Context, _ int64, step int64) error {
+	if step <= m.tf.LastUsedStep {
+		return repository.ErrTOTPStepUsed
+	}
+	m.tf.LastUsedStep = step
+	return nil
+}
+
+func (m *memoryTwoFactor) UseRecoveryCode(_ context.Context, _ int64, codeHash string) error {
+	used, ok := m.recoveryCodes[codeHash]
+	if !ok || used {
+		return repository.ErrRecoveryCodeInvalid
+	}
+	m.recoveryCodes[codeHash] = true
+	return nil
+}
+
+// totpAt computes the RFC 6238 code of the secret at now, the way an authenticator app does
+func totpAt(t *testing.T, secret string, now time.Time) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(now.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func newTwoFactorFixture(t *testing.T, enabled bool) (*TwoFactor, *memoryTwoFactor, string) {
+	t.Helper()
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+
+	repo := &memoryTwoFactor{tf: model.TwoFactor{UserID: 7, Secret: secret}}
+	if enabled {
+		now := time.Now()
+		repo.tf.EnabledAt = &now
+	}
+	svc := NewTwoFactor(repo, newMemoryAccounts(model.Account{ID: 7, Email: "alice@example.com"}), "CryptoCrowd", time.Minute)
+	return svc, repo, secret
+}
+
+func TestTwoFactorVerifyRejectsReplay(t *testing.T) {
+	ctx := context.Background()
+	svc, _, secret := newTwoFactorFixture(t, true)
+	now := time.Now()
+
+	code := totpAt(t, secret, now)
+	if err := svc.Verify(ctx, 7, code, ""); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+	if err := svc.Verify(ctx, 7, code, ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("replayed code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	// A code of the previous step is still within the clock skew but older than the one used
+	if err := svc.Verify(ctx, 7, totpAt(t, secret, now.Add(-30*time.Second)), ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("older code after a newer one: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if err := svc.Verify(ctx, 7, totpAt(t, secret, now.Add(30*time.Second)), ""); err != nil {
+		t.Fatalf("code of the next step: %v", err)
+	}
+}
+
+func TestTwoFactorConfirmBurnsStep(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, secret := newTwoFactorFixture(t, false)
+
+	code := totpAt(t, secret, time.Now())
+	codes, err := svc.Confirm(ctx, 7, code)
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if len(codes) != recoveryCodeCount || repo.tf.EnabledAt == nil {
+		t.Fatalf("Confirm returned %d recovery codes, enabled = %v", len(codes), repo.tf.EnabledAt != nil)
+	}
+
+	// The code that enabled 2FA cannot be used again to log in
+	if err = svc.Verify(ctx, 7, code, ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("Verify with the confirmation code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if _, err = svc.Confirm(ctx, 7, code); !errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+		t.Fatalf("second Confirm: error = %v, want ErrTwoFactorAlreadyEnabled", err)
+	}
+}
+
+func TestTwoFactorRecoveryCodeUsedOnce(t *testing.T) {
+	ctx := context.Background()
+	svc, _, secret := newTwoFactorFixture(t, false)
+
+	codes, err := svc.Confirm(ctx, 7, totpAt(t, secret, time.Now()))
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{name: "recovery code", code: codes[0]},
+		{name: "same recovery code again", code: codes[0], wantErr: ErrInvalidTwoFactorCode},
+		{name: "another code without dash and in upper case", code: strings.ToUpper(strings.ReplaceAll(codes[1], "-", ""))},
+		{name: "unknown code", code: "aaaaa-bbbbb", wantErr: ErrInvalidTwoFactorCode},
+	}
+
+	for _, tt := range tests {
+		if err := svc.Verify(ctx, 7, "", tt.code); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTwoFactorVerifyRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		code    string
+		wantErr error
+	}{
+		{name: "no code", enabled: true, wantErr: ErrTwoFactorRequired},
+		{name: "wrong code", enabled: true, code: "not-a-code", wantErr: ErrInvalidTwoFactorCode},
+		{name: "enrolment not confirmed", code: "000000", wantErr: ErrTwoFactorNotEnabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := newTwoFactorFixture(t, tt.enabled)
+			if err := svc.Verify(context.Background(), 7, tt.code, ""); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify: error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1 +1,72 @@
 package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+)
+
+// generateToken returns a random URL-safe token and the hash under which it is stored
+func generateToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 of a token. Tokens carry enough entropy,
+// so a fast unsalted hash is sufficient and allows lookups by hash
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureVerified returns ErrEmailNotVerified if the account has not confirmed its email
+func ensureVerified(ctx context.Context, accounts AccountReader, userID int64) error {
+	acc, err := accounts.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if acc.EmailVerifiedAt == nil {
+		logger.FromContext(ctx).Errorf("Account %d has not verified its email", userID)
+		return fmt.Errorf("%w", ErrEmailNotVerified)
+	}
+
+	return nil
+}
+
+// ensureStepUp returns ErrStepUpRequired unless the caller confirmed a second factor within window
+func ensureStepUp(ctx context.Context, window time.Duration) error {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok || !claims.MFAVerifiedWithin(window, time.Now()) {
+		logger.FromContext(ctx).Error("Step-up authentication required")
+		return fmt.Errorf("%w", ErrStepUpRequired)
+	}
+	return nil
+}
+
+// ensureKYCVerified returns ErrKYCRequired if the account has not passed identity verification
+func ensureKYCVerified(ctx context.Context, accounts AccountReader, userID int64) error {
+	acc, err := accounts.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if acc.KYCStatus != model.KYCVerified {
+		logger.FromContext(ctx).Errorf("Account %d has not passed KYC", userID)
+		return fmt.Errorf("%w", ErrKYCRequired)
+	}
+	return nil
+}
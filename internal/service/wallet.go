@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/config"
+	"github.com/CryptoCrowd/internal/logger"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/siwe"
+	"github.com/CryptoCrowd/internal/tracing"
+)
+
+var (
+	ErrInvalidSIWEMessage  = errors.New("invalid sign-in with ethereum message")
+	ErrInvalidSignature    = errors.New("signature does not match the message address")
+	ErrInvalidNonce        = errors.New("nonce is invalid, expired or already used")
+	ErrWalletAlreadyLinked = errors.New("wallet is already linked to an account")
+)
+
+// walletEmailDomain is a reserved domain for placeholder emails of accounts created by wallet login
+const walletEmailDomain = "wallet.invalid"
+
+// NonceRepository defines the interface for single-use SIWE nonce operations
+type NonceRepository interface {
+	Create(ctx context.Context, nonce string, expiresAt time.Time) error
+	Consume(ctx context.Context, nonce string) error
+}
+
+// WalletRepository defines the interface for wallet repository operations
+type WalletRepository interface {
+	GetByAddress(ctx context.Context, address string) (model.Wallet, error)
+	ListByUserID(ctx context.Context, userID int64) ([]model.Wallet, error)
+	Link(ctx context.Context, wallet model.Wallet) (model.Wallet, error)
+	CreateAccount(ctx context.Context, acc model.Account, wallet model.Wallet) (model.Wallet, error)
+}
+
+// WalletAuth service implements Sign-In With Ethereum (EIP-4361) login and wallet linking
+type WalletAuth struct {
+	nonces       NonceRepository
+	wallets      WalletRepository
+	secondFactor SecondFactor
+	sessions     SessionManager
+	screener     SanctionsScreener
+	domain       string
+	chainIDs     []int64
+	nonceTTL     time.Duration
+}
+
+// NewWalletAuth creates a new wallet authentication service
+func NewWalletAuth(
+	nonces NonceRepository,
+	wallets WalletRepository,
+	secondFactor SecondFactor,
+	sessions SessionManager,
+	screener SanctionsScreener,
+	cfg config.AuthConfig,
+) *WalletAuth {
+	return &WalletAuth{
+		nonces:       nonces,
+		wallets:      wallets,
+		secondFactor: secondFactor,
+		sessions:     sessions,
+		screener:     screener,
+		domain:       cfg.SIWEDomain,
+		chainIDs:     cfg.SIWEChainIDs,
+		nonceTTL:     time.Duration(cfg.SIWENonceTTL) * time.Minute,
+	}
+}
+
+// Nonce issues a single-use nonce to be embedded in the message the wallet signs
+func (w *WalletAuth) Nonce(ctx context.Context) (string, time.Time, error) {
+	ctx, span := tracing.Start(ctx, "WalletAuth.Nonce")
+	defer span.End()
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	nonce := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(w.nonceTTL)
+	if err := w.nonces.Create(ctx, nonce, expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return nonce, expiresAt, nil
+}
+
+// Login verifies a signed message and starts a session for the account the wallet is linked to.
+// An unknown wallet gets a new investor account
+func (w *WalletAuth) Login(
+	ctx context.Context,
+	message string,
+	signature string,
+	code string,
+	recoveryCode string,
+	client model.ClientInfo,
+) (string, auth.Claims, error) {
+	ctx, span := tracing.Start(ctx, "WalletAuth.Login")
+	defer span.End()
+
+	msg, err := w.verify(ctx, message, signature)
+	if err != nil {
+		return "", auth.Claims{}, err
+	}
+
+	address := strings.ToLower(msg.Address)
+	wallet, err := w.wallets.GetByAddress(ctx, address)
+	if errors.Is(err, repository.ErrWalletNotFound) {
+		wallet, err = w.createAccount(ctx, msg)
+	}
+	if err != nil {
+		return "", auth.Claims{}, err
+	}
+
+	enabled, err := w.secondFactor.IsEnabled(ctx, wallet.UserID)
+	if err != nil {
+		return "", auth.Claims{}, err
+	}
+
+	var mfaAt time.Time
+	if enabled {
+		if err = w.secondFactor.Verify(ctx, wallet.UserID, code, recoveryCode); err != nil {
+			return "", auth.Claims{}, err
+		}
+		mfaAt = time.Now()
+	}
+
+	return w.sessions.Start(ctx, wallet.UserID, client, mfaAt)
+}
+
+// Link verifies a signed message and links its wallet to the account of the current user
+func (w *WalletAuth) Link(ctx context.Context, userID int64, message string, signature string) (model.Wallet, error) {
+	ctx, span := tracing.Start(ctx, "WalletAuth.Link")
+	defer span.End()
+
+	msg, err := w.verify(ctx, message, signature)
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	wallet, err := w.wallets.Link(ctx, model.Wallet{
+		UserID:  userID,
+		Address: strings.ToLower(msg.Address),
+		ChainID: msg.ChainID,
+	})
+	if errors.Is(err, repository.ErrWalletAlreadyLinked) {
+		return model.Wallet{}, fmt.Errorf("%w", ErrWalletAlreadyLinked)
+	}
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	w.screenAccount(ctx, userID, model.ScreeningTriggerWalletLink)
+	return wallet, nil
+}
+
+// List returns wallets linked to the user's account
+func (w *WalletAuth) List(ctx context.Context, userID int64) ([]model.Wallet, error) {
+	ctx, span := tracing.Start(ctx, "WalletAuth.List")
+	defer span.End()
+
+	return w.wallets.ListByUserID(ctx, userID)
+}
+
+// verify checks the message fields, the signature and consumes the nonce. The nonce is consumed
+// only after the signature is checked so that forged requests cannot burn nonces of others
+func (w *WalletAuth) verify(ctx context.Context, message string, signature string) (*siwe.Message, error) {
+	msg, err := siwe.ParseMessage(message)
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to parse SIWE message: ", err)
+		return nil, fmt.Errorf("%w", ErrInvalidSIWEMessage)
+	}
+	if msg.Domain != w.domain {
+		logger.FromContext(ctx).Errorf("SIWE message is issued for domain %s", msg.Domain)
+		return nil, fmt.Errorf("%w: unexpected domain", ErrInvalidSIWEMessage)
+	}
+	if !slices.Contains(w.chainIDs, msg.ChainID) {
+		return nil, fmt.Errorf("%w: unsupported chain id %d", ErrInvalidSIWEMessage, msg.ChainID)
+	}
+	if !msg.ValidAt(time.Now()) {
+		return nil, fmt.Errorf("%w: message is expired or not yet valid", ErrInvalidSIWEMessage)
+	}
+
+	recovered, err := siwe.RecoverAddress(message, signature)
+	if err != nil || !strings.EqualFold(recovered, msg.Address) {
+		logger.FromContext(ctx).Errorf("Failed SIWE signature check for %s", msg.Address)
+		return nil, fmt.Errorf("%w", ErrInvalidSignature)
+	}
+
+	err = w.nonces.Consume(ctx, msg.Nonce)
+	if errors.Is(err, repository.ErrNonceInvalid) {
+		return nil, fmt.Errorf("%w", ErrInvalidNonce)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// createAccount registers an investor account for a wallet seen for the first time. The account
+// gets a placeholder email, has no password and stays unverified until the owner sets an email
+// through Account.SetEmail and confirms it
+func (w *WalletAuth) createAccount(ctx context.Context, msg *siwe.Message) (model.Wallet, error) {
+	address := strings.ToLower(msg.Address)
+	checksum := siwe.ChecksumAddress(msg.Address)
+
+	wallet, err := w.wallets.CreateAccount(ctx, model.Account{
+		Username: checksum[:6] + "…" + checksum[len(checksum)-4:],
+		Email:    address + "@" + walletEmailDomain,
+		Role:     "investor",
+	}, model.Wallet{
+		Address: address,
+		ChainID: msg.ChainID,
+	})
+	if errors.Is(err, repository.ErrUserAlreadyExists) || errors.Is(err, repository.ErrWalletAlreadyLinked) {
+		// Another login with the same wallet won the race; use the account it created
+		return w.wallets.GetByAddress(ctx, address)
+	}
+	if err != nil {
+		return model.Wallet{}, err
+	}
+
+	logger.FromContext(ctx).Infof("Created investor account %d for wallet %s", wallet.UserID, checksum)
+	w.screenAccount(ctx, wallet.UserID, model.ScreeningTriggerSignup)
+	return wallet, nil
+}
+
+// screenAccount screens the account after its wallets changed. A hit puts the account
+// on hold for review and does not refuse the login or the link
+func (w *WalletAuth) screenAccount(ctx context.Context, userID int64, trigger string) {
+	err := w.screener.ScreenAccount(ctx, userID, trigger)
+	if err != nil && !errors.Is(err, ErrSanctionsReview) {
+		logger.FromContext(ctx).Errorf("Failed to screen user %d: %v", userID, err)
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/auth"
+	"github.com/CryptoCrowd/internal/config"
+	"github.com/CryptoCrowd/internal/model"
+	"github.com/CryptoCrowd/internal/repository"
+	"github.com/CryptoCrowd/internal/siwe"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+const testSIWEDomain = "cryptocrowd.example"
+
+// memoryNonces keeps single-use nonces in memory like repository.PostgresSIWENonce
+type memoryNonces struct {
+	expires map[string]time.Time
+}
+
+func (m *memoryNonces) Create(_ context.Context, nonce string, expiresAt time.Time) error {
+	m.expires[nonce] = expiresAt
+	return nil
+}
+
+func (m *memoryNonces) Consume(_ context.Context, nonce string) error {
+	expiresAt, ok := m.expires[nonce]
+	if !ok || time.Now().After(expiresAt) {
+		return repository.ErrNonceInvalid
+	}
+	delete(m.expires, nonce)
+	return nil
+}
+
+// memoryWallets links wallets to accounts in memory
+type memoryWallets struct {
+	WalletRepository
+
+	byAddress map[string]model.Wallet
+	accounts  []model.Account
+}
+
+func (m *memoryWallets) GetByAddress(_ context.Context, address string) (model.Wallet, error) {
+	wallet, ok := m.byAddress[address]
+	if !ok {
+		return model.Wallet{}, repository.ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+func (m *memoryWallets) CreateAccount(_ context.Context, acc model.Account, wallet model.Wallet) (model.Wallet, error) {
+	acc.ID = int64(len(m.accounts) + 100)
+	m.accounts = append(m.accounts, acc)
+
+	wallet.UserID = acc.ID
+	m.byAddress[wallet.Address] = wallet
+	return wallet, nil
+}
+
+// noSecondFactor serves accounts without two-factor authentication
+type noSecondFactor struct {
+	SecondFactor
+}
+
+func (noSecondFactor) IsEnabled(context.Context, int64) (bool, error) { return false, nil }
+
+// startedSessions records the accounts sessions were started for
+type startedSessions struct {
+	SessionManager
+
+	userIDs []int64
+}
+
+func (s *startedSessions) Start(_ context.Context, userID int64, _ model.ClientInfo, _ time.Time) (string, auth.Claims, error) {
+	s.userIDs = append(s.userIDs, userID)
+	return "token", auth.Claims{UserID: userID}, nil
+}
+
+type walletFixture struct {
+	svc      *WalletAuth
+	nonces   *memoryNonces
+	wallets  *memoryWallets
+	sessions *startedSessions
+	key      *secp256k1.PrivateKey
+}
+
+func newWalletFixture(t *testing.T) walletFixture {
+	t.Helper()
+
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %v", err)
+	}
+
+	f := walletFixture{
+		nonces:   &memoryNonces{expires: make(map[string]time.Time)},
+		wallets:  &memoryWallets{byAddress: make(map[string]model.Wallet)},
+		sessions: &startedSessions{},
+		key:      key,
+	}
+	f.svc = NewWalletAuth(f.nonces, f.wallets, noSecondFactor{}, f.sessions, passScreening{}, config.AuthConfig{
+		SIWEDomain:   testSIWEDomain,
+		SIWEChainIDs: []int64{1, 137},
+		SIWENonceTTL: 10,
+	})
+	return f
+}
+
+// message returns a message of the fixture's wallet for a freshly issued nonce
+func (f walletFixture) message(t *testing.T) *siwe.Message {
+	t.Helper()
+
+	nonce, _, err := f.svc.Nonce(context.Background())
+	if err != nil {
+		t.Fatalf("Nonce: %v", err)
+	}
+
+	expiresAt := time.Now().Add(5 * time.Minute).UTC().Truncate(time.Second)
+	return &siwe.Message{
+		Scheme:         "https",
+		Domain:         testSIWEDomain,
+		Address:        siwe.PublicKeyToAddress(f.key.PubKey()),
+		Statement:      "Sign in to CryptoCrowd",
+		URI:            "https://" + testSIWEDomain,
+		Version:        "1",
+		ChainID:        1,
+		Nonce:          nonce,
+		IssuedAt:       time.Now().UTC().Truncate(time.Second),
+		ExpirationTime: &expiresAt,
+	}
+}
+
+// signSIWE signs the message the way personal_sign does and returns the r || s || v signature in hex
+func signSIWE(key *secp256k1.PrivateKey, message string) string {
+	compact := ecdsa.SignCompact(key, siwe.HashPersonalMessage(message), false)
+
+	sig := make([]byte, 65)
+	copy(sig, compact[1:])
+	sig[64] = compact[0]
+
+	return "0x" + hex.EncodeToString(sig)
+}
+
+func TestWalletAuthLogin(t *testing.T) {
+	other, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		edit         func(msg *siwe.Message)
+		signer       *secp256k1.PrivateKey
+		wantErr      error
+		wantConsumed bool
+	}{
+		{name: "valid message", wantConsumed: true},
+		{name: "other supported chain", edit: func(msg *siwe.Message) { msg.ChainID = 137 }, wantConsumed: true},
+		{name: "other domain", edit: func(msg *siwe.Message) { msg.Domain = "evil.example" }, wantErr: ErrInvalidSIWEMessage},
+		{name: "unsupported chain", edit: func(msg *siwe.Message) { msg.ChainID = 5 }, wantErr: ErrInvalidSIWEMessage},
+		{name: "expired", edit: func(msg *siwe.Message) {
+			expired := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+			msg.ExpirationTime = &expired
+		}, wantErr: ErrInvalidSIWEMessage},
+		{name: "not yet valid", edit: func(msg *siwe.Message) {
+			notBefore := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+			msg.NotBefore = &notBefore
+		}, wantErr: ErrInvalidSIWEMessage},
+		{name: "signed by another wallet", signer: other, wantErr: ErrInvalidSignature},
+		{name: "nonce not issued", edit: func(msg *siwe.Message) { msg.Nonce = "neverissued1" }, wantErr: ErrInvalidNonce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newWalletFixture(t)
+			msg := f.message(t)
+			issued := msg.Nonce
+			if tt.edit != nil {
+				tt.edit(msg)
+			}
+			signer := f.key
+			if tt.signer != nil {
+				signer = tt.signer
+			}
+
+			text := msg.String()
+			_, claims, err := f.svc.Login(context.Background(), text, signSIWE(signer, text), "", "", model.ClientInfo{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login: error = %v, want %v", err, tt.wantErr)
+			}
+
+			// A rejected message must not burn the nonce, so forged requests cannot lock out the owner
+			if _, pending := f.nonces.expires[issued]; pending == tt.wantConsumed {
+				t.Fatalf("nonce consumed = %v, want %v", !pending, tt.wantConsumed)
+			}
+			if tt.wantErr != nil {
+				if len(f.sessions.userIDs) != 0 {
+					t.Fatalf("sessions started for %v, want none", f.sessions.userIDs)
+				}
+				return
+			}
+
+			if len(f.wallets.accounts) != 1 {
+				t.Fatalf("created %d accounts, want 1", len(f.wallets.accounts))
+			}
+			acc := f.wallets.accounts[0]
+			if claims.UserID != acc.ID || acc.Role != "investor" {
+				t.Fatalf("session of user %d for account %+v", claims.UserID, acc)
+			}
+			if want := strings.ToLower(msg.Address) + "@" + walletEmailDomain; acc.Email != want {
+				t.Fatalf("account email = %s, want %s", acc.Email, want)
+			}
+		})
+	}
+}
+
+func TestWalletAuthNonceReuse(t *testing.T) {
+	f := newWalletFixture(t)
+	ctx := context.Background()
+	text := f.message(t).String()
+	signature := signSIWE(f.key, text)
+
+	if _, _, err := f.svc.Login(ctx, text, signature, "", "", model.ClientInfo{}); err != nil {
+		t.Fatalf("first Login: %v", err)
+	}
+	if _, _, err := f.svc.Login(ctx, text, signature, "", "", model.ClientInfo{}); !errors.Is(err, ErrInvalidNonce) {
+		t.Fatalf("replayed Login: error = %v, want ErrInvalidNonce", err)
+	}
+
+	// The second login with a new nonce finds the account created by the first one
+	text = f.message(t).String()
+	if _, _, err := f.svc.Login(ctx, text, signSIWE(f.key, text), "", "", model.ClientInfo{}); err != nil {
+		t.Fatalf("Login with a new nonce: %v", err)
+	}
+	if len(f.wallets.accounts) != 1 || len(f.sessions.userIDs) != 2 || f.sessions.userIDs[1] != f.sessions.userIDs[0] {
+		t.Fatalf("accounts = %+v, sessions = %v, want one account with two sessions", f.wallets.accounts, f.sessions.userIDs)
+	}
+}
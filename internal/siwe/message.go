@@ -0,0 +1,230 @@
+package siwe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerSuffix = " wants you to sign in with your Ethereum account:"
+	version      = "1"
+)
+
+var (
+	// ErrMalformedMessage возвращается, если текст не соответствует формату EIP-4361
+	ErrMalformedMessage = errors.New("сообщение не соответствует формату EIP-4361")
+)
+
+// Message - разобранное сообщение Sign-In With Ethereum (EIP-4361)
+type Message struct {
+	Scheme         string
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseMessage разбирает текст сообщения EIP-4361
+func ParseMessage(text string) (*Message, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 6 {
+		return nil, ErrMalformedMessage
+	}
+
+	msg := &Message{}
+
+	origin, ok := strings.CutSuffix(lines[0], headerSuffix)
+	if !ok || origin == "" {
+		return nil, fmt.Errorf("%w: некорректный заголовок", ErrMalformedMessage)
+	}
+	if scheme, domain, found := strings.Cut(origin, "://"); found {
+		msg.Scheme, msg.Domain = scheme, domain
+	} else {
+		msg.Domain = origin
+	}
+
+	msg.Address = lines[1]
+	if !IsHexAddress(msg.Address) {
+		return nil, fmt.Errorf("%w: некорректный адрес", ErrMalformedMessage)
+	}
+
+	if lines[2] != "" {
+		return nil, fmt.Errorf("%w: ожидается пустая строка после адреса", ErrMalformedMessage)
+	}
+
+	// Утверждение необязательно: за ним, как и без него, следует пустая строка
+	i := 3
+	if lines[i] != "" {
+		msg.Statement = lines[i]
+		i++
+	}
+	if i >= len(lines) || lines[i] != "" {
+		return nil, fmt.Errorf("%w: ожидается пустая строка перед полями", ErrMalformedMessage)
+	}
+	i++
+
+	fields, resources, err := parseFields(lines[i:])
+	if err != nil {
+		return nil, err
+	}
+	msg.Resources = resources
+
+	if err = msg.applyFields(fields); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func parseFields(lines []string) (map[string]string, []string, error) {
+	fields := make(map[string]string)
+	var resources []string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" && i == len(lines)-1 {
+			break
+		}
+
+		if line == "Resources:" {
+			for _, res := range lines[i+1:] {
+				uri, ok := strings.CutPrefix(res, "- ")
+				if !ok {
+					return nil, nil, fmt.Errorf("%w: некорректный ресурс", ErrMalformedMessage)
+				}
+				resources = append(resources, uri)
+			}
+			break
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: некорректная строка %q", ErrMalformedMessage, line)
+		}
+		if _, dup := fields[key]; dup {
+			return nil, nil, fmt.Errorf("%w: повторяющееся поле %s", ErrMalformedMessage, key)
+		}
+		fields[key] = value
+	}
+
+	return fields, resources, nil
+}
+
+func (m *Message) applyFields(fields map[string]string) error {
+	var err error
+
+	required := []string{"URI", "Version", "Chain ID", "Nonce", "Issued At"}
+	for _, key := range required {
+		if fields[key] == "" {
+			return fmt.Errorf("%w: отсутствует поле %s", ErrMalformedMessage, key)
+		}
+	}
+
+	m.URI = fields["URI"]
+	m.Version = fields["Version"]
+	if m.Version != version {
+		return fmt.Errorf("%w: неподдерживаемая версия %s", ErrMalformedMessage, m.Version)
+	}
+
+	if m.ChainID, err = strconv.ParseInt(fields["Chain ID"], 10, 64); err != nil || m.ChainID <= 0 {
+		return fmt.Errorf("%w: некорректный Chain ID", ErrMalformedMessage)
+	}
+
+	m.Nonce = fields["Nonce"]
+	if len(m.Nonce) < 8 || !isAlphanumeric(m.Nonce) {
+		return fmt.Errorf("%w: некорректный nonce", ErrMalformedMessage)
+	}
+
+	if m.IssuedAt, err = time.Parse(time.RFC3339, fields["Issued At"]); err != nil {
+		return fmt.Errorf("%w: некорректное поле Issued At", ErrMalformedMessage)
+	}
+	if m.ExpirationTime, err = parseOptionalTime(fields["Expiration Time"]); err != nil {
+		return fmt.Errorf("%w: некорректное поле Expiration Time", ErrMalformedMessage)
+	}
+	if m.NotBefore, err = parseOptionalTime(fields["Not Before"]); err != nil {
+		return fmt.Errorf("%w: некорректное поле Not Before", ErrMalformedMessage)
+	}
+	m.RequestID = fields["Request ID"]
+
+	return nil
+}
+
+// String формирует текст сообщения в формате EIP-4361. Именно этот текст подписывает кошелек
+func (m *Message) String() string {
+	var b strings.Builder
+
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + headerSuffix + "\n")
+	b.WriteString(m.Address + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("URI: " + m.URI + "\n")
+	b.WriteString("Version: " + m.Version + "\n")
+	b.WriteString("Chain ID: " + strconv.FormatInt(m.ChainID, 10) + "\n")
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + m.IssuedAt.Format(time.RFC3339))
+	if m.ExpirationTime != nil {
+		b.WriteString("\nExpiration Time: " + m.ExpirationTime.Format(time.RFC3339))
+	}
+	if m.NotBefore != nil {
+		b.WriteString("\nNot Before: " + m.NotBefore.Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		b.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, res := range m.Resources {
+			b.WriteString("\n- " + res)
+		}
+	}
+
+	return b.String()
+}
+
+// ValidAt проверяет сроки действия сообщения
+func (m *Message) ValidAt(now time.Time) bool {
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return false
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return false
+	}
+	return true
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
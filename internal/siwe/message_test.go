@@ -0,0 +1,171 @@
+package siwe
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMessage = `https://cryptocrowd.example wants you to sign in with your Ethereum account:
+0x2c7536E3605D9C16a7a3D7b1898e529396a65c23
+
+Sign in to CryptoCrowd
+
+URI: https://cryptocrowd.example/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756abcdef
+Issued At: 2026-10-18T12:00:00Z
+Expiration Time: 2026-10-18T12:10:00Z
+Not Before: 2026-10-18T11:59:00Z
+Request ID: req-1
+Resources:
+- https://cryptocrowd.example/terms
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq`
+
+func TestParseMessage(t *testing.T) {
+	msg, err := ParseMessage(testMessage)
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+
+	issuedAt := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	checks := []struct {
+		field string
+		got   any
+		want  any
+	}{
+		{field: "Scheme", got: msg.Scheme, want: "https"},
+		{field: "Domain", got: msg.Domain, want: "cryptocrowd.example"},
+		{field: "Address", got: msg.Address, want: testAddress},
+		{field: "Statement", got: msg.Statement, want: "Sign in to CryptoCrowd"},
+		{field: "URI", got: msg.URI, want: "https://cryptocrowd.example/login"},
+		{field: "ChainID", got: msg.ChainID, want: int64(1)},
+		{field: "Nonce", got: msg.Nonce, want: "32891756abcdef"},
+		{field: "IssuedAt", got: msg.IssuedAt, want: issuedAt},
+		{field: "ExpirationTime", got: *msg.ExpirationTime, want: issuedAt.Add(10 * time.Minute)},
+		{field: "NotBefore", got: *msg.NotBefore, want: issuedAt.Add(-time.Minute)},
+		{field: "RequestID", got: msg.RequestID, want: "req-1"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.field, c.got, c.want)
+		}
+	}
+	wantResources := []string{"https://cryptocrowd.example/terms", "ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq"}
+	if !slices.Equal(msg.Resources, wantResources) {
+		t.Errorf("Resources = %v, want %v", msg.Resources, wantResources)
+	}
+
+	if got := msg.String(); got != testMessage {
+		t.Fatalf("String() does not reproduce the signed text:\n%s", got)
+	}
+}
+
+func TestParseMessageOptionalFields(t *testing.T) {
+	text := "cryptocrowd.example wants you to sign in with your Ethereum account:\n" +
+		testAddress + "\n\n\n" +
+		"URI: https://cryptocrowd.example\nVersion: 1\nChain ID: 137\nNonce: abcdefgh\nIssued At: 2026-10-18T12:00:00Z"
+
+	msg, err := ParseMessage(strings.ReplaceAll(text, "\n", "\r\n"))
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+	if msg.Scheme != "" || msg.Statement != "" || msg.ExpirationTime != nil || msg.NotBefore != nil || msg.Resources != nil {
+		t.Fatalf("optional fields are set: %+v", msg)
+	}
+	if msg.ChainID != 137 {
+		t.Fatalf("ChainID = %d, want 137", msg.ChainID)
+	}
+	if got := msg.String(); got != text {
+		t.Fatalf("String() = %q, want %q", got, text)
+	}
+}
+
+func TestParseMessageMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string
+	}{
+		{name: "header", replace: [2]string{"wants you to sign in", "asks you to sign in"}},
+		{name: "address", replace: [2]string{testAddress, "0x1234"}},
+		{name: "address checksum", replace: [2]string{testAddress, strings.Replace(testAddress, "c7536E", "c7536e", 1)}},
+		{name: "missing blank line after address", replace: [2]string{testAddress + "\n\n", testAddress + "\n"}},
+		{name: "missing URI", replace: [2]string{"URI: https://cryptocrowd.example/login\n", ""}},
+		{name: "version", replace: [2]string{"Version: 1", "Version: 2"}},
+		{name: "chain id", replace: [2]string{"Chain ID: 1", "Chain ID: mainnet"}},
+		{name: "zero chain id", replace: [2]string{"Chain ID: 1", "Chain ID: 0"}},
+		{name: "short nonce", replace: [2]string{"Nonce: 32891756abcdef", "Nonce: abc"}},
+		{name: "nonce with symbols", replace: [2]string{"Nonce: 32891756abcdef", "Nonce: 32891756-abcdef"}},
+		{name: "issued at", replace: [2]string{"Issued At: 2026-10-18T12:00:00Z", "Issued At: yesterday"}},
+		{name: "expiration time", replace: [2]string{"Expiration Time: 2026-10-18T12:10:00Z", "Expiration Time: soon"}},
+		{name: "duplicate field", replace: [2]string{"Request ID: req-1", "Nonce: 12345678abcd"}},
+		{name: "line without value", replace: [2]string{"Request ID: req-1", "Request ID"}},
+		{name: "resource without dash", replace: [2]string{"- https://cryptocrowd.example/terms", "https://cryptocrowd.example/terms"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := strings.Replace(testMessage, tt.replace[0], tt.replace[1], 1)
+			if text == testMessage {
+				t.Fatal("test case does not change the message")
+			}
+			if _, err := ParseMessage(text); !errors.Is(err, ErrMalformedMessage) {
+				t.Fatalf("ParseMessage() error = %v, want ErrMalformedMessage", err)
+			}
+		})
+	}
+
+	if _, err := ParseMessage("too short"); !errors.Is(err, ErrMalformedMessage) {
+		t.Fatalf("ParseMessage() of a short text: error = %v, want ErrMalformedMessage", err)
+	}
+}
+
+func TestMessageValidAt(t *testing.T) {
+	msg, err := ParseMessage(testMessage)
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "before not before", at: msg.NotBefore.Add(-time.Second), want: false},
+		{name: "at not before", at: *msg.NotBefore, want: true},
+		{name: "within window", at: msg.IssuedAt, want: true},
+		{name: "at expiration", at: *msg.ExpirationTime, want: false},
+		{name: "after expiration", at: msg.ExpirationTime.Add(time.Hour), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := msg.ValidAt(tt.at); got != tt.want {
+				t.Fatalf("ValidAt(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+
+	msg.ExpirationTime, msg.NotBefore = nil, nil
+	if !msg.ValidAt(time.Now().Add(100 * 365 * 24 * time.Hour)) {
+		t.Fatal("message without time bounds must always be valid")
+	}
+}
+
+func TestMessageSignedByAddress(t *testing.T) {
+	msg, err := ParseMessage(testMessage)
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+
+	recovered, err := RecoverAddress(testMessage, signMessage(testKey(t), msg.String()))
+	if err != nil {
+		t.Fatalf("RecoverAddress: %v", err)
+	}
+	if recovered != msg.Address {
+		t.Fatalf("recovered %s, want %s", recovered, msg.Address)
+	}
+}
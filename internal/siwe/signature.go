@@ -0,0 +1,99 @@
+package siwe
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrInvalidSignature возвращается, если подпись повреждена или сделана другим адресом
+	ErrInvalidSignature = errors.New("недействительная подпись")
+)
+
+// Keccak256 вычисляет хеш Keccak-256, используемый в Ethereum
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// HashPersonalMessage вычисляет хеш сообщения так же, как personal_sign (EIP-191)
+func HashPersonalMessage(message string) []byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message))
+	return Keccak256([]byte(prefix), []byte(message))
+}
+
+// RecoverAddress восстанавливает адрес, подписавший сообщение через personal_sign.
+// Подпись передается в hex в формате r || s || v
+func RecoverAddress(message string, signatureHex string) (string, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signatureHex, "0x"))
+	if err != nil || len(sig) != 65 {
+		return "", fmt.Errorf("%w: ожидается 65 байт в hex", ErrInvalidSignature)
+	}
+
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", fmt.Errorf("%w: некорректный байт восстановления", ErrInvalidSignature)
+	}
+
+	// Компактный формат decred: байт восстановления (27 + v для несжатого ключа), затем r и s
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+
+	pub, _, err := ecdsa.RecoverCompact(compact, HashPersonalMessage(message))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	return PublicKeyToAddress(pub), nil
+}
+
+// PublicKeyToAddress вычисляет адрес Ethereum в формате EIP-55
+func PublicKeyToAddress(pub *secp256k1.PublicKey) string {
+	uncompressed := pub.SerializeUncompressed()
+	hash := Keccak256(uncompressed[1:])
+	return ChecksumAddress(hex.EncodeToString(hash[12:]))
+}
+
+// IsHexAddress проверяет, что строка - адрес Ethereum, и если в ней есть заглавные буквы, что контрольная сумма EIP-55 верна
+func IsHexAddress(address string) bool {
+	raw, ok := strings.CutPrefix(address, "0x")
+	if !ok || len(raw) != 40 {
+		return false
+	}
+	if _, err := hex.DecodeString(raw); err != nil {
+		return false
+	}
+
+	if raw == strings.ToLower(raw) || raw == strings.ToUpper(raw) {
+		return true
+	}
+	return ChecksumAddress(raw) == address
+}
+
+// ChecksumAddress приводит адрес к виду EIP-55
+func ChecksumAddress(address string) string {
+	raw := strings.ToLower(strings.TrimPrefix(address, "0x"))
+	hash := hex.EncodeToString(Keccak256([]byte(raw)))
+
+	out := []byte(raw)
+	for i, c := range out {
+		if c >= 'a' && c <= 'f' && hash[i] >= '8' {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
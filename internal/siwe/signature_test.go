@@ -0,0 +1,130 @@
+package siwe
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Ключ и подпись из примеров web3.js: web3.eth.accounts.sign("Some data", testKeyHex)
+const (
+	testKeyHex     = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testAddress    = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	testSignedData = "Some data"
+	testSignature  = "0xb91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+)
+
+// signMessage подписывает сообщение так же, как personal_sign, и возвращает подпись r || s || v в hex
+func signMessage(key *secp256k1.PrivateKey, message string) string {
+	compact := ecdsa.SignCompact(key, HashPersonalMessage(message), false)
+
+	sig := make([]byte, 65)
+	copy(sig, compact[1:])
+	sig[64] = compact[0]
+
+	return "0x" + hex.EncodeToString(sig)
+}
+
+func testKey(t *testing.T) *secp256k1.PrivateKey {
+	t.Helper()
+
+	raw, err := hex.DecodeString(testKeyHex)
+	if err != nil {
+		t.Fatalf("decode key: %v", err)
+	}
+	return secp256k1.PrivKeyFromBytes(raw)
+}
+
+func TestPublicKeyToAddress(t *testing.T) {
+	if got := PublicKeyToAddress(testKey(t).PubKey()); got != testAddress {
+		t.Fatalf("PublicKeyToAddress() = %s, want %s", got, testAddress)
+	}
+}
+
+func TestRecoverAddress(t *testing.T) {
+	key := testKey(t)
+	other, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %v", err)
+	}
+
+	// Подпись с v = 0/1 вместо 27/28, как ее возвращают некоторые кошельки
+	raw, _ := hex.DecodeString(strings.TrimPrefix(testSignature, "0x"))
+	raw[64] -= 27
+	lowV := hex.EncodeToString(raw)
+
+	tests := []struct {
+		name      string
+		message   string
+		signature string
+		want      string
+		wantErr   bool
+	}{
+		{name: "web3.js vector", message: testSignedData, signature: testSignature, want: testAddress},
+		{name: "recovery byte without offset", message: testSignedData, signature: lowV, want: testAddress},
+		{name: "locally signed", message: "hello", signature: signMessage(key, "hello"), want: testAddress},
+		{name: "other key", message: "hello", signature: signMessage(other, "hello"), want: PublicKeyToAddress(other.PubKey())},
+		{name: "tampered message", message: testSignedData + "!", signature: testSignature},
+		{name: "not hex", message: testSignedData, signature: "0xzz", wantErr: true},
+		{name: "short signature", message: testSignedData, signature: testSignature[:100], wantErr: true},
+		{name: "bad recovery byte", message: testSignedData, signature: testSignature[:130] + "1f", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RecoverAddress(tt.message, tt.signature)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Fatalf("RecoverAddress() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			// Подпись другого сообщения восстанавливается в случайный адрес либо не восстанавливается вовсе
+			if tt.want == "" {
+				if err == nil && got == testAddress {
+					t.Fatalf("RecoverAddress() of a tampered message = %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RecoverAddress(): %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("RecoverAddress() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHexAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{address: testAddress, want: true},
+		{address: strings.ToLower(testAddress), want: true},
+		{address: "0x" + strings.ToUpper(testAddress[2:]), want: true},
+		{address: strings.Replace(testAddress, "c7536E", "c7536e", 1), want: false},
+		{address: testAddress[2:], want: false},
+		{address: testAddress[:41], want: false},
+		{address: "0x" + strings.Repeat("g", 40), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if got := IsHexAddress(tt.address); got != tt.want {
+				t.Fatalf("IsHexAddress(%q) = %v, want %v", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksumAddress(t *testing.T) {
+	if got := ChecksumAddress(strings.ToLower(testAddress)); got != testAddress {
+		t.Fatalf("ChecksumAddress() = %s, want %s", got, testAddress)
+	}
+}
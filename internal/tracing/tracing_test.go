@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CryptoCrowd/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// restoreGlobals возвращает глобальные провайдер и пропагатор после теста
+func restoreGlobals(t *testing.T) {
+	t.Helper()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.TracingConfig
+		wantErr bool
+	}{
+		{name: "none", cfg: config.TracingConfig{Exporter: "none"}},
+		{name: "stdout", cfg: config.TracingConfig{Exporter: "stdout", SampleRatio: 1, ServiceName: "cryptocrowd"}},
+		{name: "otlp grpc", cfg: config.TracingConfig{Exporter: "otlp", Protocol: "grpc", Endpoint: "localhost:4317", Insecure: true, SampleRatio: 1, ServiceName: "cryptocrowd"}},
+		{name: "otlp http", cfg: config.TracingConfig{Exporter: "otlp", Protocol: "http", Endpoint: "localhost:4318", Insecure: true, SampleRatio: 1, ServiceName: "cryptocrowd"}},
+		{name: "unknown exporter", cfg: config.TracingConfig{Exporter: "zipkin"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreGlobals(t)
+
+			shutdown, err := Init(context.Background(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Init: error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// Коллектор недоступен, но без накопленных спанов завершение не обращается к нему
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err = shutdown(ctx); err != nil {
+				t.Fatalf("shutdown: %v", err)
+			}
+
+			// Контекст трассировки передается дальше при любом экспортере
+			fields := otel.GetTextMapPropagator().Fields()
+			if len(fields) < 2 || fields[0] != "traceparent" {
+				t.Fatalf("propagator fields = %v, want W3C Trace Context and Baggage", fields)
+			}
+		})
+	}
+}
+
+func TestInitNoneKeepsIncomingTrace(t *testing.T) {
+	restoreGlobals(t)
+
+	if _, err := Init(context.Background(), config.TracingConfig{Exporter: "none"}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+
+	// Спаны не записываются, но исходящие запросы продолжают трассу вызывающей стороны
+	ctx, span := Start(ctx, "Test.Operation")
+	defer span.End()
+	if span.IsRecording() {
+		t.Fatal("span is recorded with exporter none")
+	}
+
+	out := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(out))
+	if got := out.Get("traceparent"); got != header.Get("traceparent") {
+		t.Fatalf("outgoing traceparent = %q, want %q", got, header.Get("traceparent"))
+	}
+}
+
+func TestStart(t *testing.T) {
+	restoreGlobals(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := Start(context.Background(), "Investment.Create")
+	_, child := Start(ctx, "Repository.Create", trace.WithSpanKind(trace.SpanKindClient))
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans ended, want 2", len(spans))
+	}
+
+	gotChild, gotParent := spans[0], spans[1]
+	if gotChild.Name() != "Repository.Create" || gotParent.Name() != "Investment.Create" {
+		t.Fatalf("span names = %q, %q", gotChild.Name(), gotParent.Name())
+	}
+	if gotChild.Parent().SpanID() != gotParent.SpanContext().SpanID() {
+		t.Fatal("child span is not linked to its parent")
+	}
+	if gotChild.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("span kind = %v, want %v", gotChild.SpanKind(), trace.SpanKindClient)
+	}
+	if got := gotParent.InstrumentationScope().Name; got != instrumentationName {
+		t.Fatalf("instrumentation scope = %q, want %q", got, instrumentationName)
+	}
+}
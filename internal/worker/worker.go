@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CryptoCrowd/internal/logger"
+)
+
+// Job - периодическая фоновая задача
+type Job func(ctx context.Context) error
+
+// Heartbeat - состояние фоновой задачи для проверки готовности
+type Heartbeat struct {
+	Name      string
+	Interval  time.Duration
+	LastBeat  time.Time // время запуска цикла или завершения последнего выполнения задачи
+	LastError string    // ошибка последнего выполнения, пустая при успехе
+}
+
+// Stale сообщает, что задача не подавала признаков жизни дольше двух интервалов,
+// то есть зависла или ее цикл завершился
+func (h Heartbeat) Stale(now time.Time) bool {
+	return now.Sub(h.LastBeat) > 2*h.Interval
+}
+
+var heartbeats = struct {
+	sync.Mutex
+	byName map[string]*Heartbeat
+}{byName: make(map[string]*Heartbeat)}
+
+// Heartbeats возвращает состояние всех запущенных через RunPeriodic задач, упорядоченное по имени
+func Heartbeats() []Heartbeat {
+	heartbeats.Lock()
+	defer heartbeats.Unlock()
+
+	result := make([]Heartbeat, 0, len(heartbeats.byName))
+	for _, hb := range heartbeats.byName {
+		result = append(result, *hb)
+	}
+	slices.SortFunc(result, func(a, b Heartbeat) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
+
+func beat(name string, interval time.Duration, err error) {
+	heartbeats.Lock()
+	defer heartbeats.Unlock()
+
+	hb := &Heartbeat{Name: name, Interval: interval, LastBeat: time.Now()}
+	if err != nil {
+		hb.LastError = err.Error()
+	}
+	heartbeats.byName[name] = hb
+}
+
+// RunPeriodic выполняет задачу с заданным интервалом до отмены контекста.
+// Ошибки задачи логируются и не прерывают дальнейшие запуски
+func RunPeriodic(ctx context.Context, name string, interval time.Duration, job Job) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	beat(name, interval, nil)
+
+	logger.Debugf("Фоновая задача %s запущена с интервалом %s", name, interval)
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debugf("Фоновая задача %s остановлена", name)
+			return
+		case <-ticker.C:
+			err := job(ctx)
+			if err != nil {
+				logger.Errorf("ошибка выполнения фоновой задачи %s: %v", name, err)
+			}
+			beat(name, interval, err)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
 
+	"github.com/jackc/pgx/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 )
@@ -34,3 +36,72 @@ func Run(dbURL string, command string) error {
 
 	return nil
 }
+
+// Version возвращает версию последней примененной миграции
+func Version(dbURL string) (int64, error) {
+	db, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось открыть подключение к БД: %w", err)
+	}
+	defer db.Close()
+
+	if err = goose.SetDialect("postgres"); err != nil {
+		return 0, fmt.Errorf("не удалось установить диалект: %w", err)
+	}
+
+	version, err := goose.GetDBVersionContext(context.Background(), db)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения версии схемы: %w", err)
+	}
+	return version, nil
+}
+
+// RowQuerier выполняет запрос, возвращающий одну строку, например *pgxpool.Pool
+type RowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Checker сравнивает версию схемы БД со встроенными в приложение миграциями. Список миграций
+// читается один раз при создании, а версия схемы запрашивается через пул приложения, поэтому
+// проверка не открывает новых подключений и не меняет глобальные настройки goose
+type Checker struct {
+	conn     RowQuerier
+	versions []int64
+}
+
+// NewChecker создает проверку версии схемы, выполняющую запросы через conn
+func NewChecker(conn RowQuerier) (*Checker, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения списка миграций: %w", err)
+	}
+
+	versions := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		version, err := goose.NumericComponent(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("некорректное имя файла миграции %s: %w", entry.Name(), err)
+		}
+		versions = append(versions, version)
+	}
+
+	return &Checker{conn: conn, versions: versions}, nil
+}
+
+// Pending возвращает число встроенных миграций, которые еще не применены к БД
+func (c *Checker) Pending(ctx context.Context) (int, error) {
+	var version int64
+	err := c.conn.QueryRow(ctx,
+		`SELECT COALESCE(MAX(version_id), 0) FROM `+goose.DefaultTablename+` WHERE is_applied`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения версии схемы: %w", err)
+	}
+
+	pending := 0
+	for _, v := range c.versions {
+		if v > version {
+			pending++
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type versionRow struct {
+	version int64
+	err     error
+}
+
+func (r versionRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int64) = r.version
+	return nil
+}
+
+type fakeConn struct {
+	row versionRow
+}
+
+func (c fakeConn) QueryRow(context.Context, string, ...any) pgx.Row {
+	return c.row
+}
+
+func TestCheckerPending(t *testing.T) {
+	checker, err := NewChecker(fakeConn{})
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	total := len(checker.versions)
+	if total == 0 {
+		t.Fatal("no embedded migrations found")
+	}
+	latest := checker.versions[total-1]
+
+	tests := []struct {
+		name    string
+		row     versionRow
+		want    int
+		wantErr bool
+	}{
+		{name: "empty database", row: versionRow{version: 0}, want: total},
+		{name: "up to date", row: versionRow{version: latest}, want: 0},
+		{name: "one behind", row: versionRow{version: checker.versions[total-2]}, want: 1},
+		{name: "ahead of the application", row: versionRow{version: latest + 1}, want: 0},
+		{name: "query fails", row: versionRow{err: errors.New("connection refused")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker.conn = fakeConn{row: tt.row}
+
+			got, err := checker.Pending(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Pending() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
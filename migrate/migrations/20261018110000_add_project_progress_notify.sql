@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_project_progress() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('project_progress', json_build_object(
+        'project_id', NEW.id,
+        'amount_raised', NEW.amount_raised::TEXT,
+        'backers', (SELECT COUNT(DISTINCT user_id) FROM investments WHERE project_id = NEW.id),
+        'status', NEW.status
+    )::TEXT);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER projects_progress_notify
+    AFTER UPDATE OF amount_raised, status ON projects
+    FOR EACH ROW
+    WHEN (OLD.amount_raised IS DISTINCT FROM NEW.amount_raised OR OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_project_progress();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS projects_progress_notify ON projects;
+DROP FUNCTION IF EXISTS notify_project_progress();
+-- +goose StatementEnd
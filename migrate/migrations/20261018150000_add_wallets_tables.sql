@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS siwe_nonces (
+    nonce VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_siwe_nonces_expires_at ON siwe_nonces (expires_at);
+
+CREATE TABLE IF NOT EXISTS account_wallets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL,
+    chain_id BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT account_wallets_address_key UNIQUE (address)
+);
+
+CREATE INDEX idx_account_wallets_user_id ON account_wallets (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_wallets;
+DROP TABLE IF EXISTS siwe_nonces;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS kyc_status VARCHAR(16) NOT NULL DEFAULT 'unverified';
+
+CREATE TABLE IF NOT EXISTS kyc_applications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    provider VARCHAR(32) NOT NULL,
+    provider_ref VARCHAR(255) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    reviewer_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_kyc_applications_user_id ON kyc_applications (user_id);
+CREATE INDEX idx_kyc_applications_status ON kyc_applications (status);
+
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    application_id INTEGER REFERENCES kyc_applications(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    uploaded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_kyc_documents_user_id ON kyc_documents (user_id);
+CREATE INDEX idx_kyc_documents_application_id ON kyc_documents (application_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kyc_applications;
+ALTER TABLE users DROP COLUMN IF EXISTS kyc_status;
+-- +goose StatementEnd
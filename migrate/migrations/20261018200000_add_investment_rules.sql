@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS country VARCHAR(2) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS project_investment_rules (
+    project_id INTEGER PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    annual_cap NUMERIC(36,18),
+    min_ticket NUMERIC(36,18),
+    max_ticket NUMERIC(36,18),
+    max_project_share NUMERIC(5,4),
+    blocked_countries VARCHAR(2)[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS project_investment_rules;
+ALTER TABLE users DROP COLUMN IF EXISTS country;
+-- +goose StatementEnd
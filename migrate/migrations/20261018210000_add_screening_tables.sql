@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS screening_hits (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    list_source VARCHAR(255) NOT NULL,
+    entry_id VARCHAR(255) NOT NULL,
+    entry_name VARCHAR(255) NOT NULL DEFAULT '',
+    score DOUBLE PRECISION NOT NULL,
+    trigger VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    reviewer_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    -- Отклоненное совпадение не создается повторно при следующей проверке
+    UNIQUE (user_id, kind, value, list_source, entry_id)
+);
+
+CREATE INDEX idx_screening_hits_status ON screening_hits (status);
+
+CREATE TABLE IF NOT EXISTS screening_runs (
+    id SERIAL PRIMARY KEY,
+    list_version VARCHAR(64) NOT NULL,
+    screened BIGINT NOT NULL DEFAULT 0,
+    hits BIGINT NOT NULL DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS screening_runs;
+DROP TABLE IF EXISTS screening_hits;
+-- +goose StatementEnd
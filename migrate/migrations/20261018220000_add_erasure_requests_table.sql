@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS erasure_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- У пользователя может быть только один ожидающий запрос на удаление
+CREATE UNIQUE INDEX idx_erasure_requests_pending ON erasure_requests (user_id) WHERE status = 'pending';
+CREATE INDEX idx_erasure_requests_scheduled_at ON erasure_requests (scheduled_at) WHERE status = 'pending';
+
+-- Финансовая история должна пережить удаление пользователя: вместо каскадного
+-- удаления инвестиций и проектов пользователь обезличивается
+ALTER TABLE investments DROP CONSTRAINT IF EXISTS investments_user_id_fkey;
+ALTER TABLE investments ADD CONSTRAINT investments_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS projects_owner_id_fkey;
+ALTER TABLE projects ADD CONSTRAINT projects_owner_id_fkey
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE RESTRICT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS projects_owner_id_fkey;
+ALTER TABLE projects ADD CONSTRAINT projects_owner_id_fkey
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE investments DROP CONSTRAINT IF EXISTS investments_user_id_fkey;
+ALTER TABLE investments ADD CONSTRAINT investments_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+DROP TABLE IF EXISTS erasure_requests;
+ALTER TABLE users DROP COLUMN IF EXISTS erased_at;
+-- +goose StatementEnd
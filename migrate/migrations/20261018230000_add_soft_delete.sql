@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE investments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_projects_deleted_at ON projects (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_investments_deleted_at ON investments (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Окончательное удаление проекта не должно уносить с собой инвестиции в него
+ALTER TABLE investments DROP CONSTRAINT IF EXISTS investments_project_id_fkey;
+ALTER TABLE investments ADD CONSTRAINT investments_project_id_fkey
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE RESTRICT;
+
+-- Удаленные инвестиции не учитываются в числе инвесторов, как и в GetProgress
+CREATE OR REPLACE FUNCTION notify_project_progress() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('project_progress', json_build_object(
+        'project_id', NEW.id,
+        'amount_raised', NEW.amount_raised::TEXT,
+        'backers', (SELECT COUNT(DISTINCT user_id) FROM investments WHERE project_id = NEW.id AND deleted_at IS NULL),
+        'status', NEW.status
+    )::TEXT);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_project_progress() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('project_progress', json_build_object(
+        'project_id', NEW.id,
+        'amount_raised', NEW.amount_raised::TEXT,
+        'backers', (SELECT COUNT(DISTINCT user_id) FROM investments WHERE project_id = NEW.id),
+        'status', NEW.status
+    )::TEXT);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE investments DROP CONSTRAINT IF EXISTS investments_project_id_fkey;
+ALTER TABLE investments ADD CONSTRAINT investments_project_id_fkey
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_investments_deleted_at;
+DROP INDEX IF EXISTS idx_projects_deleted_at;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+
+ALTER TABLE investments DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE projects DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Версия увеличивается при каждом изменении записи через API и служит ETag для
+-- оптимистичной блокировки. Служебные поля (собранная сумма, статус KYC) версию не меняют
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE investments ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE investments DROP COLUMN IF EXISTS version;
+ALTER TABLE projects DROP COLUMN IF EXISTS version;
+ALTER TABLE users DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
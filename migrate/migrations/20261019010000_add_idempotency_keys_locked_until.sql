@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Срок резервирования ключа запросом, который еще выполняется. Если запрос не завершился
+-- к этому времени (процесс упал или ответ не сохранился), ключ можно занять заново,
+-- не дожидаясь expires_at
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS locked_until;
+-- +goose StatementEnd